@@ -27,6 +27,10 @@ var MODELS_TO_MIGRATE = []any{
 	&User{},
 	&LoadTest{},
 	&TestData{},
+	&ColumnMappingProfile{},
+	&ImportError{},
+	&LoadTestJob{},
+	&LoadTestBatch{},
 }
 
 func main() {
@@ -8,21 +8,31 @@ import (
 )
 
 type Config struct {
-	GeneralVersion       string `mapstructure:"GENERAL_VERSION"`
-	Environment          string `mapstructure:"ENVIRONMENT"`
-	ServerPort           int    `mapstructure:"SERVER_PORT"`
-	DatabaseHost         string `mapstructure:"DB_HOST"`
-	DatabasePort         int    `mapstructure:"DB_PORT"`
-	DatabaseName         string `mapstructure:"DB_NAME"`
-	DatabaseUser         string `mapstructure:"DB_USER"`
-	DatabasePassword     string `mapstructure:"DB_PASSWORD"`
-	DatabaseCacheAddress string `mapstructure:"DB_CACHE_ADDRESS"`
-	DatabaseCachePort    int    `mapstructure:"DB_CACHE_PORT"`
-	DatabaseCacheReset   int    `mapstructure:"DB_CACHE_RESET"`
-	CorsAllowOrigins     string `mapstructure:"CORS_ALLOW_ORIGINS"`
-	SecuritySalt         int    `mapstructure:"SECURITY_SALT"`
-	SecurityPepper       string `mapstructure:"SECURITY_PEPPER"`
-	SecurityJwtSecret    string `mapstructure:"SECURITY_JWT_SECRET"`
+	GeneralVersion                    string `mapstructure:"GENERAL_VERSION"`
+	Environment                       string `mapstructure:"ENVIRONMENT"`
+	ServerPort                        int    `mapstructure:"SERVER_PORT"`
+	ServerBodyLimitMB                 int    `mapstructure:"SERVER_BODY_LIMIT_MB"`
+	ServerImportReadTimeout           int    `mapstructure:"SERVER_IMPORT_READ_TIMEOUT"`
+	DatabaseHost                      string `mapstructure:"DB_HOST"`
+	DatabasePort                      int    `mapstructure:"DB_PORT"`
+	DatabaseName                      string `mapstructure:"DB_NAME"`
+	DatabaseUser                      string `mapstructure:"DB_USER"`
+	DatabasePassword                  string `mapstructure:"DB_PASSWORD"`
+	DatabaseCacheAddress              string `mapstructure:"DB_CACHE_ADDRESS"`
+	DatabaseCachePort                 int    `mapstructure:"DB_CACHE_PORT"`
+	DatabaseCacheReset                int    `mapstructure:"DB_CACHE_RESET"`
+	CorsAllowOrigins                  string `mapstructure:"CORS_ALLOW_ORIGINS"`
+	SecuritySalt                      int    `mapstructure:"SECURITY_SALT"`
+	SecurityPepper                    string `mapstructure:"SECURITY_PEPPER"`
+	SecurityJwtSecret                 string `mapstructure:"SECURITY_JWT_SECRET"`
+	LoadTestMaxConcurrent             int    `mapstructure:"LOAD_TEST_MAX_CONCURRENT"`
+	LoadTestRetryOptimizedMaxAttempts int    `mapstructure:"LOAD_TEST_RETRY_OPTIMIZED_MAX_ATTEMPTS"`
+	LoadTestRetryLudicrousMaxAttempts int    `mapstructure:"LOAD_TEST_RETRY_LUDICROUS_MAX_ATTEMPTS"`
+	LoadTestRetryPlaidMaxAttempts     int    `mapstructure:"LOAD_TEST_RETRY_PLAID_MAX_ATTEMPTS"`
+	LoadTestRetryBaseDelayMs          int    `mapstructure:"LOAD_TEST_RETRY_BASE_DELAY_MS"`
+	LoadTestRetryMaxDelayMs           int    `mapstructure:"LOAD_TEST_RETRY_MAX_DELAY_MS"`
+	IdempotencyTTLHours               int    `mapstructure:"IDEMPOTENCY_TTL_HOURS"`
+	ImportSpoolMaxMB                  int    `mapstructure:"IMPORT_SPOOL_MAX_MB"`
 	// SessionCookieName    string `mapstructure:"SESSION_COOKIE_NAME"`
 }
 
@@ -37,9 +47,12 @@ func InitConfig() (Config, error) {
 
 	// Bind environment variables to config keys
 	envVars := []string{
-		"GENERAL_VERSION", "ENVIRONMENT", "SERVER_PORT", "DB_HOST", "DB_PORT", "DB_NAME", "DB_USER", "DB_PASSWORD",
+		"GENERAL_VERSION", "ENVIRONMENT", "SERVER_PORT", "SERVER_BODY_LIMIT_MB", "SERVER_IMPORT_READ_TIMEOUT", "DB_HOST", "DB_PORT", "DB_NAME", "DB_USER", "DB_PASSWORD",
 		"DB_CACHE_ADDRESS", "DB_CACHE_PORT", "DB_CACHE_RESET",
 		"CORS_ALLOW_ORIGINS", "SECURITY_SALT", "SECURITY_PEPPER", "SECURITY_JWT_SECRET",
+		"LOAD_TEST_MAX_CONCURRENT", "LOAD_TEST_RETRY_OPTIMIZED_MAX_ATTEMPTS", "LOAD_TEST_RETRY_LUDICROUS_MAX_ATTEMPTS",
+		"LOAD_TEST_RETRY_PLAID_MAX_ATTEMPTS", "LOAD_TEST_RETRY_BASE_DELAY_MS", "LOAD_TEST_RETRY_MAX_DELAY_MS",
+		"IDEMPOTENCY_TTL_HOURS", "IMPORT_SPOOL_MAX_MB",
 	}
 	
 	for _, env := range envVars {
@@ -28,6 +28,10 @@ type App struct {
 	UserRepo repositories.UserRepository
 	LoadTestRepo repositories.LoadTestRepository
 	TestDataRepo repositories.TestDataRepository
+	ColumnMappingRepo repositories.ColumnMappingRepository
+	ImportErrorRepo repositories.ImportErrorRepository
+	LoadTestJobRepo repositories.LoadTestJobRepository
+	IdempotencyRepo repositories.IdempotencyRepository
 
 	// Controllers
 	UserController *userController.UserController
@@ -35,6 +39,9 @@ type App struct {
 	OptimizedOnlyController *controllers.OptimizedOnlyController
 	LudicrousOnlyController *controllers.LudicrousOnlyController
 	PlaidController *controllers.PlaidController
+	ColumnMappingController *controllers.ColumnMappingController
+	DateController *controllers.DateController
+	LoadTestQueue *controllers.LoadTestQueue
 }
 
 func New() (*App, error) {
@@ -59,6 +66,10 @@ func New() (*App, error) {
 	userRepo := repositories.New(db)
 	loadTestRepo := repositories.NewLoadTest(db)
 	testDataRepo := repositories.NewTestData(db)
+	columnMappingRepo := repositories.NewColumnMapping(db)
+	importErrorRepo := repositories.NewImportError(db)
+	loadTestJobRepo := repositories.NewLoadTestJob(db)
+	idempotencyRepo := repositories.NewIdempotency(db)
 
 	websocket, err := websockets.New(db, eventBus, config)
 	if err != nil {
@@ -72,9 +83,13 @@ func New() (*App, error) {
 	if err != nil {
 		return &App{}, log.Err("failed to create plaid controller", err)
 	}
-	loadTestController := controllers.NewLoadTestController(loadTestRepo, testDataRepo, db, websocket, config, plaidController)
-	optimizedOnlyController := controllers.NewOptimizedOnlyController(loadTestRepo, testDataRepo, db, websocket, config)
-	ludicrousOnlyController := controllers.NewLudicrousOnlyController(loadTestRepo, testDataRepo, db, websocket, config)
+	runRegistry := controllers.NewRunRegistry()
+	loadTestQueue := controllers.NewLoadTestQueue(loadTestJobRepo, loadTestRepo, runRegistry, websocket, eventBus, config)
+	loadTestController := controllers.NewLoadTestController(loadTestRepo, testDataRepo, columnMappingRepo, importErrorRepo, idempotencyRepo, db, websocket, config, plaidController, runRegistry, loadTestQueue)
+	optimizedOnlyController := controllers.NewOptimizedOnlyController(loadTestRepo, testDataRepo, db, websocket, config, loadTestQueue)
+	ludicrousOnlyController := controllers.NewLudicrousOnlyController(loadTestRepo, testDataRepo, db, websocket, config, loadTestQueue)
+	columnMappingController := controllers.NewColumnMappingController(columnMappingRepo)
+	dateController := controllers.NewDateController()
 
 	app := &App{
 		Database:           db,
@@ -84,11 +99,18 @@ func New() (*App, error) {
 		UserRepo:           userRepo,
 		LoadTestRepo:       loadTestRepo,
 		TestDataRepo:       testDataRepo,
+		ColumnMappingRepo:  columnMappingRepo,
+		ImportErrorRepo:    importErrorRepo,
+		LoadTestJobRepo:    loadTestJobRepo,
+		IdempotencyRepo:    idempotencyRepo,
 		UserController:     userController,
 		LoadTestController: loadTestController,
 		OptimizedOnlyController: optimizedOnlyController,
 		LudicrousOnlyController: ludicrousOnlyController,
 		PlaidController:    plaidController,
+		ColumnMappingController: columnMappingController,
+		DateController:     dateController,
+		LoadTestQueue:      loadTestQueue,
 		Websocket:          websocket,
 		EventBus:           eventBus,
 	}
@@ -97,6 +119,11 @@ func New() (*App, error) {
 		return &App{}, log.Err("failed to validate app", err)
 	}
 
+	// Start dispatching queued load tests once every controller is wired
+	if err := loadTestQueue.Start(loadTestController.RunJob); err != nil {
+		return &App{}, log.Err("failed to start load test queue", err)
+	}
+
 	return app, nil
 }
 
@@ -119,10 +146,17 @@ func (a *App) validate() error {
 		a.OptimizedOnlyController,
 		a.LudicrousOnlyController,
 		a.PlaidController,
+		a.ColumnMappingController,
+		a.DateController,
+		a.LoadTestQueue,
 		a.Middleware,
 		a.UserRepo,
 		a.LoadTestRepo,
 		a.TestDataRepo,
+		a.ColumnMappingRepo,
+		a.ImportErrorRepo,
+		a.LoadTestJobRepo,
+		a.IdempotencyRepo,
 	}
 
 	for _, check := range nilChecks {
@@ -141,6 +175,12 @@ func (a *App) Close() (err error) {
 		}
 	}
 
+	if a.LoadTestQueue != nil {
+		if closeErr := a.LoadTestQueue.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+
 	if a.PlaidController != nil {
 		if closeErr := a.PlaidController.Close(); closeErr != nil {
 			err = closeErr
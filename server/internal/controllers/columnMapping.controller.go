@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/repositories"
+)
+
+type ColumnMappingController struct {
+	mappingRepo repositories.ColumnMappingRepository
+	log         logger.Logger
+}
+
+func NewColumnMappingController(
+	mappingRepo repositories.ColumnMappingRepository,
+) *ColumnMappingController {
+	return &ColumnMappingController{
+		mappingRepo: mappingRepo,
+		log:         logger.New("columnMappingController"),
+	}
+}
+
+// CreateProfile validates and stores a new column mapping profile
+func (c *ColumnMappingController) CreateProfile(
+	ctx context.Context,
+	req *ColumnMappingProfileRequest,
+) (*ColumnMappingProfile, error) {
+	log := c.log.Function("CreateProfile")
+
+	profile := &ColumnMappingProfile{
+		Name:        req.Name,
+		Description: req.Description,
+		Mappings:    req.Mappings,
+		Flatten:     req.Flatten,
+		Layout:      req.Layout,
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.mappingRepo.Create(ctx, profile); err != nil {
+		return nil, fmt.Errorf("failed to create column mapping profile: %w", err)
+	}
+
+	log.Info("column mapping profile created", "profileId", profile.ID, "name", profile.Name)
+	return profile, nil
+}
+
+// UpdateProfile replaces the name, description, mappings, flattening rules and fixed-width layout
+// of an existing profile
+func (c *ColumnMappingController) UpdateProfile(
+	ctx context.Context,
+	id string,
+	req *ColumnMappingProfileRequest,
+) (*ColumnMappingProfile, error) {
+	log := c.log.Function("UpdateProfile")
+
+	profile, err := c.mappingRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Name = req.Name
+	profile.Description = req.Description
+	profile.Mappings = req.Mappings
+	profile.Flatten = req.Flatten
+	profile.Layout = req.Layout
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.mappingRepo.Update(ctx, profile); err != nil {
+		return nil, fmt.Errorf("failed to update column mapping profile: %w", err)
+	}
+
+	log.Info("column mapping profile updated", "profileId", profile.ID, "name", profile.Name)
+	return profile, nil
+}
+
+// GetProfileByID retrieves a column mapping profile by ID
+func (c *ColumnMappingController) GetProfileByID(
+	ctx context.Context,
+	id string,
+) (*ColumnMappingProfile, error) {
+	return c.mappingRepo.GetByID(ctx, id)
+}
+
+// GetAllProfiles retrieves all column mapping profiles
+func (c *ColumnMappingController) GetAllProfiles(
+	ctx context.Context,
+) ([]*ColumnMappingProfile, error) {
+	return c.mappingRepo.GetAll(ctx)
+}
+
+// DeleteProfile removes a column mapping profile
+func (c *ColumnMappingController) DeleteProfile(ctx context.Context, id string) error {
+	return c.mappingRepo.Delete(ctx, id)
+}
@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	. "server/internal/models"
+	"server/internal/utils"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// dialectSampleSize is how much of an upload is inspected to sniff its dialect and format
+const dialectSampleSize = 64 * 1024
+
+// dialectMaxHeaderRow is the furthest line a header row is looked for
+const dialectMaxHeaderRow = 20
+
+// DIALECT_DELIMITERS are the field separators the sniffer chooses between
+var DIALECT_DELIMITERS = []rune{',', ';', '\t', '|'}
+
+// defaultCSVDialect is the layout of the files this service generates
+func defaultCSVDialect() CSVDialect {
+	return CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE}
+}
+
+// sniffCSVDialect detects the layout of a delimited file from a sample of its first lines. The
+// delimiter is the candidate found the same number of times on the most lines, and the header is
+// the first line with that many delimiters, so title lines above it are skipped.
+func sniffCSVDialect(sample []byte) CSVDialect {
+	dialect := defaultCSVDialect()
+	lines, lineNumbers := sampleLines(sample)
+	if len(lines) == 0 {
+		return dialect
+	}
+
+	dialect.Quote = sniffQuote(lines)
+	quote, _ := utf8.DecodeRuneInString(dialect.Quote)
+
+	bestConsistency, bestCount := 0.0, 0
+	var bestCounts []int
+	for _, delimiter := range DIALECT_DELIMITERS {
+		counts := make([]int, len(lines))
+		for i, line := range lines {
+			counts[i] = countDelimiters(line, delimiter, quote)
+		}
+
+		count, consistency := modeCount(counts)
+		if count == 0 {
+			continue
+		}
+		if consistency > bestConsistency || (consistency == bestConsistency && count > bestCount) {
+			dialect.Delimiter = string(delimiter)
+			bestConsistency, bestCount, bestCounts = consistency, count, counts
+		}
+	}
+
+	for i, count := range bestCounts {
+		if lineNumbers[i] > dialectMaxHeaderRow {
+			break
+		}
+		if count == bestCount {
+			dialect.HeaderRow = lineNumbers[i]
+			break
+		}
+	}
+
+	if backslashed, doubled := countQuoteEscapes(sample, dialect.Quote[0]); backslashed > 0 && doubled == 0 {
+		dialect.Escape = DIALECT_ESCAPE_BACKSLASH
+	}
+
+	return dialect
+}
+
+// countQuoteEscapes counts backslashed quotes and doubled quotes inside quoted values. An empty
+// quoted value is a quote that opens and closes at once, not a doubled quote.
+func countQuoteEscapes(sample []byte, quote byte) (backslashed, doubled int) {
+	quoted := false
+	for i := 0; i < len(sample); i++ {
+		var next byte
+		if i+1 < len(sample) {
+			next = sample[i+1]
+		}
+
+		switch {
+		case !quoted:
+			if sample[i] != quote {
+				continue
+			}
+			if next == quote && (i+2 >= len(sample) || sample[i+2] != quote) {
+				i++
+				continue
+			}
+			quoted = true
+		case sample[i] == '\\' && (next == quote || next == '\\'):
+			if next == quote {
+				backslashed++
+			}
+			i++
+		case sample[i] == quote && next == quote:
+			doubled++
+			i++
+		case sample[i] == quote:
+			quoted = false
+		}
+	}
+	return backslashed, doubled
+}
+
+// sampleLines splits a sample into its non-blank lines and their zero-based line numbers, dropping
+// a final line the sample may have cut short
+func sampleLines(sample []byte) ([]string, []int) {
+	text := string(sample)
+	if len(sample) >= dialectSampleSize {
+		if end := strings.LastIndexByte(text, '\n'); end >= 0 {
+			text = text[:end]
+		}
+	}
+
+	var lines []string
+	var lineNumbers []int
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+			lineNumbers = append(lineNumbers, i)
+		}
+	}
+	return lines, lineNumbers
+}
+
+// sniffQuote returns "'" when more values are wrapped in single quotes than double quotes
+func sniffQuote(lines []string) string {
+	var double, single int
+	for _, line := range lines {
+		double += strings.Count(line, `"`)
+		single += strings.Count(line, `'`)
+	}
+
+	// Apostrophes in names are common, so single quotes must clearly dominate
+	if single > 2*double && single >= 2*len(lines) {
+		return "'"
+	}
+	return `"`
+}
+
+// countDelimiters counts the delimiters in a line that fall outside quoted values
+func countDelimiters(line string, delimiter, quote rune) int {
+	count := 0
+	quoted := false
+	for _, r := range line {
+		switch r {
+		case quote:
+			quoted = !quoted
+		case delimiter:
+			if !quoted {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// modeCount returns the most common count and the fraction of lines that have it
+func modeCount(counts []int) (int, float64) {
+	frequency := make(map[int]int)
+	for _, count := range counts {
+		frequency[count]++
+	}
+
+	mode, seen := 0, 0
+	for count, lines := range frequency {
+		if lines > seen || (lines == seen && count > mode) {
+			mode, seen = count, lines
+		}
+	}
+	return mode, float64(seen) / float64(len(counts))
+}
+
+// applyDialectOverrides replaces the sniffed parts of dialect that the request sets explicitly
+func applyDialectOverrides(dialect *CSVDialect, req *CreateImportRequest) error {
+	if req.Delimiter != "" {
+		delimiter, err := parseDialectChar(req.Delimiter)
+		if err != nil {
+			return fmt.Errorf("invalid delimiter: %w", err)
+		}
+		if delimiter == "\n" || delimiter == "\r" || delimiter == `"` {
+			return fmt.Errorf("invalid delimiter: %q", delimiter)
+		}
+		dialect.Delimiter = delimiter
+	}
+
+	switch req.Quote {
+	case "":
+	case `"`, "'":
+		dialect.Quote = req.Quote
+	default:
+		return fmt.Errorf("unsupported quote character: %s", req.Quote)
+	}
+
+	switch req.Escape {
+	case "":
+	case DIALECT_ESCAPE_DOUBLE, DIALECT_ESCAPE_BACKSLASH:
+		dialect.Escape = req.Escape
+	default:
+		return fmt.Errorf("unknown escape style: %s", req.Escape)
+	}
+
+	if req.HeaderRow != "" {
+		headerRow, err := strconv.Atoi(req.HeaderRow)
+		if err != nil || headerRow < 0 {
+			return fmt.Errorf("invalid header row: %s", req.HeaderRow)
+		}
+		dialect.HeaderRow = headerRow
+	}
+
+	return nil
+}
+
+// parseDialectChar reads a single character, accepting names for the usual delimiters since
+// a literal tab does not survive form handling
+func parseDialectChar(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "tab", `\t`:
+		return "\t", nil
+	case "comma":
+		return ",", nil
+	case "semicolon":
+		return ";", nil
+	case "pipe":
+		return "|", nil
+	}
+
+	if utf8.RuneCountInString(value) != 1 {
+		return "", fmt.Errorf("expected a single character, got %q", value)
+	}
+	return value, nil
+}
+
+// newDialectReader returns a csv.Reader configured for dialect. Title lines above the header are
+// skipped unless resuming part way through the file. csv.Reader only understands doubled double
+// quotes, so other quoting is rewritten into that form on the way in and restored in each value.
+func newDialectReader(input io.Reader, dialect *CSVDialect, resuming bool) utils.RecordReader {
+	if dialect == nil {
+		return csv.NewReader(input)
+	}
+
+	reader := &dialectReader{
+		swapQuotes: dialect.Quote == "'",
+		backslash:  dialect.Escape == DIALECT_ESCAPE_BACKSLASH,
+	}
+	if dialect.HeaderRow > 0 && !resuming {
+		buffered := bufio.NewReader(input)
+		for reader.lineBase < dialect.HeaderRow {
+			line, err := buffered.ReadString('\n')
+			reader.offsetBase += int64(len(line))
+			if err != nil {
+				break
+			}
+			reader.lineBase++
+		}
+		input = buffered
+	}
+	if reader.swapQuotes || reader.backslash {
+		input = &quoteNormalizer{input: input, swapQuotes: reader.swapQuotes, backslash: reader.backslash}
+	}
+
+	reader.csv = csv.NewReader(input)
+	reader.csv.Comma, _ = utf8.DecodeRuneInString(dialect.Delimiter)
+
+	if reader.lineBase == 0 && !reader.swapQuotes && !reader.backslash {
+		return reader.csv
+	}
+	return reader
+}
+
+// dialectReader adapts a csv.Reader to a dialect it cannot parse natively, keeping offsets and
+// line numbers relative to the start of the file
+type dialectReader struct {
+	csv        *csv.Reader
+	swapQuotes bool
+	backslash  bool
+	offsetBase int64
+	lineBase   int
+}
+
+func (r *dialectReader) Read() ([]string, error) {
+	record, err := r.csv.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		parseErr.StartLine += r.lineBase
+		parseErr.Line += r.lineBase
+	}
+	if err != nil {
+		return record, err
+	}
+
+	for i, value := range record {
+		if r.backslash {
+			value = strings.ReplaceAll(value, `\\`, `\`)
+		}
+		if r.swapQuotes {
+			value = swapQuoteChars(value)
+		}
+		record[i] = value
+	}
+	return record, nil
+}
+
+func (r *dialectReader) FieldPos(field int) (line, column int) {
+	line, column = r.csv.FieldPos(field)
+	return line + r.lineBase, column
+}
+
+func (r *dialectReader) InputOffset() int64 {
+	return r.offsetBase + r.csv.InputOffset()
+}
+
+// quoteNormalizer rewrites a stream into the quoting csv.Reader expects without changing its
+// length, so input offsets stay valid. Single and double quotes are swapped for files quoted with
+// "'", and a backslashed quote becomes a doubled quote. Backslashed backslashes are passed through
+// for dialectReader to collapse.
+type quoteNormalizer struct {
+	input      io.Reader
+	swapQuotes bool
+	backslash  bool
+	pending    bool // The last byte read was a backslash that has not been written yet
+	buf        []byte
+}
+
+func (n *quoteNormalizer) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Reserve room for a held back backslash
+	if cap(n.buf) < len(p) {
+		n.buf = make([]byte, len(p))
+	}
+	read, err := n.input.Read(n.buf[:len(p)-1])
+
+	written := 0
+	for _, b := range n.buf[:read] {
+		if n.swapQuotes {
+			b = swapQuote(b)
+		}
+		switch {
+		case n.pending:
+			n.pending = false
+			if b == '"' {
+				p[written] = '"'
+			} else {
+				p[written] = '\\'
+			}
+			p[written+1] = b
+			written += 2
+		case n.backslash && b == '\\':
+			n.pending = true
+		default:
+			p[written] = b
+			written++
+		}
+	}
+
+	if err != nil && n.pending {
+		n.pending = false
+		p[written] = '\\'
+		written++
+	}
+	return written, err
+}
+
+func swapQuote(b byte) byte {
+	switch b {
+	case '"':
+		return '\''
+	case '\'':
+		return '"'
+	default:
+		return b
+	}
+}
+
+func swapQuoteChars(value string) string {
+	if !strings.ContainsAny(value, `"'`) {
+		return value
+	}
+	buf := []byte(value)
+	for i, b := range buf {
+		buf[i] = swapQuote(b)
+	}
+	return string(buf)
+}
+
+// csvReaderOf returns the csv.Reader behind a record reader, or nil for other formats
+func csvReaderOf(reader utils.RecordReader) *csv.Reader {
+	switch r := reader.(type) {
+	case *csv.Reader:
+		return r
+	case *dialectReader:
+		return r.csv
+	case *dateColumnReader:
+		return csvReaderOf(r.reader)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	. "server/internal/models"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSniffCSVDialect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sample   string
+		expected CSVDialect
+	}{
+		{
+			"empty",
+			"",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"comma",
+			"first_name,last_name,birth_date\nAnn,Lee,1985-07-01\nBob,Ray,1990-01-02\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"semicolon with decimal commas",
+			"first_name;salary;birth_date\nAnn;52000,50;01.07.1985\nBob;48000,00;02.01.1990\n",
+			CSVDialect{Delimiter: ";", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"tab",
+			"first_name\tlast_name\tbirth_date\r\nAnn\tLee\t1985-07-01\r\nBob\tRay\t1990-01-02\r\n",
+			CSVDialect{Delimiter: "\t", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"pipe",
+			"first_name|last_name|city\nAnn|Lee|Boise, ID\nBob|Ray|Provo, UT\n",
+			CSVDialect{Delimiter: "|", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"delimiters inside quotes",
+			"name,city\n\"Lee, Ann\",Boise\n\"Ray, Bob\",Provo\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"title lines",
+			"Member Export\nGenerated 2024-01-01\n\nfirst_name,last_name,birth_date\nAnn,Lee,1985-07-01\nBob,Ray,1990-01-02\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE, HeaderRow: 3},
+		},
+		{
+			"single quotes",
+			"'first_name','last_name'\n'Ann','Lee'\n'Bob','Ray'\n",
+			CSVDialect{Delimiter: ",", Quote: "'", Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"apostrophes in names",
+			"first_name,last_name\nAnn,O'Brien\nBob,D'Angelo\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"doubled quotes",
+			"id,note\n1,\"say \"\"hi\"\"\"\n2,\"plain\"\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_DOUBLE},
+		},
+		{
+			"backslash escapes",
+			"id,note\n1,\"say \\\"hi\\\"\"\n2,\"plain\"\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_BACKSLASH},
+		},
+		{
+			"backslash escapes with empty values",
+			"id,note,extra\n1,\"say \\\"hi\\\"\",\"\"\n2,\"\",\"\"\n",
+			CSVDialect{Delimiter: ",", Quote: `"`, Escape: DIALECT_ESCAPE_BACKSLASH},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := sniffCSVDialect([]byte(tc.sample))
+			if result != tc.expected {
+				t.Errorf("Expected dialect %+v, got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestCountQuoteEscapes(t *testing.T) {
+	testCases := []struct {
+		name                string
+		sample              string
+		quote               byte
+		expectedBackslashed int
+		expectedDoubled     int
+	}{
+		{"no quotes", "a,b\n1,2\n", '"', 0, 0},
+		{"plain quoted values", `"a","b"`, '"', 0, 0},
+		{"empty values", `1,"",""` + "\n" + `"",2`, '"', 0, 0},
+		{"empty value at the end", `1,""`, '"', 0, 0},
+		{"doubled quotes", `"say ""hi"""`, '"', 0, 2},
+		{"value starting with a quote", `"""hi"" there"`, '"', 0, 2},
+		{"backslashed quotes", `"say \"hi\""`, '"', 2, 0},
+		{"backslashed backslash before closing quote", `"C:\\","x"`, '"', 0, 0},
+		{"backslashed quotes with empty values", `"say \"hi\"",""`, '"', 2, 0},
+		{"single quotes", `'it''s','x'`, '\'', 0, 1},
+		{"backslashes outside quotes", `C:\temp,"x"`, '"', 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backslashed, doubled := countQuoteEscapes([]byte(tc.sample), tc.quote)
+			if backslashed != tc.expectedBackslashed || doubled != tc.expectedDoubled {
+				t.Errorf("Expected %d backslashed and %d doubled for %q, got %d and %d",
+					tc.expectedBackslashed, tc.expectedDoubled, tc.sample, backslashed, doubled)
+			}
+		})
+	}
+}
+
+func TestModeCount(t *testing.T) {
+	testCases := []struct {
+		name                string
+		counts              []int
+		expectedCount       int
+		expectedConsistency float64
+	}{
+		{"consistent", []int{2, 2, 2}, 2, 1},
+		{"title line", []int{0, 2, 2, 2}, 2, 0.75},
+		{"tie prefers more delimiters", []int{1, 1, 3, 3}, 3, 0.5},
+		{"no delimiters", []int{0, 0}, 0, 1},
+		{"single line", []int{4}, 4, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count, consistency := modeCount(tc.counts)
+			if count != tc.expectedCount || consistency != tc.expectedConsistency {
+				t.Errorf("Expected count %d with consistency %v, got %d and %v",
+					tc.expectedCount, tc.expectedConsistency, count, consistency)
+			}
+		})
+	}
+}
+
+func TestQuoteNormalizer_Read(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		swapQuotes bool
+		backslash  bool
+		expected   string
+	}{
+		{"swap quotes", `'a','it"s'`, true, false, `"a","it's"`},
+		{"backslashed quote", `"say \"hi\""`, false, true, `"say ""hi"""`},
+		{"backslashed backslash", `"C:\\temp"`, false, true, `"C:\\temp"`},
+		{"other backslashes", `"a\nb"`, false, true, `"a\nb"`},
+		{"trailing backslash", `"a",b\`, false, true, `"a",b\`},
+		{"backslashed single quote", `'it\'s'`, true, true, `"it""s"`},
+	}
+
+	readers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"whole", func(r io.Reader) io.Reader { return r }},
+		// Backslashes land at the end of one read and their quote at the start of the next
+		{"one byte at a time", iotest.OneByteReader},
+	}
+
+	for _, tc := range testCases {
+		for _, reader := range readers {
+			t.Run(tc.name+"/"+reader.name, func(t *testing.T) {
+				normalizer := &quoteNormalizer{
+					input:      reader.wrap(strings.NewReader(tc.input)),
+					swapQuotes: tc.swapQuotes,
+					backslash:  tc.backslash,
+				}
+				result, err := io.ReadAll(normalizer)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if string(result) != tc.expected {
+					t.Errorf("Expected %q, got %q", tc.expected, result)
+				}
+				if len(result) != len(tc.input) {
+					t.Errorf("Expected length %d to be kept, got %d", len(tc.input), len(result))
+				}
+			})
+		}
+	}
+}
+
+func TestNewDialectReader(t *testing.T) {
+	input := "Member Export\n" +
+		"id|note|path\n" +
+		"1|\"say \\\"hi\\\"\"|\"C:\\\\temp\"\n" +
+		"2|\"\"|\"a|b\"\n"
+	dialect := &CSVDialect{Delimiter: "|", Quote: `"`, Escape: DIALECT_ESCAPE_BACKSLASH, HeaderRow: 1}
+	expected := [][]string{
+		{"id", "note", "path"},
+		{"1", `say "hi"`, `C:\temp`},
+		{"2", "", "a|b"},
+	}
+
+	reader := newDialectReader(iotest.OneByteReader(strings.NewReader(input)), dialect, false)
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	if !slices.EqualFunc(records, expected, slices.Equal) {
+		t.Errorf("Expected records %q, got %q", expected, records)
+	}
+	if line, _ := reader.FieldPos(0); line != 4 {
+		t.Errorf("Expected the last record on line 4, got %d", line)
+	}
+	if offset := reader.InputOffset(); offset != int64(len(input)) {
+		t.Errorf("Expected offset %d, got %d", len(input), offset)
+	}
+}
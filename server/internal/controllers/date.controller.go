@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"fmt"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/utils"
+	"slices"
+)
+
+// DateController reads samples of date values outside of an import, so partner files can be
+// triaged before they are imported
+type DateController struct {
+	log logger.Logger
+}
+
+func NewDateController() *DateController {
+	return &DateController{
+		log: logger.New("dateController"),
+	}
+}
+
+// DateValueResult is what was read from one value of a sample
+type DateValueResult struct {
+	Value      string                      `json:"value"`
+	Valid      bool                        `json:"valid"`
+	Format     utils.DateFormat            `json:"format,omitempty"`    // Layout the value was written in
+	Precision  utils.DatePrecision         `json:"precision,omitempty"` // Finest unit the value was written to
+	Locale     utils.DateLocale            `json:"locale,omitempty"`    // Language of its month and weekday names
+	HasOffset  bool                        `json:"hasOffset,omitempty"` // The value fixed its own offset
+	DST        utils.DSTTransition         `json:"dst,omitempty"`       // How a time skipped or repeated by DST was resolved
+	Ambiguous  bool                        `json:"ambiguous,omitempty"` // A numeric date read without knowing its day and month order
+	Normalized string                      `json:"normalized,omitempty"`
+	Converted  map[utils.DateFormat]string `json:"converted,omitempty"` // Every supported format, for the "all" output format
+}
+
+// DateBatchResult holds the per-value results of a sample and a histogram of its formats
+type DateBatchResult struct {
+	Results   []DateValueResult        `json:"results"`
+	Formats   map[utils.DateFormat]int `json:"formats"` // Valid values per format they were written in
+	Valid     int                      `json:"valid"`
+	Invalid   int                      `json:"invalid"`
+	DateOrder utils.DateOrderProfile   `json:"dateOrder"` // Evidence for the order of numeric dates, and the order they were read in
+}
+
+// Normalize reads each value of the sample and writes it in the requested output format. Values
+// are written as ISO 8601 to their precision by default, with times in UTC; partial dates such
+// as a birth year keep their precision in every output format, so no day is made up.
+func (c *DateController) Normalize(req *DateBatchRequest) (*DateBatchResult, error) {
+	log := c.log.Function("Normalize")
+
+	if !slices.Contains([]string{"", DATE_OUTPUT_ISO8601, DATE_OUTPUT_ALL}, req.OutputFormat) &&
+		!isDateOutputLayout(utils.DateFormat(req.OutputFormat), utils.NormalizeLocale(req.Locale)) {
+		return nil, fmt.Errorf("unknown output format: %s", req.OutputFormat)
+	}
+
+	batch, err := c.readDates(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range batch.results {
+		if !result.IsValid {
+			continue
+		}
+		value := &batch.Results[i]
+		value.Normalized = result.Normalized()
+		switch {
+		case req.OutputFormat == DATE_OUTPUT_ALL:
+			value.Converted = make(map[utils.DateFormat]string)
+			for _, format := range batch.validator.GetSupportedFormats() {
+				value.Converted[format] = formatDateOutput(result, format, batch.locale)
+			}
+		case req.OutputFormat != "" && req.OutputFormat != DATE_OUTPUT_ISO8601:
+			value.Normalized = formatDateOutput(result, utils.DateFormat(req.OutputFormat), batch.locale)
+		}
+	}
+
+	log.Info("date sample normalized",
+		"values", len(req.Values),
+		"invalid", batch.Invalid,
+		"outputFormat", req.OutputFormat)
+	return &batch.DateBatchResult, nil
+}
+
+// Detect reads each value of the sample, reporting the format, precision and language it was
+// written in without converting it
+func (c *DateController) Detect(req *DateBatchRequest) (*DateBatchResult, error) {
+	log := c.log.Function("Detect")
+
+	batch, err := c.readDates(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("date sample detected",
+		"values", len(req.Values),
+		"invalid", batch.Invalid,
+		"formats", len(batch.Formats))
+	return &batch.DateBatchResult, nil
+}
+
+// dateBatch is a sample as read by readDates, along with how it was read
+type dateBatch struct {
+	DateBatchResult
+	results   []utils.ValidationResult
+	validator *utils.DateValidator
+	locale    utils.DateLocale
+}
+
+// readDates validates the options of a sample and reads its values the way an import reads a
+// date column: numeric dates in the requested order, or in the order inferred from the sample
+// for "auto", and times without an offset in the requested timezone
+func (c *DateController) readDates(req *DateBatchRequest) (*dateBatch, error) {
+	if len(req.Values) == 0 {
+		return nil, fmt.Errorf("no values to read")
+	}
+	if len(req.Values) > MAX_DATE_BATCH_VALUES {
+		return nil, fmt.Errorf("too many values: %d, at most %d are read at once", len(req.Values), MAX_DATE_BATCH_VALUES)
+	}
+
+	switch req.DateOrder {
+	case "", DATE_ORDER_AUTO, DATE_ORDER_MONTH_FIRST, DATE_ORDER_DAY_FIRST:
+	default:
+		return nil, fmt.Errorf("unknown date order: %s", req.DateOrder)
+	}
+
+	var locale utils.DateLocale
+	if req.Locale != "" {
+		if locale = utils.NormalizeLocale(req.Locale); locale == "" {
+			return nil, fmt.Errorf("unsupported locale: %s", req.Locale)
+		}
+	}
+
+	location, _, err := parseImportTimezones(req.Timezone, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Values sent here are known to be dates, so bare numbers are read as years and serial dates
+	dateUtils := newDateColumnUtils()
+	validator := dateUtils.GetValidator()
+	validator.SetLocale(locale)
+
+	profile := utils.ProfileDateOrder(req.Values)
+	switch req.DateOrder {
+	case DATE_ORDER_MONTH_FIRST, DATE_ORDER_DAY_FIRST:
+		profile.Order, profile.Ambiguous = utils.DateOrder(req.DateOrder), false
+	case "":
+		profile.Order = ""
+	}
+
+	batch := &dateBatch{
+		DateBatchResult: DateBatchResult{
+			Results:   make([]DateValueResult, len(req.Values)),
+			Formats:   make(map[utils.DateFormat]int),
+			DateOrder: profile,
+		},
+		results:   make([]utils.ValidationResult, len(req.Values)),
+		validator: validator,
+		locale:    locale,
+	}
+	for i, value := range req.Values {
+		result := validator.ValidateInLocation(value, profile.Order, location)
+		batch.results[i] = result
+		batch.Results[i] = DateValueResult{
+			Value:     value,
+			Valid:     result.IsValid,
+			Ambiguous: profile.Order == "" && utils.ProfileDateOrder([]string{value}).Undecided > 0,
+		}
+		if !result.IsValid {
+			batch.Invalid++
+			continue
+		}
+
+		batch.Valid++
+		batch.Formats[result.DetectedFormat]++
+		batch.Results[i].Format = result.DetectedFormat
+		batch.Results[i].Precision = result.Precision
+		batch.Results[i].Locale = result.Locale
+		batch.Results[i].HasOffset = result.HasOffset
+		batch.Results[i].DST = result.DST
+	}
+	return batch, nil
+}
+
+// isDateOutputLayout reports whether format is a layout dates can be written in, in locale
+func isDateOutputLayout(format utils.DateFormat, locale utils.DateLocale) bool {
+	return slices.Contains(utils.NewDateValidator().GetSupportedFormats(), format) ||
+		slices.Contains(utils.LocaleFormats(locale), format)
+}
+
+// formatDateOutput writes a valid result in format, in UTC. Partial dates are written to their
+// precision instead, as any other format would make up a day for them.
+func formatDateOutput(result utils.ValidationResult, format utils.DateFormat, locale utils.DateLocale) string {
+	if result.IsPartial() {
+		return result.Normalized()
+	}
+	return utils.FormatTimeInLocale(result.ParsedTime.UTC(), format, locale)
+}
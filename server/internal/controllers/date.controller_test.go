@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"reflect"
+	. "server/internal/models"
+	"server/internal/utils"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDateController_Normalize(t *testing.T) {
+	controller := NewDateController()
+
+	testCases := []struct {
+		name        string
+		req         DateBatchRequest
+		expected    []string
+		expectedErr string
+	}{
+		{
+			"iso8601 by default",
+			DateBatchRequest{Values: []string{"2024-01-02", "1985", "1985-07", "44927", "2024-01-02T15:04:05Z", "soon"}},
+			[]string{"2024-01-02", "1985", "1985-07", "2023-01-01", "2024-01-02T15:04:05Z", ""},
+			"",
+		},
+		{
+			"explicit iso8601",
+			DateBatchRequest{Values: []string{"07/04/1985"}, OutputFormat: DATE_OUTPUT_ISO8601},
+			[]string{"1985-07-04"},
+			"",
+		},
+		{
+			"layout",
+			DateBatchRequest{Values: []string{"2024-01-02", "2024-01-02T15:04:05Z"}, OutputFormat: "01/02/2006"},
+			[]string{"01/02/2024", "01/02/2024"},
+			"",
+		},
+		{
+			"partial dates keep their precision under a layout",
+			DateBatchRequest{Values: []string{"1985", "1985-07", "07/1985"}, OutputFormat: "01/02/2006"},
+			[]string{"1985", "1985-07", "1985-07"},
+			"",
+		},
+		{
+			"localized layout",
+			DateBatchRequest{Values: []string{"2024-01-02", "1985"}, OutputFormat: "2 January 2006", Locale: "fr-CA"},
+			[]string{"2 janvier 2024", "1985"},
+			"",
+		},
+		{
+			"localized layout without its locale",
+			DateBatchRequest{Values: []string{"2024-01-02"}, OutputFormat: "2 January 2006"},
+			nil,
+			"unknown output format: 2 January 2006",
+		},
+		{
+			"unknown output format",
+			DateBatchRequest{Values: []string{"2024-01-02"}, OutputFormat: "yyyy-mm-dd"},
+			nil,
+			"unknown output format: yyyy-mm-dd",
+		},
+		{
+			"times in the timezone",
+			DateBatchRequest{
+				Values:   []string{"2024-01-01 12:00:00", "2024-01-01T12:00:00+02:00", "2024-01-01"},
+				Timezone: "America/Chicago",
+			},
+			// Times with an offset keep it, and calendar dates stay calendar dates
+			[]string{"2024-01-01T18:00:00Z", "2024-01-01T10:00:00Z", "2024-01-01"},
+			"",
+		},
+		{
+			"times in UTC by default",
+			DateBatchRequest{Values: []string{"2024-01-01 12:00:00"}},
+			[]string{"2024-01-01T12:00:00Z"},
+			"",
+		},
+		{
+			"auto order from the sample",
+			DateBatchRequest{Values: []string{"03/04/2024", "13/04/2024", "14/05/2024", "25/12/2024"}, DateOrder: DATE_ORDER_AUTO},
+			[]string{"2024-04-03", "2024-04-13", "2024-05-14", "2024-12-25"},
+			"",
+		},
+		{
+			"auto order with only ambiguous samples",
+			DateBatchRequest{Values: []string{"03/04/2024", "05/06/2024"}, DateOrder: DATE_ORDER_AUTO},
+			[]string{"2024-03-04", "2024-05-06"},
+			"",
+		},
+		{
+			"explicit order",
+			DateBatchRequest{Values: []string{"03/04/2024", "05/14/2024"}, DateOrder: DATE_ORDER_DAY_FIRST},
+			[]string{"2024-04-03", ""},
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := controller.Normalize(&tc.req)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Errorf("Expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var normalized []string
+			for _, value := range result.Results {
+				normalized = append(normalized, value.Normalized)
+			}
+			if !slices.Equal(normalized, tc.expected) {
+				t.Errorf("Expected %q, got %q", tc.expected, normalized)
+			}
+		})
+	}
+}
+
+func TestDateController_NormalizeAll(t *testing.T) {
+	result, err := NewDateController().Normalize(&DateBatchRequest{
+		Values:       []string{"2024-01-02", "1985"},
+		OutputFormat: DATE_OUTPUT_ALL,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	full, partial := result.Results[0], result.Results[1]
+	if full.Normalized != "2024-01-02" || full.Converted["01/02/2006"] != "01/02/2024" {
+		t.Errorf("Expected 2024-01-02 converted to 01/02/2024, got %+v", full)
+	}
+	for format, value := range partial.Converted {
+		if value != "1985" {
+			t.Errorf("Expected a partial date to stay 1985 in %s, got %q", format, value)
+		}
+	}
+	if len(partial.Converted) != len(full.Converted) {
+		t.Errorf("Expected %d formats for a partial date, got %d", len(full.Converted), len(partial.Converted))
+	}
+}
+
+func TestDateController_Detect(t *testing.T) {
+	controller := NewDateController()
+
+	testCases := []struct {
+		name          string
+		req           DateBatchRequest
+		expected      []DateValueResult
+		expectedOrder utils.DateOrderProfile
+	}{
+		{
+			"formats and precisions",
+			DateBatchRequest{Values: []string{"1985", "1985-07-04", "soon"}},
+			[]DateValueResult{
+				{Value: "1985", Valid: true, Format: "2006", Precision: utils.PrecisionYear},
+				{Value: "1985-07-04", Valid: true, Format: "2006-01-02", Precision: utils.PrecisionDay},
+				{Value: "soon"},
+			},
+			utils.DateOrderProfile{},
+		},
+		{
+			"locales",
+			DateBatchRequest{Values: []string{"2 janvier 2006", "02. Jan. 2006"}},
+			[]DateValueResult{
+				{Value: "2 janvier 2006", Valid: true, Format: "2 January 2006", Precision: utils.PrecisionDay, Locale: utils.LocaleFrench},
+				{Value: "02. Jan. 2006", Valid: true, Format: "2. Jan 2006", Precision: utils.PrecisionDay, Locale: utils.LocaleGerman},
+			},
+			utils.DateOrderProfile{},
+		},
+		{
+			"auto order with ambiguous samples",
+			DateBatchRequest{Values: []string{"03/04/2024", "05/06/2024", "2024-01-02"}, DateOrder: DATE_ORDER_AUTO},
+			[]DateValueResult{
+				{Value: "03/04/2024", Valid: true, Format: "01/02/2006", Precision: utils.PrecisionDay, Ambiguous: true},
+				{Value: "05/06/2024", Valid: true, Format: "01/02/2006", Precision: utils.PrecisionDay, Ambiguous: true},
+				{Value: "2024-01-02", Valid: true, Format: "2006-01-02", Precision: utils.PrecisionDay},
+			},
+			utils.DateOrderProfile{Undecided: 2, Ambiguous: true},
+		},
+		{
+			"auto order with disagreeing samples",
+			DateBatchRequest{Values: []string{"13/04/2024", "05/14/2024"}, DateOrder: DATE_ORDER_AUTO},
+			[]DateValueResult{
+				{Value: "13/04/2024", Valid: true, Format: "02/01/2006", Precision: utils.PrecisionDay},
+				{Value: "05/14/2024", Valid: true, Format: "01/02/2006", Precision: utils.PrecisionDay},
+			},
+			utils.DateOrderProfile{MonthFirst: 1, DayFirst: 1, Ambiguous: true},
+		},
+		{
+			"auto order inferred",
+			DateBatchRequest{Values: []string{"03/04/2024", "13/04/2024", "14/05/2024", "25/12/2024"}, DateOrder: DATE_ORDER_AUTO},
+			[]DateValueResult{
+				{Value: "03/04/2024", Valid: true, Format: "02/01/2006", Precision: utils.PrecisionDay},
+				{Value: "13/04/2024", Valid: true, Format: "02/01/2006", Precision: utils.PrecisionDay},
+				{Value: "14/05/2024", Valid: true, Format: "02/01/2006", Precision: utils.PrecisionDay},
+				{Value: "25/12/2024", Valid: true, Format: "02/01/2006", Precision: utils.PrecisionDay},
+			},
+			utils.DateOrderProfile{Order: utils.DateOrderDayFirst, DayFirst: 3, Undecided: 1},
+		},
+		{
+			"explicit order is not profiled as ambiguous",
+			DateBatchRequest{Values: []string{"03/04/2024"}, DateOrder: DATE_ORDER_MONTH_FIRST},
+			[]DateValueResult{
+				{Value: "03/04/2024", Valid: true, Format: "01/02/2006", Precision: utils.PrecisionDay},
+			},
+			utils.DateOrderProfile{Order: utils.DateOrderMonthFirst, Undecided: 1},
+		},
+		{
+			"offsets and daylight saving",
+			DateBatchRequest{
+				Values:   []string{"2024-01-01T12:00:00+02:00", "2024-03-10 02:30:00", "2024-11-03 01:30:00"},
+				Timezone: "America/Chicago",
+			},
+			[]DateValueResult{
+				{Value: "2024-01-01T12:00:00+02:00", Valid: true, Format: "2006-01-02T15:04:05Z07:00", Precision: utils.PrecisionSecond, HasOffset: true},
+				{Value: "2024-03-10 02:30:00", Valid: true, Format: "2006-01-02 15:04:05", Precision: utils.PrecisionSecond, DST: utils.DSTGap},
+				{Value: "2024-11-03 01:30:00", Valid: true, Format: "2006-01-02 15:04:05", Precision: utils.PrecisionSecond, DST: utils.DSTOverlap},
+			},
+			utils.DateOrderProfile{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := controller.Detect(&tc.req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Results, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, result.Results)
+			}
+			if result.DateOrder != tc.expectedOrder {
+				t.Errorf("Expected date order %+v, got %+v", tc.expectedOrder, result.DateOrder)
+			}
+
+			valid := 0
+			formats := make(map[utils.DateFormat]int)
+			for _, value := range tc.expected {
+				if value.Valid {
+					valid++
+					formats[value.Format]++
+				}
+			}
+			if result.Valid != valid || result.Invalid != len(tc.expected)-valid {
+				t.Errorf("Expected %d valid and %d invalid, got %d and %d",
+					valid, len(tc.expected)-valid, result.Valid, result.Invalid)
+			}
+			if !reflect.DeepEqual(result.Formats, formats) {
+				t.Errorf("Expected formats %v, got %v", formats, result.Formats)
+			}
+		})
+	}
+}
+
+func TestDateController_ReadDatesOptions(t *testing.T) {
+	controller := NewDateController()
+	values := func(count int) []string {
+		return slices.Repeat([]string{"2024-01-02"}, count)
+	}
+
+	testCases := []struct {
+		name        string
+		req         DateBatchRequest
+		expectedErr string
+	}{
+		{"no values", DateBatchRequest{}, "no values to read"},
+		{"at the limit", DateBatchRequest{Values: values(MAX_DATE_BATCH_VALUES)}, ""},
+		{"over the limit", DateBatchRequest{Values: values(MAX_DATE_BATCH_VALUES + 1)}, "too many values"},
+		{"unknown date order", DateBatchRequest{Values: values(1), DateOrder: "ymd"}, "unknown date order: ymd"},
+		{"unsupported locale", DateBatchRequest{Values: values(1), Locale: "ja"}, "unsupported locale: ja"},
+		{"regional locale", DateBatchRequest{Values: values(1), Locale: "pt-BR"}, ""},
+		{"unknown timezone", DateBatchRequest{Values: values(1), Timezone: "Mars/Olympus"}, "Mars/Olympus"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := controller.readDates(&tc.req)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("Expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Valid != len(tc.req.Values) {
+				t.Errorf("Expected %d valid values, got %d", len(tc.req.Values), result.Valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "server/internal/models"
+	"server/internal/utils"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // Source timezones must load on hosts without a zoneinfo database
+)
+
+// dateOrderSampleRows is the number of rows read ahead to infer the date order of each column
+const dateOrderSampleRows = 1000
+
+// dateColumnReader normalizes the date columns of a source before any insertion strategy sees
+// them, so every strategy reads them the same way.
+//
+// Numeric dates such as 03/04/2020 are read in a single day and month order per column. In the
+// auto mode a sample of rows is read ahead to infer each column's order from the values that can
+// only be read one way, then replayed; values that contradict the order, and columns whose order
+// is ambiguous, are left for the strategy to read on their own.
+//
+// With a locale, dates naming their month in that language are rewritten, so the strategies
+// never read them in another language.
+//
+// With a source timezone, times without an offset are read as wall clock time in the column's
+// zone and rewritten as UTC, while times with an offset keep it. When offsets are recorded, the
+// original offset of each date is appended to the row as the date_offsets sidecar column.
+type dateColumnReader struct {
+	reader    utils.RecordReader
+	opts      *ImportOptions
+	validator *utils.DateValidator
+	headers   []string
+	columns   []dateColumn // Settings of each source column
+	zoned     bool         // Every date is normalized, not just those read with an order
+	prepared  bool
+	sample    []sampledRecord
+	current   *sampledRecord // The replayed row, nil once reads pass through
+	last      []string       // The last row or header returned, before the sidecar was appended
+}
+
+// dateColumn holds how one source column is read
+type dateColumn struct {
+	field    string
+	isDate   bool
+	order    utils.DateOrder // Empty when each value is read on its own
+	location *time.Location
+}
+
+// sampledRecord is a row read ahead, with the positions the reader reported for it
+type sampledRecord struct {
+	record []string
+	err    error
+	lines  []int
+	cols   []int
+	offset int64
+}
+
+// newDateColumnReader wraps reader to normalize dates as set by opts. Resumed runs start after
+// the header row, so their headers come from the checkpoint.
+func newDateColumnReader(reader utils.RecordReader, opts *ImportOptions) *dateColumnReader {
+	r := &dateColumnReader{
+		reader:    reader,
+		opts:      opts,
+		validator: utils.NewDateValidator(),
+		zoned:     opts.Timezone != nil || len(opts.ColumnTimezones) > 0 || opts.RecordOffsets,
+	}
+	r.validator.SetLocale(opts.Locale)
+	r.validator.SetYearDates(true)
+	if opts.checkpoint().resumed() {
+		r.headers = slices.Clone(opts.Checkpoint.checkpoint.Headers)
+		if opts.RecordOffsets && len(r.headers) > 0 && r.headers[len(r.headers)-1] == DATE_OFFSETS_FIELD {
+			r.headers = r.headers[:len(r.headers)-1]
+		}
+	}
+	return r
+}
+
+func (r *dateColumnReader) Read() ([]string, error) {
+	if r.headers == nil {
+		headers, err := r.reader.Read()
+		if err != nil {
+			return headers, err
+		}
+		r.headers = slices.Clone(headers)
+		r.last = headers
+		if r.opts.RecordOffsets {
+			headers = append(headers, DATE_OFFSETS_FIELD)
+		}
+		return headers, nil
+	}
+	if !r.prepared {
+		r.prepare()
+	}
+
+	if len(r.sample) > 0 {
+		r.current = &r.sample[0]
+		r.sample = r.sample[1:]
+		if r.current.err != nil {
+			return nil, r.current.err
+		}
+		return r.normalize(r.current.record), nil
+	}
+
+	r.current = nil
+	record, err := r.reader.Read()
+	if err != nil {
+		return record, err
+	}
+	return r.normalize(record), nil
+}
+
+// prepare decides how each column is read, reading ahead a sample when inferring date orders
+func (r *dateColumnReader) prepare() {
+	r.prepared = true
+	fields := r.opts.resolveFields(r.headers)
+	dateColumns := r.opts.dateColumns(r.headers)
+	r.columns = make([]dateColumn, len(r.headers))
+	for i, field := range fields {
+		r.columns[i] = dateColumn{field: field, isDate: dateColumns[i], location: r.opts.Timezone}
+		if location, ok := r.opts.ColumnTimezones[field]; ok {
+			r.columns[i].location = location
+		}
+	}
+
+	switch {
+	case len(r.opts.DateOrders) > 0:
+		// Resumed runs keep the orders the interrupted run used
+		for i, column := range r.columns {
+			for _, order := range r.opts.DateOrders {
+				if column.isDate && order.Column == column.field {
+					r.columns[i].order = utils.DateOrder(order.Order)
+				}
+			}
+		}
+	case r.opts.DateOrder == DATE_ORDER_AUTO:
+		r.inferOrders()
+	case r.opts.DateOrder != "":
+		for i, column := range r.columns {
+			if column.isDate {
+				r.columns[i].order = utils.DateOrder(r.opts.DateOrder)
+				r.opts.DateOrders = append(r.opts.DateOrders, ColumnDateOrder{
+					Column: column.field,
+					Order:  r.opts.DateOrder,
+				})
+			}
+		}
+	}
+}
+
+// inferOrders profiles a sample of each date column to infer its order
+func (r *dateColumnReader) inferOrders() {
+	r.readSample()
+	for i, column := range r.columns {
+		if !column.isDate {
+			continue
+		}
+
+		var values []string
+		for _, sampled := range r.sample {
+			if sampled.err == nil && i < len(sampled.record) {
+				values = append(values, sampled.record[i])
+			}
+		}
+		profile := utils.ProfileDateOrder(values)
+		r.columns[i].order = profile.Order
+		r.opts.DateOrders = append(r.opts.DateOrders, ColumnDateOrder{
+			Column:     column.field,
+			Order:      string(profile.Order),
+			MonthFirst: profile.MonthFirst,
+			DayFirst:   profile.DayFirst,
+			Undecided:  profile.Undecided,
+			Ambiguous:  profile.Ambiguous,
+		})
+	}
+}
+
+// readSample reads ahead up to dateOrderSampleRows rows, stopping at the first error that is not
+// a malformed row
+func (r *dateColumnReader) readSample() {
+	for rows := 0; rows < dateOrderSampleRows; rows++ {
+		record, err := r.reader.Read()
+		sampled := sampledRecord{record: slices.Clone(record), err: err, offset: r.reader.InputOffset()}
+		if err == nil {
+			sampled.lines = make([]int, len(record))
+			sampled.cols = make([]int, len(record))
+			for field := range record {
+				sampled.lines[field], sampled.cols[field] = r.reader.FieldPos(field)
+			}
+		}
+		r.sample = append(r.sample, sampled)
+
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return
+		}
+	}
+}
+
+// normalize rewrites the dates of a row as ISO 8601 to their precision, in UTC when they have a
+// time of day, and appends the sidecar of their original offsets when recording them
+func (r *dateColumnReader) normalize(record []string) []string {
+	r.last = record
+	var offsets map[string]string
+	for i, column := range r.columns {
+		if !column.isDate || i >= len(record) || record[i] == "" {
+			continue
+		}
+		ordered := column.order != "" && utils.HasDateOrder(record[i])
+		if !r.zoned && !ordered && r.opts.Locale == "" {
+			continue
+		}
+
+		result := r.validator.ValidateInLocation(record[i], column.order, column.location)
+		if !result.IsValid || (!r.zoned && !ordered && result.Locale == "") {
+			continue
+		}
+		record[i] = result.Normalized()
+		if result.IsCalendarDate() {
+			continue
+		}
+
+		if offsets == nil {
+			offsets = make(map[string]string)
+		}
+		offsets[column.field] = result.ParsedTime.Format("-07:00")
+	}
+
+	if !r.opts.RecordOffsets {
+		return record
+	}
+	// Ragged rows stay one value off the widened header, so they are still rejected
+	sidecar := ""
+	if len(offsets) > 0 {
+		data, _ := json.Marshal(offsets)
+		sidecar = string(data)
+	}
+	return append(record, sidecar)
+}
+
+// FieldPos reports the sidecar column at the end of the last source field
+func (r *dateColumnReader) FieldPos(field int) (line, column int) {
+	sidecar := r.opts.RecordOffsets && field >= len(r.last) && len(r.last) > 0
+	if sidecar {
+		field = len(r.last) - 1
+	}
+
+	if r.current == nil {
+		line, column = r.reader.FieldPos(field)
+	} else if len(r.current.lines) > 0 {
+		field = min(max(field, 0), len(r.current.lines)-1)
+		line, column = r.current.lines[field], r.current.cols[field]
+	}
+
+	if sidecar && csvReaderOf(r.reader) != nil {
+		// A quoted CSV field can span lines
+		line += strings.Count(r.last[field], "\n")
+	}
+	return line, column
+}
+
+func (r *dateColumnReader) InputOffset() int64 {
+	if r.current == nil {
+		return r.reader.InputOffset()
+	}
+	return r.current.offset
+}
+
+// newDateColumnUtils returns the date utils the strategies validate date columns with. Only date
+// columns are validated, so bare years in them are partial dates such as birth years, and other
+// day counts are serial dates from spreadsheets.
+func newDateColumnUtils() *utils.DateUtils {
+	dateUtils := utils.NewDateUtils()
+	validator := dateUtils.GetValidator()
+	validator.SetYearDates(true)
+	validator.SetSerialDates(utils.FormatExcelSerial)
+	return dateUtils
+}
+
+// newDateCache returns a cache memoizing the dates a run validates with dateUtils, shared by the
+// run's parser workers
+func newDateCache(dateUtils *utils.DateUtils) *utils.DateCache {
+	return utils.NewDateCache(dateUtils.GetValidator(), utils.DefaultDateCacheSize)
+}
+
+// recordDateCache stores how many of a run's dates were answered by its date cache on its load test
+func recordDateCache(loadTest *LoadTest, stats utils.DateCacheStats) {
+	loadTest.DateCacheHits = stats.Hits
+	loadTest.DateCacheMisses = stats.Misses
+}
+
+// setNormalizedDate stores a valid date to its precision, with the precision next to it
+func setNormalizedDate(data *TestData, field string, result utils.ValidationResult) {
+	normalized, precision := result.Normalized(), string(result.Precision)
+	*data.FieldPointer(field) = &normalized
+	*data.FieldPointer(TestDataPrecisionField(field)) = &precision
+}
+
+// parseImportTimezones loads the source timezone of an import and its per-column overrides,
+// given as comma-separated field=zone pairs such as "start_date=America/Chicago"
+func parseImportTimezones(timezone, columnTimezones string) (*time.Location, map[string]*time.Location, error) {
+	var location *time.Location
+	if timezone != "" {
+		loaded, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unknown timezone: %s", timezone)
+		}
+		location = loaded
+	}
+
+	var columns map[string]*time.Location
+	for _, pair := range strings.Split(columnTimezones, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, zone, ok := strings.Cut(pair, "=")
+		field, zone = strings.TrimSpace(field), strings.TrimSpace(zone)
+		if !ok || !slices.Contains(TestDataDateFields, field) {
+			return nil, nil, fmt.Errorf("invalid column timezone: %s", pair)
+		}
+		loaded, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unknown timezone for %s: %s", field, zone)
+		}
+		if columns == nil {
+			columns = make(map[string]*time.Location)
+		}
+		columns[field] = loaded
+	}
+
+	return location, columns, nil
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	. "server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fhirExportPageSize is the number of test data rows read per page while streaming an export
+const fhirExportPageSize = 1000
+
+// FHIR_COVERAGE_CLASS_SYSTEM is the code system of Coverage.class types
+const FHIR_COVERAGE_CLASS_SYSTEM = "http://terminology.hl7.org/CodeSystem/coverage-class"
+
+// fhirPatient is the subset of a FHIR R4 Patient that test data can populate
+type fhirPatient struct {
+	ResourceType string          `json:"resourceType"`
+	ID           string          `json:"id"`
+	Name         []fhirHumanName `json:"name,omitempty"`
+	Telecom      []fhirTelecom   `json:"telecom,omitempty"`
+	BirthDate    string          `json:"birthDate,omitempty"`
+	Address      []fhirAddress   `json:"address,omitempty"`
+}
+
+type fhirHumanName struct {
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type fhirTelecom struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+}
+
+type fhirAddress struct {
+	Line       []string `json:"line,omitempty"`
+	City       string   `json:"city,omitempty"`
+	State      string   `json:"state,omitempty"`
+	PostalCode string   `json:"postalCode,omitempty"`
+	Country    string   `json:"country,omitempty"`
+}
+
+// fhirCoverage is the subset of a FHIR R4 Coverage that test data can populate
+type fhirCoverage struct {
+	ResourceType string              `json:"resourceType"`
+	ID           string              `json:"id"`
+	Identifier   []fhirIdentifier    `json:"identifier,omitempty"`
+	Status       string              `json:"status"`
+	SubscriberID string              `json:"subscriberId,omitempty"`
+	Beneficiary  fhirReference       `json:"beneficiary"`
+	Period       *fhirPeriod         `json:"period,omitempty"`
+	Payor        []fhirReference     `json:"payor"`
+	Class        []fhirCoverageClass `json:"class,omitempty"`
+}
+
+type fhirIdentifier struct {
+	Value string `json:"value"`
+}
+
+type fhirReference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type fhirPeriod struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type fhirCoverageClass struct {
+	Type  fhirCodeableConcept `json:"type"`
+	Value string              `json:"value"`
+}
+
+type fhirCodeableConcept struct {
+	Coding []fhirCoding `json:"coding"`
+}
+
+type fhirCoding struct {
+	System string `json:"system"`
+	Code   string `json:"code"`
+}
+
+// WriteFHIRExport streams the test data of a load test to w as FHIR R4 NDJSON, one resource per
+// line in the style of a Bulk Data export. Every row becomes a Patient followed by its Coverage.
+// Coverage requires a payor, so rows without an insurance carrier export only the Patient.
+// Rows are paged by ID, and the export stops when ctx ends.
+func (c *LoadTestController) WriteFHIRExport(
+	ctx context.Context,
+	loadTestID string,
+	w io.Writer,
+) error {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+
+	for lastID := uuid.Nil; ; {
+		rows, err := c.testDataRepo.GetByLoadTestIDAfter(ctx, loadTestID, lastID, fhirExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := encoder.Encode(fhirPatientOf(row)); err != nil {
+				return err
+			}
+			if coverage := fhirCoverageOf(row); coverage != nil {
+				if err := encoder.Encode(coverage); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+
+		if len(rows) < fhirExportPageSize {
+			return nil
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// fhirPatientOf maps the demographic fields of a row onto a Patient sharing the row's ID
+func fhirPatientOf(row *TestData) *fhirPatient {
+	patient := &fhirPatient{
+		ResourceType: "Patient",
+		ID:           row.ID.String(),
+		BirthDate:    fhirDate(row.BirthDate),
+	}
+
+	if row.LastName != nil || row.FirstName != nil {
+		name := fhirHumanName{Family: stringValue(row.LastName)}
+		if row.FirstName != nil {
+			name.Given = []string{*row.FirstName}
+		}
+		patient.Name = []fhirHumanName{name}
+	}
+
+	if row.Phone != nil {
+		patient.Telecom = append(patient.Telecom, fhirTelecom{System: "phone", Value: *row.Phone})
+	}
+	if row.Email != nil {
+		patient.Telecom = append(patient.Telecom, fhirTelecom{System: "email", Value: *row.Email})
+	}
+
+	address := fhirAddress{
+		City:       stringValue(row.City),
+		State:      stringValue(row.State),
+		PostalCode: stringValue(row.ZipCode),
+		Country:    stringValue(row.Country),
+	}
+	for _, line := range []*string{row.AddressLine1, row.AddressLine2} {
+		if line != nil {
+			address.Line = append(address.Line, *line)
+		}
+	}
+	if len(address.Line) > 0 || address.City != "" || address.State != "" ||
+		address.PostalCode != "" || address.Country != "" {
+		patient.Address = []fhirAddress{address}
+	}
+
+	return patient
+}
+
+// fhirCoverageOf maps the insurance fields of a row onto a Coverage of its Patient, or returns
+// nil when the row has no insurance carrier to name as the payor
+func fhirCoverageOf(row *TestData) *fhirCoverage {
+	if row.InsuranceCarrier == nil {
+		return nil
+	}
+
+	coverage := &fhirCoverage{
+		ResourceType: "Coverage",
+		ID:           row.ID.String(),
+		Status:       "active",
+		SubscriberID: stringValue(row.MemberID),
+		Beneficiary:  fhirReference{Reference: "Patient/" + row.ID.String()},
+		Payor:        []fhirReference{{Display: *row.InsuranceCarrier}},
+	}
+
+	if row.PolicyNumber != nil {
+		coverage.Identifier = []fhirIdentifier{{Value: *row.PolicyNumber}}
+	}
+
+	if start, end := fhirDate(row.StartDate), fhirDate(row.EndDate); start != "" || end != "" {
+		coverage.Period = &fhirPeriod{Start: start, End: end}
+	}
+
+	for _, class := range []struct {
+		code  string
+		value *string
+	}{
+		{"group", row.GroupNumber},
+		{"plan", row.InsurancePlanID},
+	} {
+		if class.value != nil {
+			coverage.Class = append(coverage.Class, fhirCoverageClass{
+				Type: fhirCodeableConcept{
+					Coding: []fhirCoding{{System: FHIR_COVERAGE_CLASS_SYSTEM, Code: class.code}},
+				},
+				Value: *class.value,
+			})
+		}
+	}
+
+	return coverage
+}
+
+// fhirDate returns the calendar date of a normalized date field. FHIR dates may be partial, so a
+// birth year or month is passed through as stored. Values that were stored unnormalized because
+// they could not be parsed are left out rather than guessed at.
+func fhirDate(value *string) string {
+	if value == nil {
+		return ""
+	}
+	for _, layout := range []string{"2006", "2006-01", time.DateOnly} {
+		if _, err := time.Parse(layout, *value); err == nil {
+			return *value
+		}
+	}
+	parsed, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return ""
+	}
+	return parsed.Format(time.DateOnly)
+}
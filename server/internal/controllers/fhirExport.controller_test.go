@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func ptr(value string) *string {
+	return &value
+}
+
+// pagedTestDataRepo serves rows in ID order through GetByLoadTestIDAfter, recording the cursor of
+// every page requested
+type pagedTestDataRepo struct {
+	repositories.TestDataRepository
+	rows    []*TestData
+	cursors []uuid.UUID
+}
+
+func (r *pagedTestDataRepo) GetByLoadTestIDAfter(
+	ctx context.Context,
+	loadTestID string,
+	afterID uuid.UUID,
+	limit int,
+) ([]*TestData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.cursors = append(r.cursors, afterID)
+
+	var page []*TestData
+	for _, row := range r.rows {
+		if bytes.Compare(row.ID[:], afterID[:]) > 0 && len(page) < limit {
+			page = append(page, row)
+		}
+	}
+	return page, nil
+}
+
+func TestFHIRPatientOf(t *testing.T) {
+	id := uuid.MustParse("01890a5d-ac96-774b-bcce-b302099a8057")
+
+	testCases := []struct {
+		name     string
+		row      *TestData
+		expected *fhirPatient
+	}{
+		{
+			"empty row",
+			&TestData{ID: id},
+			&fhirPatient{ResourceType: "Patient", ID: id.String()},
+		},
+		{
+			"full demographics",
+			&TestData{
+				ID:           id,
+				FirstName:    ptr("Ann"),
+				LastName:     ptr("Lee"),
+				Phone:        ptr("555-123-4567"),
+				Email:        ptr("ann@example.com"),
+				BirthDate:    ptr("1985-07-01"),
+				AddressLine1: ptr("1 Main St"),
+				AddressLine2: ptr("Apt 2"),
+				City:         ptr("Boise"),
+				State:        ptr("ID"),
+				ZipCode:      ptr("83702"),
+				Country:      ptr("US"),
+			},
+			&fhirPatient{
+				ResourceType: "Patient",
+				ID:           id.String(),
+				Name:         []fhirHumanName{{Family: "Lee", Given: []string{"Ann"}}},
+				Telecom: []fhirTelecom{
+					{System: "phone", Value: "555-123-4567"},
+					{System: "email", Value: "ann@example.com"},
+				},
+				BirthDate: "1985-07-01",
+				Address: []fhirAddress{{
+					Line:       []string{"1 Main St", "Apt 2"},
+					City:       "Boise",
+					State:      "ID",
+					PostalCode: "83702",
+					Country:    "US",
+				}},
+			},
+		},
+		{
+			"given name only",
+			&TestData{ID: id, FirstName: ptr("Ann")},
+			&fhirPatient{ResourceType: "Patient", ID: id.String(), Name: []fhirHumanName{{Given: []string{"Ann"}}}},
+		},
+		{
+			"address without lines",
+			&TestData{ID: id, State: ptr("ID")},
+			&fhirPatient{ResourceType: "Patient", ID: id.String(), Address: []fhirAddress{{State: "ID"}}},
+		},
+		{
+			"partial birth date",
+			&TestData{ID: id, BirthDate: ptr("1985")},
+			&fhirPatient{ResourceType: "Patient", ID: id.String(), BirthDate: "1985"},
+		},
+		{
+			"birth date with a time",
+			&TestData{ID: id, BirthDate: ptr("1985-07-01T04:00:00Z")},
+			&fhirPatient{ResourceType: "Patient", ID: id.String(), BirthDate: "1985-07-01"},
+		},
+		{
+			"unparsed birth date",
+			&TestData{ID: id, BirthDate: ptr("07/01/85")},
+			&fhirPatient{ResourceType: "Patient", ID: id.String()},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := fhirPatientOf(tc.row)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFHIRCoverageOf(t *testing.T) {
+	id := uuid.MustParse("01890a5d-ac96-774b-bcce-b302099a8057")
+	classOf := func(code, value string) fhirCoverageClass {
+		return fhirCoverageClass{
+			Type:  fhirCodeableConcept{Coding: []fhirCoding{{System: FHIR_COVERAGE_CLASS_SYSTEM, Code: code}}},
+			Value: value,
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		row      *TestData
+		expected *fhirCoverage
+	}{
+		{
+			"no carrier",
+			&TestData{ID: id, MemberID: ptr("M001"), PolicyNumber: ptr("POL-1")},
+			nil,
+		},
+		{
+			"carrier only",
+			&TestData{ID: id, InsuranceCarrier: ptr("Blue Cross")},
+			&fhirCoverage{
+				ResourceType: "Coverage",
+				ID:           id.String(),
+				Status:       "active",
+				Beneficiary:  fhirReference{Reference: "Patient/" + id.String()},
+				Payor:        []fhirReference{{Display: "Blue Cross"}},
+			},
+		},
+		{
+			"full insurance",
+			&TestData{
+				ID:               id,
+				InsuranceCarrier: ptr("Blue Cross"),
+				MemberID:         ptr("M001"),
+				PolicyNumber:     ptr("POL-1"),
+				GroupNumber:      ptr("G100"),
+				InsurancePlanID:  ptr("PLAN-A"),
+				StartDate:        ptr("2024-01-01"),
+				EndDate:          ptr("2024-12-31T23:59:59Z"),
+			},
+			&fhirCoverage{
+				ResourceType: "Coverage",
+				ID:           id.String(),
+				Identifier:   []fhirIdentifier{{Value: "POL-1"}},
+				Status:       "active",
+				SubscriberID: "M001",
+				Beneficiary:  fhirReference{Reference: "Patient/" + id.String()},
+				Period:       &fhirPeriod{Start: "2024-01-01", End: "2024-12-31"},
+				Payor:        []fhirReference{{Display: "Blue Cross"}},
+				Class:        []fhirCoverageClass{classOf("group", "G100"), classOf("plan", "PLAN-A")},
+			},
+		},
+		{
+			"open-ended period",
+			&TestData{ID: id, InsuranceCarrier: ptr("Blue Cross"), StartDate: ptr("2024-01")},
+			&fhirCoverage{
+				ResourceType: "Coverage",
+				ID:           id.String(),
+				Status:       "active",
+				Beneficiary:  fhirReference{Reference: "Patient/" + id.String()},
+				Period:       &fhirPeriod{Start: "2024-01"},
+				Payor:        []fhirReference{{Display: "Blue Cross"}},
+			},
+		},
+		{
+			"unparsed dates leave out the period",
+			&TestData{ID: id, InsuranceCarrier: ptr("Blue Cross"), StartDate: ptr("soon"), EndDate: ptr("")},
+			&fhirCoverage{
+				ResourceType: "Coverage",
+				ID:           id.String(),
+				Status:       "active",
+				Beneficiary:  fhirReference{Reference: "Patient/" + id.String()},
+				Payor:        []fhirReference{{Display: "Blue Cross"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := fhirCoverageOf(tc.row)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestWriteFHIRExport(t *testing.T) {
+	rows := make([]*TestData, 2*fhirExportPageSize+1)
+	for i := range rows {
+		rows[i] = &TestData{ID: uuid.Must(uuid.NewV7()), FirstName: ptr("Ann")}
+		if i%2 == 0 {
+			rows[i].InsuranceCarrier = ptr("Blue Cross")
+		}
+	}
+	repo := &pagedTestDataRepo{rows: rows}
+	controller := &LoadTestController{testDataRepo: repo}
+
+	var out bytes.Buffer
+	if err := controller.WriteFHIRExport(context.Background(), uuid.NewString(), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedCursors := []uuid.UUID{uuid.Nil, rows[fhirExportPageSize-1].ID, rows[2*fhirExportPageSize-1].ID}
+	if !reflect.DeepEqual(repo.cursors, expectedCursors) {
+		t.Errorf("Expected pages after %v, got %v", expectedCursors, repo.cursors)
+	}
+
+	// Each row is a Patient, followed by its Coverage when it has a carrier
+	var expected []string
+	for i, row := range rows {
+		expected = append(expected, "Patient/"+row.ID.String())
+		if i%2 == 0 {
+			expected = append(expected, "Coverage/"+row.ID.String())
+		}
+	}
+	var resources []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var resource struct {
+			ResourceType string `json:"resourceType"`
+			ID           string `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &resource); err != nil {
+			t.Fatalf("Expected one JSON resource per line, got %q: %v", scanner.Text(), err)
+		}
+		resources = append(resources, resource.ResourceType+"/"+resource.ID)
+	}
+	if strings.Join(resources, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %d resources in row order, got %d", len(expected), len(resources))
+	}
+}
+
+func TestWriteFHIRExport_Cancelled(t *testing.T) {
+	rows := make([]*TestData, 2*fhirExportPageSize)
+	for i := range rows {
+		rows[i] = &TestData{ID: uuid.Must(uuid.NewV7())}
+	}
+	repo := &pagedTestDataRepo{rows: rows}
+	controller := &LoadTestController{testDataRepo: repo}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := &cancellingWriter{cancel: cancel}
+	err := controller.WriteFHIRExport(ctx, uuid.NewString(), cancelled)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the export to stop with context.Canceled, got %v", err)
+	}
+	if len(repo.cursors) != 1 {
+		t.Errorf("Expected no pages read after cancelling, got %d pages", len(repo.cursors))
+	}
+}
+
+// cancellingWriter cancels its context on the first write, as a client disconnecting would
+type cancellingWriter struct {
+	cancel context.CancelFunc
+}
+
+func (w *cancellingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return len(p), nil
+}
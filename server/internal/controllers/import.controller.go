@@ -0,0 +1,833 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"server/internal/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importBatchSize is the number of records buffered per insert for streamed brute_force/batched imports
+const importBatchSize = 2000
+
+// importTimingResult is the common timing breakdown returned by every import strategy
+type importTimingResult struct {
+	ParseTime        int
+	InsertTime       int
+	TotalTime        int
+	RecordsProcessed int
+	BatchRetries     BatchRetries         // Set by the strategies that retry transient database errors
+	DateCache        utils.DateCacheStats // Set by the strategies that memoize date validation
+}
+
+// ImportOptions carries per-import settings through the insertion strategies.
+// A nil *ImportOptions is valid and selects the defaults.
+type ImportOptions struct {
+	Format          string      // One of the IMPORT_FORMAT constants, CSV when empty
+	Dialect         *CSVDialect // Layout of CSV sources, the generated layout when nil
+	Mapping         *ColumnMappingProfile
+	Rejections      *importErrorRecorder
+	Checkpoint      *checkpointTracker
+	Upsert          *upsertImport             // Set when rows are upserted on a natural key instead of inserted
+	Sheet           string                    // Worksheet of XLSX sources, the first when empty
+	HeaderRow       *int                      // Zero-based header row of XLSX sources, detected when nil
+	DateOrder       string                    // One of the DATE_ORDER modes; each date is read on its own when empty
+	DateOrders      DateOrders                // Order each date column was read with, restored when resuming
+	Timezone        *time.Location            // Zone of times without an offset, UTC when nil
+	ColumnTimezones map[string]*time.Location // Per date field overrides of Timezone
+	RecordOffsets   bool                      // Keep each date's original offset in the date_offsets sidecar
+	Locale          utils.DateLocale          // Language of month and weekday names, detected per value when empty
+}
+
+// checkpoint returns the resume checkpoint tracker, or nil when the run is not checkpointed
+func (o *ImportOptions) checkpoint() *checkpointTracker {
+	if o == nil {
+		return nil
+	}
+	return o.Checkpoint
+}
+
+// rejections returns the recorder for rejected rows, or nil when rows are not being recorded
+func (o *ImportOptions) rejections() *importErrorRecorder {
+	if o == nil {
+		return nil
+	}
+	return o.Rejections
+}
+
+// format returns the file format of the source
+func (o *ImportOptions) format() string {
+	if o == nil || o.Format == "" {
+		return IMPORT_FORMAT_CSV
+	}
+	return o.Format
+}
+
+// resolveFields maps source headers onto canonical TestData field names. NDJSON sources resolve
+// each key as it is read and X12 sources map segments onto fields, so their headers are already
+// canonical.
+func (o *ImportOptions) resolveFields(headers []string) []string {
+	if o == nil {
+		return (*ColumnMappingProfile)(nil).ResolveHeaders(headers)
+	}
+	if o.format() == IMPORT_FORMAT_NDJSON || o.format() == IMPORT_FORMAT_X12 {
+		return slices.Clone(headers)
+	}
+	fields := o.Mapping.ResolveHeaders(headers)
+	// The offsets column is appended by the date reader, so it is the only source of the sidecar
+	if o.RecordOffsets && len(headers) > 0 && headers[len(headers)-1] == DATE_OFFSETS_FIELD {
+		fields[len(fields)-1] = DATE_OFFSETS_FIELD
+	}
+	return fields
+}
+
+// dateOrders returns the order each date column was read with
+func (o *ImportOptions) dateOrders() DateOrders {
+	if o == nil {
+		return nil
+	}
+	return o.DateOrders
+}
+
+// recordReader returns the reader for the source format, normalizing its dates when a date
+// order, source timezone or locale is set
+func (o *ImportOptions) recordReader(input io.Reader) utils.RecordReader {
+	reader := o.formatReader(input)
+	if o != nil && (o.DateOrder != "" || o.Timezone != nil || len(o.ColumnTimezones) > 0 || o.RecordOffsets ||
+		o.Locale != "") {
+		return newDateColumnReader(reader, o)
+	}
+	return reader
+}
+
+// formatReader returns the reader for the source format. CSV sources that record rejections
+// accept ragged rows so they can be rejected instead of failing the import. Fixed-width sources
+// require a mapping profile with a layout.
+func (o *ImportOptions) formatReader(input io.Reader) utils.RecordReader {
+	if o.format() == IMPORT_FORMAT_NDJSON {
+		var rules FlattenRules
+		if o.Mapping != nil {
+			rules = o.Mapping.Flatten
+		}
+		columns := append(append([]string{}, TestDataDateFields...), TestDataMeaningfulFields...)
+		reader := utils.NewNDJSONReader(input, columns, rules.Flatten, o.Mapping.KeyResolver())
+		if o.checkpoint().resumed() {
+			reader.SkipHeader()
+		}
+		return reader
+	}
+
+	if o.format() == IMPORT_FORMAT_X12 {
+		columns := append(append([]string{}, TestDataDateFields...), TestDataMeaningfulFields...)
+		return utils.NewX12Reader(input, columns, o.rejections().RecordEnvelopeError)
+	}
+
+	if o.format() == IMPORT_FORMAT_XLSX {
+		headerRow := -1
+		if o.HeaderRow != nil {
+			headerRow = *o.HeaderRow
+		}
+		return utils.NewXLSXReader(input, o.Sheet, headerRow, o.dateColumns)
+	}
+
+	if o.format() == IMPORT_FORMAT_FIXED_WIDTH {
+		reader := utils.NewFixedWidthReader(input, o.Mapping.Layout.Names(), o.Mapping.Layout.Split)
+		if o.checkpoint().resumed() {
+			reader.SkipHeader()
+		}
+		return reader
+	}
+
+	var dialect *CSVDialect
+	if o != nil {
+		dialect = o.Dialect
+	}
+	reader := newDialectReader(input, dialect, o.checkpoint().resumed())
+	if o.rejections() != nil {
+		csvReaderOf(reader).FieldsPerRecord = -1
+	}
+	return reader
+}
+
+// dateColumns reports which source headers resolve onto date fields
+func (o *ImportOptions) dateColumns(headers []string) []bool {
+	dates := make([]bool, len(headers))
+	for i, field := range o.resolveFields(headers) {
+		dates[i] = slices.Contains(TestDataDateFields, field)
+	}
+	return dates
+}
+
+// zipSignature starts every zip archive, including XLSX workbooks
+var zipSignature = []byte("PK\x03\x04")
+
+// decompressedSource wraps input in a ProgressReader that decompresses gzip and zstd sources.
+// Progress counts the compressed bytes read against total. The caller closes the reader.
+func decompressedSource(input io.Reader, total int64) (*utils.ProgressReader, error) {
+	source := utils.NewProgressReader(input, total)
+	if err := source.Decompress(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// uploadSource wraps an upload in a ProgressReader that decompresses it and transcodes it to
+// UTF-8 from encoding, or from the encoding detected in the file when encoding is empty.
+// Checkpoint offsets are positions in the transcoded stream. The caller closes the reader.
+func uploadSource(input io.Reader, total int64, encoding string) (*utils.ProgressReader, error) {
+	source, err := decompressedSource(input, total)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := source.Transcode(encoding); err != nil {
+		source.Close()
+		return nil, err
+	}
+	return source, nil
+}
+
+// sampleUpload returns the start of an upload, decompressed and transcoded, and rewinds it
+func sampleUpload(upload io.ReadSeeker, encoding string) ([]byte, error) {
+	probe, err := uploadSource(upload, 0, encoding)
+	if err != nil {
+		return nil, err
+	}
+	sample := make([]byte, dialectSampleSize)
+	n, err := io.ReadFull(probe, sample)
+	probe.Close()
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if _, err := upload.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	return sample[:n], nil
+}
+
+// importFileExt returns the lowercased extension of an upload's file name, ignoring any
+// compression suffix
+func importFileExt(fileName string) string {
+	name := strings.ToLower(fileName)
+	for _, suffix := range []string{".gz", ".zst", ".zstd"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return filepath.Ext(name)
+}
+
+// isWorkbookUpload reports whether an upload is an XLSX workbook, from its file name or the zip
+// signature it starts with, and rewinds it. Workbooks are binary, so they must be recognised
+// before the upload is sampled as text.
+func isWorkbookUpload(fileName string, upload io.ReadSeeker) (bool, error) {
+	if importFileExt(fileName) == ".xlsx" {
+		return true, nil
+	}
+
+	probe, err := decompressedSource(upload, 0)
+	if err != nil {
+		return false, err
+	}
+	head := make([]byte, len(zipSignature))
+	n, err := io.ReadFull(probe, head)
+	probe.Close()
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if _, err := upload.Seek(0, io.SeekStart); err != nil {
+		return false, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	return bytes.Equal(head[:n], zipSignature), nil
+}
+
+// detectImportFormat picks the format of an upload from its file name, ignoring any compression
+// suffix, falling back to NDJSON when the first non-blank character opens a JSON object and X12
+// when the upload starts with an ISA segment
+func detectImportFormat(fileName string, sample []byte) string {
+	switch importFileExt(fileName) {
+	case ".ndjson", ".jsonl":
+		return IMPORT_FORMAT_NDJSON
+	case ".edi", ".x12", ".834":
+		return IMPORT_FORMAT_X12
+	case ".csv", ".tsv", ".psv":
+		return IMPORT_FORMAT_CSV
+	}
+
+	// Anything else, including .txt, is judged by its content
+	head := bytes.TrimLeft(bytes.TrimPrefix(sample, []byte("\ufeff")), " \t\r\n")
+	if len(head) > 0 && head[0] == '{' {
+		return IMPORT_FORMAT_NDJSON
+	}
+	if bytes.HasPrefix(head, []byte("ISA")) && len(head) > 3 && !isAlphanumeric(head[3]) {
+		return IMPORT_FORMAT_X12
+	}
+	return IMPORT_FORMAT_CSV
+}
+
+// IsValidImportMethod reports whether method is one of the supported insertion strategies
+func IsValidImportMethod(method string) bool {
+	switch method {
+	case "brute_force", "batched", "plaid", "optimized", "ludicrous":
+		return true
+	}
+	return false
+}
+
+// CreateAndRunImport creates a load test for an uploaded file and streams it through the
+// requested insertion strategy. The input is consumed synchronously because it is backed
+// by the request body, so this returns once the import has finished.
+//
+// A file already imported with the same method and options within the idempotency TTL, or a
+// request repeating an earlier Idempotency-Key, returns the existing load test with Replayed set
+// instead of importing again.
+func (c *LoadTestController) CreateAndRunImport(
+	ctx context.Context,
+	req *CreateImportRequest,
+	input io.Reader,
+) (*LoadTest, error) {
+	log := c.log.Function("CreateAndRunImport")
+
+	if !IsValidImportMethod(req.Method) {
+		return nil, fmt.Errorf("unknown insertion method: %s", req.Method)
+	}
+
+	switch req.Format {
+	case "", IMPORT_FORMAT_CSV, IMPORT_FORMAT_NDJSON, IMPORT_FORMAT_FIXED_WIDTH, IMPORT_FORMAT_X12,
+		IMPORT_FORMAT_XLSX:
+	default:
+		return nil, fmt.Errorf("unknown import format: %s", req.Format)
+	}
+
+	switch req.DateOrder {
+	case "", DATE_ORDER_AUTO, DATE_ORDER_MONTH_FIRST, DATE_ORDER_DAY_FIRST:
+	default:
+		return nil, fmt.Errorf("unknown date order: %s", req.DateOrder)
+	}
+
+	encoding := ""
+	if req.Encoding != "" {
+		if encoding = utils.NormalizeEncoding(req.Encoding); encoding == "" {
+			return nil, fmt.Errorf("unsupported encoding: %s", req.Encoding)
+		}
+	}
+
+	opts := &ImportOptions{Format: req.Format, DateOrder: req.DateOrder}
+	if req.Locale != "" {
+		if opts.Locale = utils.NormalizeLocale(req.Locale); opts.Locale == "" {
+			return nil, fmt.Errorf("unsupported locale: %s", req.Locale)
+		}
+	}
+	timezone, columnTimezones, err := parseImportTimezones(req.Timezone, req.ColumnTimezones)
+	if err != nil {
+		return nil, err
+	}
+	opts.Timezone, opts.ColumnTimezones = timezone, columnTimezones
+	switch req.RecordOffsets {
+	case "", "false":
+	case "true":
+		opts.RecordOffsets = true
+	default:
+		return nil, fmt.Errorf("invalid recordOffsets: %s", req.RecordOffsets)
+	}
+	if req.MappingProfileID != "" {
+		profile, err := c.mappingRepo.GetByID(ctx, req.MappingProfileID)
+		if err != nil {
+			return nil, fmt.Errorf("column mapping profile not found: %w", err)
+		}
+		opts.Mapping = profile
+	}
+
+	// A profile with a layout describes fixed-width files, which cannot be detected from content
+	if opts.Format == "" && opts.Mapping != nil && len(opts.Mapping.Layout) > 0 {
+		opts.Format = IMPORT_FORMAT_FIXED_WIDTH
+	}
+	if opts.Format == IMPORT_FORMAT_FIXED_WIDTH && (opts.Mapping == nil || len(opts.Mapping.Layout) == 0) {
+		return nil, fmt.Errorf("fixed-width imports require a mapping profile with a layout")
+	}
+
+	upsert, err := c.importMode(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	opts.Upsert = upsert
+
+	// A retried request is answered without importing or spooling the upload again
+	if req.IdempotencyKey != "" {
+		existing, err := c.replayKey(ctx, req.IdempotencyKey, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+		}
+		if existing != nil {
+			log.Info("returning load test for repeated idempotency key", "loadTestId", existing.ID)
+			return existing, nil
+		}
+	}
+
+	upload, checksum, size, err := spoolUpload(input, c.spoolLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer removeSpool(upload)
+
+	if opts.Format == "" {
+		workbook, err := isWorkbookUpload(req.FileName, upload)
+		if err != nil {
+			return nil, err
+		}
+		if workbook {
+			opts.Format = IMPORT_FORMAT_XLSX
+		}
+	}
+	if opts.Format == IMPORT_FORMAT_XLSX {
+		opts.Sheet = req.Sheet
+		if req.HeaderRow != "" {
+			headerRow, err := strconv.Atoi(req.HeaderRow)
+			if err != nil || headerRow < 0 {
+				return nil, fmt.Errorf("invalid header row: %s", req.HeaderRow)
+			}
+			opts.HeaderRow = &headerRow
+		}
+	} else {
+		sample, err := sampleUpload(upload, encoding)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Format == "" {
+			opts.Format = detectImportFormat(req.FileName, sample)
+		}
+		if opts.Format == IMPORT_FORMAT_CSV {
+			dialect := sniffCSVDialect(sample)
+			if err := applyDialectOverrides(&dialect, req); err != nil {
+				return nil, err
+			}
+			opts.Dialect = &dialect
+		}
+	}
+
+	uploadKey, err := importUploadKey(checksum, req.Method, encoding, opts)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := c.findImport(ctx, repositories.IDEMPOTENCY_CHECKSUM_HASH, uploadKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check upload checksum: %w", err)
+	}
+	if existing != nil {
+		log.Info("returning load test for repeated upload", "loadTestId", existing.ID, "checksum", checksum)
+		if req.IdempotencyKey != "" {
+			if err := c.saveImportKey(ctx, req.IdempotencyKey, checksum, existing); err != nil {
+				log.Warn("failed to record idempotency key for repeated upload",
+					"loadTestId", existing.ID, "error", err)
+			}
+		}
+		return existing, nil
+	}
+
+	var source *utils.ProgressReader
+	if opts.Format == IMPORT_FORMAT_XLSX {
+		// Workbooks are binary, and their text is always stored as UTF-8
+		source, err = decompressedSource(upload, size)
+	} else {
+		source, err = uploadSource(upload, size, encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	loadTest := &LoadTest{
+		Method:  req.Method,
+		Status:  "queued",
+		Source:  "upload",
+		Format:  opts.Format,
+		Dialect: opts.Dialect,
+	}
+	// Resumes must decode the file the same way for the checkpoint offsets to line up
+	if encoding = source.Encoding(); encoding != "" {
+		loadTest.Encoding = &encoding
+	}
+	if req.FileName != "" {
+		loadTest.FileName = &req.FileName
+	}
+	if opts.Sheet != "" {
+		loadTest.Sheet = &opts.Sheet
+	}
+	if opts.DateOrder != "" {
+		loadTest.DateOrder = &opts.DateOrder
+	}
+	if req.Timezone != "" {
+		loadTest.Timezone = &req.Timezone
+	}
+	if req.ColumnTimezones != "" {
+		loadTest.ColumnTimezones = &req.ColumnTimezones
+	}
+	loadTest.RecordOffsets = opts.RecordOffsets
+	if opts.Locale != "" {
+		locale := string(opts.Locale)
+		loadTest.Locale = &locale
+	}
+	if opts.Mapping != nil {
+		loadTest.MappingProfileID = &opts.Mapping.ID
+	}
+	loadTest.ImportMode = IMPORT_MODE_INSERT
+	if upsert != nil {
+		upsertKey := upsert.key()
+		loadTest.ImportMode = IMPORT_MODE_UPSERT
+		loadTest.UpsertKey = &upsertKey
+	}
+
+	if err := c.loadTestRepo.Create(ctx, loadTest); err != nil {
+		_ = log.Err("failed to create load test", err, "loadTest", loadTest)
+		return nil, fmt.Errorf("failed to create load test: %w", err)
+	}
+
+	// Concurrent identical submissions race to claim the checksum and key; the losers hand back
+	// the winner's load test
+	existing, err = c.claimImportKeys(ctx, req.IdempotencyKey, uploadKey, checksum, loadTest)
+	if err != nil || existing != nil {
+		if deleteErr := c.loadTestRepo.Delete(ctx, loadTest.ID.String()); deleteErr != nil {
+			_ = log.Err("failed to remove duplicate load test", deleteErr, "loadTestId", loadTest.ID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to record import checksum: %w", err)
+		}
+		return existing, nil
+	}
+
+	opts.Rejections = newImportErrorRecorder(c.importErrorRepo, loadTest.ID)
+	// X12 sources and workbooks cannot be resumed part way through, as their structure is read
+	// from the start of the file
+	resumable := opts.Format != IMPORT_FORMAT_X12 && opts.Format != IMPORT_FORMAT_XLSX
+	if (loadTest.Method == "ludicrous" || loadTest.Method == "plaid") && resumable {
+		// Uploads are checkpointed too, but resuming one requires the file to be sent again
+		opts.Checkpoint = newCheckpointTracker(c.loadTestRepo, loadTest, "")
+	}
+
+	runCtx, finish, err := c.runs.Start(loadTest.ID)
+	if err != nil {
+		c.releaseImportKeys(ctx, req.IdempotencyKey, uploadKey)
+		return loadTest, err
+	}
+	defer finish()
+
+	// The upload is streamed from this request, so it waits here for a slot under the same
+	// limit as queued runs
+	release, err := c.acquireUploadSlot(runCtx, loadTest)
+	if err != nil {
+		c.releaseImportKeys(ctx, req.IdempotencyKey, uploadKey)
+		return loadTest, err
+	}
+	defer release()
+
+	log.Info("import created and started",
+		"loadTestId", loadTest.ID,
+		"method", loadTest.Method,
+		"format", loadTest.Format,
+		"encoding", encoding,
+		"dialect", opts.Dialect,
+		"dateOrder", opts.DateOrder,
+		"timezone", req.Timezone,
+		"locale", opts.Locale,
+		"fileName", req.FileName,
+		"size", size,
+		"checksum", checksum)
+
+	if err := c.processImport(runCtx, loadTest, source, opts); err != nil {
+		c.releaseImportKeys(ctx, req.IdempotencyKey, uploadKey)
+		return loadTest, err
+	}
+
+	return loadTest, nil
+}
+
+// processImport dispatches an uploaded file to the matching insertion strategy and records the result
+func (c *LoadTestController) processImport(
+	ctx context.Context,
+	loadTest *LoadTest,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
+) error {
+	log := c.log.Function("processImport")
+	testID := loadTest.ID.String()
+
+	c.wsManager.SendLoadTestProgress(testID, map[string]any{
+		"phase":           "insertion",
+		"overallProgress": 0,
+		"phaseProgress":   0,
+		"currentPhase":    "Streaming Upload",
+		"rowsProcessed":   0,
+		"rowsPerSecond":   0,
+		"eta":             "Calculating...",
+		"message": fmt.Sprintf(
+			"Streaming uploaded file using %s method...",
+			loadTest.Method,
+		),
+	})
+
+	var result importTimingResult
+	var err error
+
+	switch loadTest.Method {
+	case "plaid":
+		var timing PlaidTimingResult
+		timing, err = c.plaidController.RunPlaidCopyFromReader(ctx, source, loadTest.ID, opts)
+		result = importTimingResult(timing)
+	case "optimized":
+		var timing OptimizedTimingResult
+		timing, err = c.optimizedController.ImportStream(ctx, loadTest, source, opts)
+		result = importTimingResult{
+			ParseTime:        timing.ParseTime,
+			InsertTime:       timing.InsertTime,
+			TotalTime:        timing.ParseTime + timing.InsertTime,
+			RecordsProcessed: timing.RecordsProcessed,
+			BatchRetries:     timing.BatchRetries,
+			DateCache:        timing.DateCache,
+		}
+	case "ludicrous":
+		var timing LudicrousTimingResult
+		timing, err = c.ludicrousController.ImportStream(ctx, loadTest, source, opts)
+		result = importTimingResult{
+			ParseTime:        timing.ParseTime,
+			InsertTime:       timing.InsertTime,
+			TotalTime:        timing.ParseTime + timing.InsertTime,
+			RecordsProcessed: timing.RecordsProcessed,
+			BatchRetries:     timing.BatchRetries,
+			DateCache:        timing.DateCache,
+		}
+	default:
+		result, err = c.importStreamWithProgress(ctx, loadTest, source, opts, testID)
+	}
+
+	opts.rejections().Flush(ctx)
+	loadTest.RejectedRows = opts.rejections().Rejected()
+	loadTest.UnwrittenErrors = opts.rejections().Unwritten()
+	if loadTest.UnwrittenErrors > 0 {
+		log.Warn("import errors could not all be written, the error report is incomplete",
+			"loadTestId", loadTest.ID,
+			"unwritten", loadTest.UnwrittenErrors)
+	}
+	loadTest.ReplacementRows = opts.rejections().ReplacementRows()
+	loadTest.EnvelopeErrors = opts.rejections().EnvelopeErrors()
+	loadTest.DateOrders = opts.dateOrders()
+	if ambiguous := loadTest.DateOrders.Ambiguous(); len(ambiguous) > 0 {
+		log.Warn("date order could not be inferred, dates read value by value",
+			"loadTestId", loadTest.ID,
+			"columns", ambiguous)
+	}
+
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Import failed", err)
+		return err
+	}
+
+	loadTest.Rows = result.RecordsProcessed
+	loadTest.ParseTime = &result.ParseTime
+	loadTest.InsertTime = &result.InsertTime
+	loadTest.TotalTime = &result.TotalTime
+	loadTest.Status = "completed"
+	recordRetries(loadTest, result.BatchRetries)
+	recordDateCache(loadTest, result.DateCache)
+	recordImportCounts(loadTest, opts, result.RecordsProcessed)
+
+	if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
+		_ = log.Err("failed to update completed load test", err, "loadTestId", loadTest.ID)
+	}
+	opts.checkpoint().complete(ctx)
+
+	c.wsManager.SendLoadTestComplete(testID, map[string]any{
+		"id":              loadTest.ID.String(),
+		"rows":            loadTest.Rows,
+		"rejectedRows":    loadTest.RejectedRows,
+		"unwrittenErrors": loadTest.UnwrittenErrors,
+		"replacementRows": loadTest.ReplacementRows,
+		"envelopeErrors":  loadTest.EnvelopeErrors,
+		"dateOrders":      loadTest.DateOrders,
+		"retries":         loadTest.Retries,
+		"dateCacheHits":   loadTest.DateCacheHits,
+		"dateCacheMisses": loadTest.DateCacheMisses,
+		"importMode":      loadTest.ImportMode,
+		"insertedRows":    loadTest.InsertedRows,
+		"updatedRows":     loadTest.UpdatedRows,
+		"unchangedRows":   loadTest.UnchangedRows,
+		"columns":         loadTest.Columns,
+		"dateColumns":     loadTest.DateColumns,
+		"method":          loadTest.Method,
+		"status":          "completed",
+		"source":          loadTest.Source,
+		"parseTime":       result.ParseTime,
+		"insertTime":      result.InsertTime,
+		"totalTime":       result.TotalTime,
+	})
+
+	log.Info("import completed successfully",
+		"loadTestId", loadTest.ID,
+		"rows", loadTest.Rows,
+		"rejectedRows", loadTest.RejectedRows,
+		"replacementRows", loadTest.ReplacementRows,
+		"envelopeErrors", len(loadTest.EnvelopeErrors),
+		"bytesRead", source.BytesRead(),
+		"compression", source.Compression(),
+		"encoding", source.Encoding(),
+		"totalTime", result.TotalTime,
+		"method", loadTest.Method)
+
+	return nil
+}
+
+// importStreamWithProgress parses the upload row by row and inserts it using the brute_force or
+// batched method without holding the whole file in memory
+func (c *LoadTestController) importStreamWithProgress(
+	ctx context.Context,
+	loadTest *LoadTest,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
+	testID string,
+) (importTimingResult, error) {
+	log := c.log.Function("importStreamWithProgress")
+	startTime := time.Now()
+
+	reader := opts.recordReader(source)
+	if csvReader := csvReaderOf(reader); csvReader != nil {
+		csvReader.ReuseRecord = true
+	}
+
+	headers, err := reader.Read()
+	if err != nil {
+		return importTimingResult{}, fmt.Errorf("failed to read headers: %w", err)
+	}
+	headers = opts.resolveFields(headers)
+
+	headerIndex := make(map[string]int, len(headers))
+	for i, header := range headers {
+		headerIndex[header] = i
+	}
+	loadTest.Columns = len(headers)
+
+	// Dates are validated once per distinct value for the run
+	dates := newDateCache(c.dateUtils)
+
+	var insertDuration time.Duration
+	batch := make([]*TestData, 0, importBatchSize)
+	rowCount := 0
+	insertedCount := 0
+	rejections := opts.rejections()
+	lastUpdateTime := time.Now()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		insertStart := time.Now()
+		defer func() { insertDuration += time.Since(insertStart) }()
+
+		if upsert := opts.upsert(); upsert != nil {
+			fields := TestDataImportFields()
+			counts, err := c.testDataRepo.UpsertBatch(ctx, batch, fields, upsert.Columns)
+			if err != nil {
+				return fmt.Errorf("batch upsert failed: %w", err)
+			}
+			upsert.record(counts)
+		} else if loadTest.Method == "batched" {
+			if err := c.testDataRepo.CreateBatch(ctx, batch, importBatchSize); err != nil {
+				return fmt.Errorf("batch insertion failed: %w", err)
+			}
+		} else {
+			for _, data := range batch {
+				if err := c.testDataRepo.Create(ctx, data); err != nil {
+					return fmt.Errorf("record insertion failed: %w", err)
+				}
+			}
+		}
+
+		batch = make([]*TestData, 0, importBatchSize)
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return importTimingResult{}, fmt.Errorf("import cancelled: %w", err)
+		}
+
+		record, line, err := rejections.nextRecord(ctx, reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return importTimingResult{}, fmt.Errorf("failed to read row %d: %w", rowCount+1, err)
+		}
+
+		rowCount++
+
+		data := &TestData{LoadTestID: loadTest.ID}
+		issues := checkRowStructure(record, headers)
+		if len(issues) == 0 {
+			issues = c.parseAndValidateRow(record, headers, headerIndex, data, dates)
+		}
+		if len(issues) > 0 {
+			rejections.RejectRow(ctx, line, issues, record)
+			continue
+		}
+		batch = append(batch, data)
+		insertedCount++
+
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				return importTimingResult{}, err
+			}
+		}
+
+		if time.Since(lastUpdateTime) > 2*time.Second {
+			elapsed := time.Since(startTime)
+			progress := source.Fraction() * 100
+			c.wsManager.SendLoadTestProgress(testID, map[string]any{
+				"phase":           "insertion",
+				"overallProgress": progress,
+				"phaseProgress":   progress,
+				"currentPhase":    "Streaming Upload",
+				"rowsProcessed":   rowCount,
+				"rowsPerSecond":   int(float64(rowCount) / elapsed.Seconds()),
+				"eta":             "Calculating...",
+				"message": fmt.Sprintf(
+					"Imported %d records using %s method (%s elapsed)...",
+					rowCount,
+					loadTest.Method,
+					elapsed.Round(time.Second),
+				),
+			})
+			lastUpdateTime = time.Now()
+		}
+	}
+
+	if err := flush(); err != nil {
+		return importTimingResult{}, err
+	}
+
+	totalTime := int(time.Since(startTime).Milliseconds())
+	insertTime := int(insertDuration.Milliseconds())
+
+	if rejected := rejections.Rejected(); rejected > 0 {
+		log.Warn("rows rejected during import",
+			"rejectedCount", rejected,
+			"rowCount", rowCount)
+	}
+
+	return importTimingResult{
+		ParseTime:        totalTime - insertTime,
+		InsertTime:       insertTime,
+		TotalTime:        totalTime,
+		RecordsProcessed: insertedCount,
+		DateCache:        dates.Stats(),
+	}, nil
+}
+
+// isAlphanumeric reports whether b is an ASCII letter or digit, which X12 never uses as a separator
+func isAlphanumeric(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
@@ -0,0 +1,413 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"server/internal/database"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"server/internal/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// importErrorFlushSize is the number of pending rejections buffered before writing to the database
+const importErrorFlushSize = 500
+
+// importErrorRetryPolicy retries writes of pending rejections that failed with a transient error
+var importErrorRetryPolicy = database.RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+// importErrorPageSize is the page size used when streaming rejections into a CSV download
+const importErrorPageSize = 1000
+
+// maxEnvelopeErrors caps the X12 envelope problems kept for a load test. Any beyond it are only
+// counted, since a badly framed file can produce one for nearly every segment.
+const maxEnvelopeErrors = 100
+
+// rowIssue describes a single problem found while validating an import row
+type rowIssue struct {
+	Column   string
+	RawValue string
+	Reason   string
+	Message  string
+}
+
+func (i rowIssue) String() string {
+	if i.Column == "" {
+		return fmt.Sprintf("%s: %s", i.Reason, i.Message)
+	}
+	return fmt.Sprintf("%s in %s: %s", i.Reason, i.Column, i.Message)
+}
+
+// checkRowStructure reports problems with the shape and encoding of a row before any field is parsed
+func checkRowStructure(record []string, fields []string) []rowIssue {
+	var issues []rowIssue
+
+	if len(record) > len(fields) {
+		issues = append(issues, rowIssue{
+			Reason:  IMPORT_ERROR_TOO_MANY_FIELDS,
+			Message: fmt.Sprintf("expected %d fields, found %d", len(fields), len(record)),
+		})
+	}
+
+	for i, value := range record {
+		if !utf8.ValidString(value) {
+			column := ""
+			if i < len(fields) {
+				column = fields[i]
+			}
+			issues = append(issues, rowIssue{
+				Column:   column,
+				RawValue: strings.ToValidUTF8(value, "�"),
+				Reason:   IMPORT_ERROR_ENCODING,
+				Message:  "value is not valid UTF-8",
+			})
+		}
+	}
+
+	return issues
+}
+
+// dateFieldIssues reports date fields that had a value in the row but were left unset by a
+// parser that silently drops unrecognized dates
+func dateFieldIssues(record []string, fields []string, data *TestData) []rowIssue {
+	var issues []rowIssue
+
+	for i, field := range fields {
+		if i >= len(record) || record[i] == "" || !slices.Contains(TestDataDateFields, field) {
+			continue
+		}
+		if value := data.FieldPointer(field); value != nil && *value == nil {
+			issues = append(issues, rowIssue{
+				Column:   field,
+				RawValue: record[i],
+				Reason:   IMPORT_ERROR_INVALID_DATE,
+				Message:  "unrecognized date format",
+			})
+		}
+	}
+
+	return issues
+}
+
+// importErrorRecorder collects rejected rows for a load test and persists them in batches.
+// It is safe for concurrent use, and a nil recorder discards everything.
+type importErrorRecorder struct {
+	repo            repositories.ImportErrorRepository
+	loadTestID      uuid.UUID
+	log             logger.Logger
+	mu              sync.Mutex
+	pending         []*ImportError
+	rejected        int
+	unwritten       int // Issues dropped because writing them to import_errors failed
+	replaced        int // Rows holding U+FFFD, left where transcoding met bytes it could not decode
+	envelope        EnvelopeErrors
+	envelopeDropped int // Envelope problems found after maxEnvelopeErrors were kept
+	lineOffset      int // Source lines consumed before this reader started, when resuming
+}
+
+func newImportErrorRecorder(
+	repo repositories.ImportErrorRepository,
+	loadTestID uuid.UUID,
+) *importErrorRecorder {
+	return &importErrorRecorder{
+		repo:       repo,
+		loadTestID: loadTestID,
+		log:        logger.New("importErrorRecorder"),
+	}
+}
+
+// RejectRow records every issue found on a row. record may be nil when the row could not be parsed.
+func (r *importErrorRecorder) RejectRow(
+	ctx context.Context,
+	line int,
+	issues []rowIssue,
+	record []string,
+) {
+	if r == nil || len(issues) == 0 {
+		return
+	}
+
+	rawRow := encodeCSVRow(record)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rejected++
+	for _, issue := range issues {
+		importError := &ImportError{
+			LoadTestID: r.loadTestID,
+			LineNumber: line + r.lineOffset,
+			ReasonCode: issue.Reason,
+			Message:    issue.Message,
+			RawRow:     rawRow,
+		}
+		if issue.Column != "" {
+			column := issue.Column
+			importError.Column = &column
+		}
+		if issue.RawValue != "" {
+			rawValue := issue.RawValue
+			importError.RawValue = &rawValue
+		}
+		r.pending = append(r.pending, importError)
+	}
+
+	if len(r.pending) >= importErrorFlushSize {
+		r.flushLocked(ctx)
+	}
+}
+
+// Rejected returns the number of rows rejected so far
+func (r *importErrorRecorder) Rejected() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rejected
+}
+
+// Unwritten returns the number of issues that could not be written to the database, so the
+// rejections recorded for the load test are incomplete
+func (r *importErrorRecorder) Unwritten() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unwritten
+}
+
+// ReplacementRows returns the number of rows read so far that contain a replacement character
+func (r *importErrorRecorder) ReplacementRows() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.replaced
+}
+
+// countReplacements notes a row that contains a replacement character
+func (r *importErrorRecorder) countReplacements(record []string) {
+	for _, value := range record {
+		if strings.ContainsRune(value, utf8.RuneError) {
+			r.mu.Lock()
+			r.replaced++
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+// RecordEnvelopeError notes a problem with the envelopes of an X12 source. Envelope problems do
+// not reject any row, so they are kept for the load test rather than written as import errors.
+// Only the first maxEnvelopeErrors are kept and logged.
+func (r *importErrorRecorder) RecordEnvelopeError(envelopeErr utils.X12EnvelopeError) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.envelope) >= maxEnvelopeErrors {
+		r.envelopeDropped++
+		return
+	}
+
+	r.log.Function("RecordEnvelopeError").Warn("X12 envelope error",
+		"loadTestId", r.loadTestID,
+		"segment", envelopeErr.Segment,
+		"position", envelopeErr.Position,
+		"controlNumber", envelopeErr.ControlNumber,
+		"message", envelopeErr.Message)
+	r.envelope = append(r.envelope, EnvelopeError(envelopeErr))
+}
+
+// EnvelopeErrors returns the envelope problems found so far, ending with a note of how many more
+// were found once the cap was reached
+func (r *importErrorRecorder) EnvelopeErrors() EnvelopeErrors {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	envelope := slices.Clone(r.envelope)
+	if r.envelopeDropped > 0 {
+		envelope = append(envelope, EnvelopeError{
+			Message: fmt.Sprintf("%d more envelope errors were not recorded", r.envelopeDropped),
+		})
+	}
+	return envelope
+}
+
+// Flush writes any pending rejections to the database
+func (r *importErrorRecorder) Flush(ctx context.Context) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked(ctx)
+}
+
+func (r *importErrorRecorder) flushLocked(ctx context.Context) {
+	if len(r.pending) == 0 {
+		return
+	}
+
+	retries, err := importErrorRetryPolicy.Run(ctx, func() error {
+		return r.repo.CreateBatch(ctx, r.pending)
+	})
+	if err != nil {
+		r.log.Function("flush").
+			Er("failed to persist import errors", err,
+				"loadTestId", r.loadTestID,
+				"count", len(r.pending),
+				"retries", retries)
+		r.unwritten += len(r.pending)
+	}
+	r.pending = nil
+}
+
+// nextRecord reads the next record and its starting line number. Malformed rows are recorded
+// as rejections and skipped, so only I/O errors and io.EOF are returned. Without a recorder,
+// parse errors are returned to the caller unchanged.
+func (r *importErrorRecorder) nextRecord(
+	ctx context.Context,
+	reader utils.RecordReader,
+) ([]string, int, error) {
+	for {
+		record, err := reader.Read()
+		if err == nil {
+			if r != nil {
+				r.countReplacements(record)
+			}
+			line, _ := reader.FieldPos(0)
+			return record, line, nil
+		}
+
+		var parseErr *csv.ParseError
+		if r != nil && errors.As(err, &parseErr) {
+			r.RejectRow(ctx, parseErr.StartLine, []rowIssue{{
+				Reason:  IMPORT_ERROR_MALFORMED_ROW,
+				Message: parseErr.Err.Error(),
+			}}, nil)
+			continue
+		}
+
+		return nil, 0, err
+	}
+}
+
+// encodeCSVRow re-encodes a record as a single CSV line so rejected rows can be downloaded as-is
+func encodeCSVRow(record []string) *string {
+	if record == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(record)
+	writer.Flush()
+
+	row := strings.ToValidUTF8(strings.TrimRight(buf.String(), "\r\n"), "�")
+	return &row
+}
+
+// GetImportErrors returns a page of rejected rows for a load test along with the total count
+func (c *LoadTestController) GetImportErrors(
+	ctx context.Context,
+	loadTestID string,
+	offset, limit int,
+) ([]*ImportError, int64, error) {
+	total, err := c.importErrorRepo.CountByLoadTestID(ctx, loadTestID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	importErrors, err := c.importErrorRepo.GetByLoadTestIDPaginated(ctx, loadTestID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return importErrors, total, nil
+}
+
+// WriteImportErrorsCSV streams every rejected row for a load test to w as CSV
+func (c *LoadTestController) WriteImportErrorsCSV(
+	ctx context.Context,
+	loadTestID string,
+	w io.Writer,
+) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"line_number",
+		"column",
+		"reason_code",
+		"raw_value",
+		"message",
+		"raw_row",
+	}); err != nil {
+		return err
+	}
+
+	for offset := 0; ; offset += importErrorPageSize {
+		importErrors, err := c.importErrorRepo.GetByLoadTestIDPaginated(
+			ctx,
+			loadTestID,
+			offset,
+			importErrorPageSize,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, importError := range importErrors {
+			if err := writer.Write([]string{
+				strconv.Itoa(importError.LineNumber),
+				stringValue(importError.Column),
+				importError.ReasonCode,
+				stringValue(importError.RawValue),
+				importError.Message,
+				stringValue(importError.RawRow),
+			}); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+		if len(importErrors) < importErrorPageSize {
+			return nil
+		}
+	}
+}
+
+// stringValue dereferences an optional string, returning "" for nil
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
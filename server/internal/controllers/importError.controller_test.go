@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	. "server/internal/models"
+	"server/internal/utils"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestImportErrorRecorder_EnvelopeErrorCap(t *testing.T) {
+	testCases := []struct {
+		name         string
+		recorded     int
+		expectedKept int
+		expectedNote string
+	}{
+		{"under the cap", 3, 3, ""},
+		{"at the cap", maxEnvelopeErrors, maxEnvelopeErrors, ""},
+		{"over the cap", maxEnvelopeErrors + 25, maxEnvelopeErrors, "25 more envelope errors were not recorded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := newImportErrorRecorder(nil, uuid.New())
+			for i := range tc.recorded {
+				recorder.RecordEnvelopeError(utils.X12EnvelopeError{Segment: "SE", Position: i + 1, Message: "trailer counts"})
+			}
+
+			envelopeErrors := recorder.EnvelopeErrors()
+			expectedLen := tc.expectedKept
+			if tc.expectedNote != "" {
+				expectedLen++
+			}
+			if len(envelopeErrors) != expectedLen {
+				t.Fatalf("Expected %d envelope errors, got %d", expectedLen, len(envelopeErrors))
+			}
+			if envelopeErrors[tc.expectedKept-1].Position != tc.expectedKept {
+				t.Errorf("Expected the first %d envelope errors to be kept", tc.expectedKept)
+			}
+			if tc.expectedNote != "" && envelopeErrors[len(envelopeErrors)-1] != (EnvelopeError{Message: tc.expectedNote}) {
+				t.Errorf("Expected a final note %q, got %+v", tc.expectedNote, envelopeErrors[len(envelopeErrors)-1])
+			}
+		})
+	}
+}
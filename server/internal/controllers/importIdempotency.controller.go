@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"server/config"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IMPORT_SPOOL_DIR holds uploads while they are checksummed and imported. Each upload is spooled
+// in full, as its checksum decides whether it is imported at all and its format and dialect are
+// sniffed from the start of it, then removed once the import returns.
+const IMPORT_SPOOL_DIR = "/tmp/load_tests/uploads"
+
+// DEFAULT_IMPORT_SPOOL_MAX_MB bounds the size of a spooled upload unless IMPORT_SPOOL_MAX_MB is set
+const DEFAULT_IMPORT_SPOOL_MAX_MB = 2048
+
+var (
+	ErrUploadTooLarge       = errors.New("upload is larger than the import spool allows")
+	ErrIdempotencyKeyReused = errors.New("Idempotency-Key was already used for a different file")
+)
+
+// importSpoolLimit returns the largest upload, in bytes, that is spooled for import
+func importSpoolLimit(config config.Config) int64 {
+	maxMB := config.ImportSpoolMaxMB
+	if maxMB <= 0 {
+		maxMB = DEFAULT_IMPORT_SPOOL_MAX_MB
+	}
+	return int64(maxMB) << 20
+}
+
+// idempotencyTTL returns how long idempotency records are kept
+func idempotencyTTL(config config.Config) time.Duration {
+	if config.IdempotencyTTLHours > 0 {
+		return time.Duration(config.IdempotencyTTLHours) * time.Hour
+	}
+	return repositories.DEFAULT_IDEMPOTENCY_TTL
+}
+
+// spoolUpload copies an upload to a temporary file while computing its SHA-256, so a repeated
+// file can be recognised before any of it is imported. Uploads over limit bytes are refused with
+// ErrUploadTooLarge rather than filling the disk. The caller removes the file.
+func spoolUpload(input io.Reader, limit int64) (*os.File, string, int64, error) {
+	if err := os.MkdirAll(IMPORT_SPOOL_DIR, 0755); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(IMPORT_SPOOL_DIR, "upload_*.csv")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create upload file: %w", err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(input, limit+1))
+	if err == nil && size > limit {
+		removeSpool(file)
+		return nil, "", 0, fmt.Errorf("%w: at most %d MB", ErrUploadTooLarge, limit>>20)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeSpool(file)
+		return nil, "", 0, fmt.Errorf("failed to spool upload: %w", err)
+	}
+
+	return file, hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func removeSpool(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// importUploadKey identifies an import by the checksum of its file together with the method and
+// resolved options it is imported with, so the same file sent again with another method or other
+// options is imported again rather than replayed
+func importUploadKey(checksum string, method string, encoding string, opts *ImportOptions) (string, error) {
+	settings := struct {
+		Method          string
+		Encoding        string
+		Format          string
+		Dialect         *CSVDialect
+		Mapping         *ColumnMappingProfile
+		UpsertKey       string
+		Sheet           string
+		HeaderRow       *int
+		DateOrder       string
+		Timezone        string
+		ColumnTimezones map[string]string
+		RecordOffsets   bool
+		Locale          string
+	}{
+		Method:        method,
+		Encoding:      encoding,
+		Format:        opts.Format,
+		Dialect:       opts.Dialect,
+		Mapping:       opts.Mapping,
+		Sheet:         opts.Sheet,
+		HeaderRow:     opts.HeaderRow,
+		DateOrder:     opts.DateOrder,
+		RecordOffsets: opts.RecordOffsets,
+		Locale:        string(opts.Locale),
+	}
+	if opts.Upsert != nil {
+		settings.UpsertKey = opts.Upsert.key()
+	}
+	if opts.Timezone != nil {
+		settings.Timezone = opts.Timezone.String()
+	}
+	if len(opts.ColumnTimezones) > 0 {
+		settings.ColumnTimezones = make(map[string]string, len(opts.ColumnTimezones))
+		for field, location := range opts.ColumnTimezones {
+			settings.ColumnTimezones[field] = location.String()
+		}
+	}
+
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode import options: %w", err)
+	}
+	hash := sha256.New()
+	hash.Write([]byte(checksum))
+	hash.Write(encoded)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checksumUpload reads an upload to its end and returns its SHA-256 without keeping any of it
+func checksumUpload(input io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, input); err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replayKey returns the load test a client's Idempotency-Key already refers to, or nil if the key
+// is new. The upload is checksummed without being spooled, so a key sent again with a different
+// file fails with ErrIdempotencyKeyReused instead of returning another file's import.
+func (c *LoadTestController) replayKey(
+	ctx context.Context,
+	key string,
+	input io.Reader,
+) (*LoadTest, error) {
+	record, err := c.idempotencyRepo.Get(ctx, repositories.IDEMPOTENCY_KEY_HASH, key)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	loadTest, err := c.replayImport(ctx, repositories.IDEMPOTENCY_KEY_HASH, key, record)
+	if err != nil || loadTest == nil {
+		return nil, err
+	}
+
+	checksum, err := checksumUpload(input)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != record.Checksum {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	return loadTest, nil
+}
+
+// findImport returns the load test an earlier submission created for key, or nil if there is none
+// to return. Records of imports that failed or were cancelled are dropped so the file can be sent
+// again.
+func (c *LoadTestController) findImport(
+	ctx context.Context,
+	hashPattern string,
+	key string,
+) (*LoadTest, error) {
+	record, err := c.idempotencyRepo.Get(ctx, hashPattern, key)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	return c.replayImport(ctx, hashPattern, key, record)
+}
+
+// replayImport loads the load test behind an idempotency record, deleting the record if that
+// import should not be replayed. The record is kept when the load test could not be loaded for
+// any reason other than it no longer existing.
+func (c *LoadTestController) replayImport(
+	ctx context.Context,
+	hashPattern string,
+	key string,
+	record *IdempotencyRecord,
+) (*LoadTest, error) {
+	loadTest, err := c.loadTestRepo.GetByID(ctx, record.LoadTestID.String())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load replayed import: %w", err)
+	}
+	if err != nil || loadTest.Status == "failed" || loadTest.Status == "cancelled" {
+		if err := c.idempotencyRepo.Delete(ctx, hashPattern, key); err != nil {
+			c.log.Function("replayImport").Warn("failed to drop stale idempotency record", "key", key, "error", err)
+		}
+		return nil, nil
+	}
+
+	loadTest.Replayed = true
+	return loadTest, nil
+}
+
+// claimImport records loadTest as the import for key. If an identical submission claimed key
+// first, its load test is returned instead; a submission of a different file under the same key
+// fails with ErrIdempotencyKeyReused.
+func (c *LoadTestController) claimImport(
+	ctx context.Context,
+	hashPattern string,
+	key string,
+	record *IdempotencyRecord,
+) (*LoadTest, error) {
+	existing, err := c.idempotencyRepo.Reserve(ctx, hashPattern, key, record, c.idempotencyTTL)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	loadTest, err := c.replayImport(ctx, hashPattern, key, existing)
+	if err != nil {
+		return nil, err
+	}
+	if loadTest != nil {
+		if existing.Checksum != record.Checksum {
+			return nil, ErrIdempotencyKeyReused
+		}
+		return loadTest, nil
+	}
+
+	// The earlier import did not succeed, so this one takes its place
+	return nil, c.idempotencyRepo.Save(ctx, hashPattern, key, record, c.idempotencyTTL)
+}
+
+// claimImportKeys claims the upload key from importUploadKey and, when sent, the client's
+// Idempotency-Key for loadTest. It returns the load test of an earlier identical submission if
+// there was one.
+func (c *LoadTestController) claimImportKeys(
+	ctx context.Context,
+	idempotencyKey string,
+	uploadKey string,
+	checksum string,
+	loadTest *LoadTest,
+) (*LoadTest, error) {
+	record := &IdempotencyRecord{
+		LoadTestID: loadTest.ID,
+		Checksum:   checksum,
+		CreatedAt:  time.Now(),
+	}
+
+	existing, err := c.claimImport(ctx, repositories.IDEMPOTENCY_CHECKSUM_HASH, uploadKey, record)
+	if err != nil || idempotencyKey == "" {
+		return existing, err
+	}
+
+	if existing != nil {
+		// The key now refers to the import that owns the file
+		return existing, c.saveImportKey(ctx, idempotencyKey, checksum, existing)
+	}
+
+	existing, err = c.claimImport(ctx, repositories.IDEMPOTENCY_KEY_HASH, idempotencyKey, record)
+	if existing != nil || err != nil {
+		c.releaseImportKeys(ctx, "", uploadKey)
+	}
+	return existing, err
+}
+
+// saveImportKey records a client's Idempotency-Key against the existing import of its file, so a
+// retry with the key is answered without spooling the file again
+func (c *LoadTestController) saveImportKey(
+	ctx context.Context,
+	idempotencyKey string,
+	checksum string,
+	loadTest *LoadTest,
+) error {
+	record := &IdempotencyRecord{
+		LoadTestID: loadTest.ID,
+		Checksum:   checksum,
+		CreatedAt:  time.Now(),
+	}
+	return c.idempotencyRepo.Save(ctx, repositories.IDEMPOTENCY_KEY_HASH, idempotencyKey, record, c.idempotencyTTL)
+}
+
+// releaseImportKeys forgets a failed import so the same file or key can be submitted again
+func (c *LoadTestController) releaseImportKeys(ctx context.Context, idempotencyKey string, uploadKey string) {
+	log := c.log.Function("releaseImportKeys")
+
+	if err := c.idempotencyRepo.Delete(ctx, repositories.IDEMPOTENCY_CHECKSUM_HASH, uploadKey); err != nil {
+		log.Warn("failed to release upload key", "uploadKey", uploadKey, "error", err)
+	}
+	if idempotencyKey == "" {
+		return
+	}
+	if err := c.idempotencyRepo.Delete(ctx, repositories.IDEMPOTENCY_KEY_HASH, idempotencyKey); err != nil {
+		log.Warn("failed to release idempotency key", "key", idempotencyKey, "error", err)
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "server/internal/models"
+	"strings"
+	"sync"
+)
+
+// ErrUpsertKeyRequired is returned for upsert imports sent without a natural key
+var ErrUpsertKeyRequired = errors.New("upsertKey is required for upsert imports")
+
+// upsertImport carries the natural key of an upsert import and tallies how its rows were applied
+// across the insert workers
+type upsertImport struct {
+	Fields  []string // Canonical key fields, e.g. "member_id"
+	Columns []string // Database columns of Fields
+	mu      sync.Mutex
+	counts  UpsertCounts
+}
+
+// upsert returns the natural key settings, or nil when the import only inserts
+func (o *ImportOptions) upsert() *upsertImport {
+	if o == nil {
+		return nil
+	}
+	return o.Upsert
+}
+
+// supportsUpsert reports whether an insertion method can upsert. Brute force and optimized
+// imports insert through GORM only.
+func supportsUpsert(method string) bool {
+	switch method {
+	case "batched", "ludicrous", "plaid":
+		return true
+	}
+	return false
+}
+
+// parseUpsertKey parses a comma-separated list of canonical TestData fields
+func parseUpsertKey(key string) (*upsertImport, error) {
+	upsert := &upsertImport{}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(key, ",") {
+		field := strings.ToLower(strings.TrimSpace(part))
+		if field == "" || seen[field] {
+			continue
+		}
+		if !IsTestDataField(field) {
+			return nil, fmt.Errorf("unknown upsert key field: %s", field)
+		}
+		seen[field] = true
+		upsert.Fields = append(upsert.Fields, field)
+		upsert.Columns = append(upsert.Columns, TestDataColumn(field))
+	}
+
+	if len(upsert.Fields) == 0 {
+		return nil, ErrUpsertKeyRequired
+	}
+	return upsert, nil
+}
+
+// importMode validates the mode of an import request, returning the natural key for upserts.
+// The unique index the upsert conflicts against is created here, before any rows are read.
+func (c *LoadTestController) importMode(ctx context.Context, req *CreateImportRequest) (*upsertImport, error) {
+	switch req.Mode {
+	case "", IMPORT_MODE_INSERT:
+		return nil, nil
+	case IMPORT_MODE_UPSERT:
+	default:
+		return nil, fmt.Errorf("unknown import mode: %s", req.Mode)
+	}
+
+	if !supportsUpsert(req.Method) {
+		return nil, fmt.Errorf("upsert is not supported for the %s method", req.Method)
+	}
+
+	upsert, err := parseUpsertKey(req.UpsertKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.testDataRepo.EnsureNaturalKey(ctx, upsert.Columns); err != nil {
+		return nil, fmt.Errorf("failed to prepare natural key %s, check for duplicate keys: %w", upsert.key(), err)
+	}
+	return upsert, nil
+}
+
+// key returns the natural key as stored on the load test
+func (u *upsertImport) key() string {
+	return strings.Join(u.Fields, ",")
+}
+
+// hasField reports whether field is part of the natural key
+func (u *upsertImport) hasField(field string) bool {
+	if u == nil {
+		return false
+	}
+	for _, keyField := range u.Fields {
+		if keyField == field {
+			return true
+		}
+	}
+	return false
+}
+
+// withKeyFields returns fields followed by any key fields it does not already contain
+func (u *upsertImport) withKeyFields(fields []string) []string {
+	combined := append([]string{}, fields...)
+	for _, field := range u.Fields {
+		found := false
+		for _, existing := range fields {
+			if existing == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			combined = append(combined, field)
+		}
+	}
+	return combined
+}
+
+// record adds the outcome of one committed batch
+func (u *upsertImport) record(counts UpsertCounts) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.counts.Inserted += counts.Inserted
+	u.counts.Updated += counts.Updated
+	u.counts.Unchanged += counts.Unchanged
+	u.mu.Unlock()
+}
+
+func (u *upsertImport) result() UpsertCounts {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.counts
+}
+
+// recordImportCounts stores how the rows of a finished import were applied. Insert-only imports
+// insert every row they process.
+func recordImportCounts(loadTest *LoadTest, opts *ImportOptions, rowsProcessed int) {
+	upsert := opts.upsert()
+	if upsert == nil {
+		loadTest.InsertedRows = rowsProcessed
+		return
+	}
+
+	counts := upsert.result()
+	loadTest.InsertedRows = counts.Inserted
+	loadTest.UpdatedRows = counts.Updated
+	loadTest.UnchangedRows = counts.Unchanged
+}
@@ -19,12 +19,19 @@ import (
 type LoadTestController struct {
 	loadTestRepo        repositories.LoadTestRepository
 	testDataRepo        repositories.TestDataRepository
+	mappingRepo         repositories.ColumnMappingRepository
+	importErrorRepo     repositories.ImportErrorRepository
+	idempotencyRepo     repositories.IdempotencyRepository
 	plaidController     *PlaidController
 	optimizedController *OptimizedOnlyController
 	ludicrousController *LudicrousOnlyController
 	dateUtils           *utils.DateUtils
 	log                 logger.Logger
 	wsManager           WSManager
+	runs                *RunRegistry
+	queue               *LoadTestQueue
+	idempotencyTTL      time.Duration
+	spoolLimit          int64
 }
 
 // WSManager interface for WebSocket operations to avoid import cycles
@@ -32,15 +39,21 @@ type WSManager interface {
 	SendLoadTestProgress(testID string, data map[string]any)
 	SendLoadTestComplete(testID string, testResult map[string]any)
 	SendLoadTestError(testID string, errorMsg string)
+	SendLoadTestCancelled(testID string, data map[string]any)
 }
 
 func NewLoadTestController(
 	loadTestRepo repositories.LoadTestRepository,
 	testDataRepo repositories.TestDataRepository,
+	mappingRepo repositories.ColumnMappingRepository,
+	importErrorRepo repositories.ImportErrorRepository,
+	idempotencyRepo repositories.IdempotencyRepository,
 	db database.DB,
 	wsManager WSManager,
 	config config.Config,
 	plaidController *PlaidController,
+	runs *RunRegistry,
+	queue *LoadTestQueue,
 ) *LoadTestController {
 	optimizedController := NewOptimizedOnlyController(
 		loadTestRepo,
@@ -48,6 +61,7 @@ func NewLoadTestController(
 		db,
 		wsManager,
 		config,
+		queue,
 	)
 	ludicrousController := NewLudicrousOnlyController(
 		loadTestRepo,
@@ -55,55 +69,36 @@ func NewLoadTestController(
 		db,
 		wsManager,
 		config,
+		queue,
 	)
 
 	return &LoadTestController{
 		loadTestRepo:        loadTestRepo,
 		testDataRepo:        testDataRepo,
+		mappingRepo:         mappingRepo,
+		importErrorRepo:     importErrorRepo,
+		idempotencyRepo:     idempotencyRepo,
 		plaidController:     plaidController,
 		optimizedController: optimizedController,
 		ludicrousController: ludicrousController,
-		dateUtils:           utils.NewDateUtils(),
+		dateUtils:           newDateColumnUtils(),
 		log:                 logger.New("loadTestController"),
 		wsManager:           wsManager,
+		runs:                runs,
+		queue:               queue,
+		idempotencyTTL:      idempotencyTTL(config),
+		spoolLimit:          importSpoolLimit(config),
 	}
 }
 
 // GetKnownDateColumns returns the list of known date column names that need validation
 func GetKnownDateColumns() []string {
-	return []string{
-		"birth_date",
-		"start_date",
-		"end_date",
-		"created_at",
-		"updated_at",
-	}
+	return append(append([]string{}, TestDataDateFields...), "created_at", "updated_at")
 }
 
 // GetMeaningfulColumns returns the list of meaningful column names for demographics, employment, and insurance
 func GetMeaningfulColumns() []string {
-	return []string{
-		"first_name",
-		"last_name",
-		"email",
-		"phone",
-		"address_line_1",
-		"address_line_2",
-		"city",
-		"state",
-		"zip_code",
-		"country",
-		"social_security_no",
-		"employer",
-		"job_title",
-		"department",
-		"salary",
-		"insurance_plan_id",
-		"insurance_carrier",
-		"policy_number",
-		"group_number",
-		"member_id",
-	}
+	return append([]string{}, TestDataMeaningfulFields...)
 }
 
 // CreateAndRunTest creates a new load test and starts the performance test
@@ -124,6 +119,11 @@ func (c *LoadTestController) CreateAndRunTest(
 		return c.ludicrousController.CreateAndRunTest(ctx, req)
 	}
 
+	locale, err := generatedLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the LoadTest record with fixed column structure
 	// We use a fixed structure: 5 date columns + 20 regular columns = 25 total
 	const FixedTotalColumns = 25
@@ -134,7 +134,9 @@ func (c *LoadTestController) CreateAndRunTest(
 		Columns:     FixedTotalColumns, // Override: always use 25 columns
 		DateColumns: FixedDateColumns,  // Override: always populate 6 date columns
 		Method:      req.Method,
-		Status:      "running",
+		Status:      "queued",
+		Source:      "generated",
+		Locale:      locale,
 	}
 
 	if err := c.loadTestRepo.Create(ctx, loadTest); err != nil {
@@ -142,16 +144,54 @@ func (c *LoadTestController) CreateAndRunTest(
 		return nil, fmt.Errorf("failed to create load test: %w", err)
 	}
 
-	// Process the load test asynchronously
-	go c.processLoadTest(ctx, loadTest)
+	// The run waits in the queue until a slot is free
+	if err := c.queue.Enqueue(ctx, loadTest, LOAD_TEST_JOB_RUN, req.Priority); err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Failed to queue load test", err)
+		return nil, err
+	}
+
+	log.Info("load test created and queued", "loadTestId", loadTest.ID, "method", loadTest.Method)
+	return c.queue.withPosition(ctx, loadTest), nil
+}
+
+// generatedLocale validates the locale a generated run writes its dates in, returning it as stored
+// on the load test; nil when tag is empty
+func generatedLocale(tag string) (*string, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	locale := utils.NormalizeLocale(tag)
+	if locale == "" {
+		return nil, fmt.Errorf("unsupported locale: %s", tag)
+	}
+	value := string(locale)
+	return &value, nil
+}
+
+// RunJob executes a load test claimed from the queue with the method it was created for
+func (c *LoadTestController) RunJob(ctx context.Context, job *LoadTestJob, loadTest *LoadTest) {
+	if job.Kind == LOAD_TEST_JOB_RESUME {
+		c.resumeFromDisk(ctx, loadTest)
+		return
+	}
 
-	log.Info("load test created and started", "loadTestId", loadTest.ID, "method", loadTest.Method)
-	return loadTest, nil
+	switch loadTest.Method {
+	case "optimized":
+		c.optimizedController.processLoadTest(ctx, loadTest)
+	case "ludicrous":
+		c.ludicrousController.processLoadTest(ctx, loadTest)
+	default:
+		c.processLoadTest(ctx, loadTest)
+	}
 }
 
-// GetLoadTestByID retrieves a load test by ID
+// GetLoadTestByID retrieves a load test by ID, including its queue position while it waits
 func (c *LoadTestController) GetLoadTestByID(ctx context.Context, id string) (*LoadTest, error) {
-	return c.loadTestRepo.GetByID(ctx, id)
+	loadTest, err := c.loadTestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.queue.withPosition(ctx, loadTest), nil
 }
 
 // GetAllLoadTests retrieves all load tests
@@ -382,6 +422,7 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 		Rows:        loadTest.Rows,
 		DateColumns: loadTest.DateColumns,
 		FilePrefix:  "load_test",
+		Locale:      utils.DateLocale(stringValue(loadTest.Locale)),
 		Context:     ctx,
 		ProgressCallback: func(phase string, progress float64, message string) {
 			c.wsManager.SendLoadTestProgress(testID, map[string]any{
@@ -399,12 +440,10 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 	csvResult, err := utils.GeneratePerformanceCSV(csvConfig)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "CSV generation failed", err)
-		c.wsManager.SendLoadTestError(testID, "CSV generation failed: "+err.Error())
 		return
 	}
 	csvPath := csvResult.FilePath
 	csvGenTime := csvResult.GenerationTime
-	defer os.Remove(csvPath)
 
 	if loadTest.Method == "plaid" {
 		// Go directly to plaid COPY streaming insertion
@@ -419,15 +458,18 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 			"message":         "Starting Plaid PostgreSQL COPY streaming insertion...",
 		})
 
+		// Checkpoint committed batches; the CSV is kept on failure so the run can be resumed
+		opts := &ImportOptions{Checkpoint: newCheckpointTracker(c.loadTestRepo, loadTest, csvPath)}
+
 		timingResult, err := c.plaidController.RunPlaidCopy(
 			ctx,
 			csvPath,
 			loadTest.ID,
 			loadTest.Rows,
+			opts,
 		)
 		if err != nil {
 			c.updateLoadTestError(ctx, loadTest, "Plaid COPY insertion failed", err)
-			c.wsManager.SendLoadTestError(testID, "Plaid COPY insertion failed: "+err.Error())
 			return
 		}
 
@@ -437,15 +479,20 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 		loadTest.InsertTime = &timingResult.InsertTime
 		loadTest.TotalTime = &timingResult.TotalTime
 		loadTest.Status = "completed"
+		recordRetries(loadTest, timingResult.BatchRetries)
+		recordDateCache(loadTest, timingResult.DateCache)
 
 		if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
 			_ = log.Err("failed to update completed load test", err, "loadTestId", loadTest.ID)
 		}
+		opts.Checkpoint.complete(ctx)
+		os.Remove(csvPath)
 
 		// Send completion notification
 		c.wsManager.SendLoadTestComplete(testID, map[string]any{
 			"id":          loadTest.ID.String(),
 			"rows":        loadTest.Rows,
+			"retries":     loadTest.Retries,
 			"columns":     loadTest.Columns,
 			"dateColumns": loadTest.DateColumns,
 			"method":      loadTest.Method,
@@ -464,6 +511,8 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 	}
 
 	// Traditional flow for brute_force and batched methods
+	defer os.Remove(csvPath)
+
 	// Progress update: CSV generation complete
 	c.wsManager.SendLoadTestProgress(testID, map[string]any{
 		"phase":           "parsing",
@@ -476,11 +525,11 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 		"message":         "Starting data parsing and validation...",
 	})
 
-	// Step 2: Parse and validate CSV data
-	testData, parseTime, err := c.parseAndValidateCSVWithProgress(csvPath, loadTest)
+	// Step 2: Parse and validate CSV data, validating each distinct date once
+	dates := newDateCache(c.dateUtils)
+	testData, parseTime, err := c.parseAndValidateCSVWithProgress(ctx, csvPath, loadTest, dates)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "CSV parsing failed", err)
-		c.wsManager.SendLoadTestError(testID, "CSV parsing failed: "+err.Error())
 		return
 	}
 
@@ -503,7 +552,6 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 	insertTime, err := c.insertTestDataWithProgress(ctx, testData, loadTest.Method, testID)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "Data insertion failed", err)
-		c.wsManager.SendLoadTestError(testID, "Data insertion failed: "+err.Error())
 		return
 	}
 
@@ -515,6 +563,7 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 	loadTest.InsertTime = &insertTime
 	loadTest.TotalTime = &totalTime
 	loadTest.Status = "completed"
+	recordDateCache(loadTest, dates.Stats())
 
 	if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
 		_ = log.Err("failed to update completed load test", err, "loadTestId", loadTest.ID)
@@ -522,16 +571,18 @@ func (c *LoadTestController) processLoadTest(ctx context.Context, loadTest *Load
 
 	// Send completion notification
 	c.wsManager.SendLoadTestComplete(testID, map[string]any{
-		"id":          loadTest.ID.String(),
-		"rows":        loadTest.Rows,
-		"columns":     loadTest.Columns,
-		"dateColumns": loadTest.DateColumns,
-		"method":      loadTest.Method,
-		"status":      "completed",
-		"csvGenTime":  csvGenTime,
-		"parseTime":   parseTime,
-		"insertTime":  insertTime,
-		"totalTime":   totalTime,
+		"id":              loadTest.ID.String(),
+		"rows":            loadTest.Rows,
+		"columns":         loadTest.Columns,
+		"dateColumns":     loadTest.DateColumns,
+		"method":          loadTest.Method,
+		"status":          "completed",
+		"csvGenTime":      csvGenTime,
+		"parseTime":       parseTime,
+		"insertTime":      insertTime,
+		"totalTime":       totalTime,
+		"dateCacheHits":   loadTest.DateCacheHits,
+		"dateCacheMisses": loadTest.DateCacheMisses,
 	})
 
 	log.Info("load test completed successfully",
@@ -1288,10 +1339,13 @@ func (c *LoadTestController) generateMeaningfulColumnValue(columnName string) st
 	}
 }
 
-// parseAndValidateCSVWithProgress reads the CSV file and validates only the populated date columns
+// parseAndValidateCSVWithProgress reads the CSV file and validates only the populated date columns,
+// through the run's date cache
 func (c *LoadTestController) parseAndValidateCSVWithProgress(
+	ctx context.Context,
 	csvPath string,
 	loadTest *LoadTest,
+	dates *utils.DateCache,
 ) ([]*TestData, int, error) {
 	log := c.log.Function("parseAndValidateCSV")
 	startTime := time.Now()
@@ -1315,6 +1369,9 @@ func (c *LoadTestController) parseAndValidateCSVWithProgress(
 		return nil, 0, fmt.Errorf("failed to read CSV headers: %w", err)
 	}
 
+	// Map source headers onto canonical field names
+	headers = (*ColumnMappingProfile)(nil).ResolveHeaders(headers)
+
 	// Create header index map for efficient lookups
 	headerIndex := make(map[string]int)
 	for i, header := range headers {
@@ -1332,6 +1389,10 @@ func (c *LoadTestController) parseAndValidateCSVWithProgress(
 
 	// Process each data row
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, fmt.Errorf("CSV parsing cancelled at row %d: %w", rowCount, err)
+		}
+
 		record, err := reader.Read()
 		if err != nil {
 			if err.Error() == "EOF" {
@@ -1348,8 +1409,8 @@ func (c *LoadTestController) parseAndValidateCSVWithProgress(
 		}
 
 		// Parse and validate the row
-		if err := c.parseAndValidateRow(record, headers, headerIndex, data); err != nil {
-			validationErrors = append(validationErrors, fmt.Sprintf("row %d: %v", rowCount, err))
+		if issues := c.parseAndValidateRow(record, headers, headerIndex, data, dates); len(issues) > 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("row %d: %v", rowCount, issues))
 			// Continue processing even with validation errors
 		}
 
@@ -1363,11 +1424,14 @@ func (c *LoadTestController) parseAndValidateCSVWithProgress(
 
 	parseTime := int(time.Since(startTime).Milliseconds())
 
+	dateCache := dates.Stats()
 	log.Info("CSV parsing completed",
 		"csvPath", csvPath,
 		"rowsParsed", rowCount,
 		"validationErrors", len(validationErrors),
-		"parseTimeMs", parseTime)
+		"parseTimeMs", parseTime,
+		"dateCacheHits", dateCache.Hits,
+		"dateCacheMisses", dateCache.Misses)
 
 	// Log validation errors if any (but don't fail)
 	if len(validationErrors) > 0 {
@@ -1385,56 +1449,63 @@ func (c *LoadTestController) parseAndValidateCSVWithProgress(
 	return testData, parseTime, nil
 }
 
-// parseAndValidateRow parses a single CSV row into a TestData struct with validation
+// parseAndValidateRow parses a single CSV row into a TestData struct with validation,
+// returning every problem found so callers can decide whether to reject the row. Dates are
+// validated through the run's date cache.
 func (c *LoadTestController) parseAndValidateRow(
 	record []string,
 	headers []string,
 	headerIndex map[string]int,
 	data *TestData,
-) error {
+	dates *utils.DateCache,
+) []rowIssue {
 	knownDateColumns := GetKnownDateColumns()
-	var validationErrors []string
+	var issues []rowIssue
 
 	// Map all columns from CSV to TestData struct
 	for i, header := range headers {
 		if i >= len(record) {
 			continue // Skip if record is shorter than headers
 		}
+		if header == "" {
+			continue // Column is not mapped to a TestData field
+		}
 
 		value := record[i]
 
 		// Handle date columns with validation and normalization
 		if c.isKnownDateColumn(header, knownDateColumns) {
-			// Set the value with validation and normalization
-			if err := c.setDateColumnValue(data, header, value); err != nil {
-				validationErrors = append(
-					validationErrors,
-					fmt.Sprintf("failed to set %s: %v", header, err),
-				)
-			}
-
-			// Additional validation reporting for non-empty values
-			if value != "" {
-				if _, isValid := c.validateAndNormalizeDateValue(value); !isValid {
-					validationErrors = append(
-						validationErrors,
-						fmt.Sprintf("invalid date in %s: %s", header, value),
-					)
-				}
+			// Set the value with validation and normalization, reporting values that don't parse
+			isValid, err := c.setDateColumnValue(data, header, value, dates)
+			if err != nil {
+				issues = append(issues, rowIssue{
+					Column:   header,
+					RawValue: value,
+					Reason:   IMPORT_ERROR_MALFORMED_ROW,
+					Message:  err.Error(),
+				})
+			} else if !isValid {
+				issues = append(issues, rowIssue{
+					Column:   header,
+					RawValue: value,
+					Reason:   IMPORT_ERROR_INVALID_DATE,
+					Message:  "unrecognized date format",
+				})
 			}
 		} else {
 			// Handle regular columns (no validation needed)
 			if err := c.setRegularColumnValue(data, header, value); err != nil {
-				validationErrors = append(validationErrors, fmt.Sprintf("failed to set %s: %v", header, err))
+				issues = append(issues, rowIssue{
+					Column:   header,
+					RawValue: value,
+					Reason:   IMPORT_ERROR_MALFORMED_ROW,
+					Message:  err.Error(),
+				})
 			}
 		}
 	}
 
-	if len(validationErrors) > 0 {
-		return fmt.Errorf("validation errors: %v", validationErrors)
-	}
-
-	return nil
+	return issues
 }
 
 // isKnownDateColumn checks if a column name is a known date column
@@ -1447,20 +1518,22 @@ func (c *LoadTestController) isKnownDateColumn(columnName string, knownDateColum
 	return false
 }
 
-// validateAndNormalizeDateValue validates and normalizes a date string to ISO8601 format with UTC timezone
-func (c *LoadTestController) validateAndNormalizeDateValue(value string) (string, bool) {
+// validateAndNormalizeDateValue validates and normalizes a date string to ISO8601 at the precision
+// it was written to, in UTC when it has a time of day
+func (c *LoadTestController) validateAndNormalizeDateValue(
+	value string,
+	dates *utils.DateCache,
+) (string, utils.DatePrecision, bool) {
 	if value == "" {
-		return "", true // Empty values are valid
+		return "", "", true // Empty values are valid
 	}
 
-	result := c.dateUtils.GetValidator().ValidateAndConvert(value)
+	result := dates.ValidateAndConvert(value)
 	if !result.IsValid {
-		return "", false
+		return "", "", false
 	}
 
-	// Convert to UTC and format as ISO8601 with timezone (RFC3339)
-	normalized := result.ParsedTime.UTC().Format(time.RFC3339)
-	return normalized, true
+	return result.Normalized(), result.Precision, true
 }
 
 // validateDateValue validates a date string using our date utils (keeping for backward compatibility)
@@ -1469,12 +1542,17 @@ func (c *LoadTestController) validateDateValue(value string) bool {
 	return result.IsValid
 }
 
-// setDateColumnValue sets a date column value in the TestData struct with normalization
-func (c *LoadTestController) setDateColumnValue(data *TestData, columnName, value string) error {
+// setDateColumnValue sets a date column value in the TestData struct with normalization,
+// reporting whether the value was a valid date. Empty values are valid.
+func (c *LoadTestController) setDateColumnValue(
+	data *TestData,
+	columnName, value string,
+	dates *utils.DateCache,
+) (bool, error) {
 	// Handle empty values and normalize non-empty ones
-	var valuePtr *string
+	var valuePtr, precisionPtr *string
+	normalized, precision, isValid := c.validateAndNormalizeDateValue(value, dates)
 	if value != "" {
-		normalized, isValid := c.validateAndNormalizeDateValue(value)
 		if !isValid {
 			// Log the invalid date but store the original value for debugging
 			c.log.Warn("invalid date detected, storing original value",
@@ -1483,24 +1561,29 @@ func (c *LoadTestController) setDateColumnValue(data *TestData, columnName, valu
 			valuePtr = &value
 		} else {
 			valuePtr = &normalized
+			precisionValue := string(precision)
+			precisionPtr = &precisionValue
 		}
 	}
 
 	switch columnName {
 	case "birth_date":
 		data.BirthDate = valuePtr
+		data.BirthDatePrecision = precisionPtr
 	case "start_date":
 		data.StartDate = valuePtr
+		data.StartDatePrecision = precisionPtr
 	case "end_date":
 		data.EndDate = valuePtr
+		data.EndDatePrecision = precisionPtr
 	case "created_at":
 		// CreatedAt field removed with BaseModel - ignoring this column
 	case "updated_at":
 		// UpdatedAt field removed with BaseModel - ignoring this column
 	default:
-		return fmt.Errorf("unknown date column: %s", columnName)
+		return false, fmt.Errorf("unknown date column: %s", columnName)
 	}
-	return nil
+	return isValid, nil
 }
 
 // setRegularColumnValue sets a regular column value in the TestData struct
@@ -1512,51 +1595,12 @@ func (c *LoadTestController) setRegularColumnValue(data *TestData, columnName, v
 	}
 
 	// Map meaningful column names to TestData fields
-	switch columnName {
-	case "first_name":
-		data.FirstName = valuePtr
-	case "last_name":
-		data.LastName = valuePtr
-	case "email":
-		data.Email = valuePtr
-	case "phone":
-		data.Phone = valuePtr
-	case "address_line_1":
-		data.AddressLine1 = valuePtr
-	case "address_line_2":
-		data.AddressLine2 = valuePtr
-	case "city":
-		data.City = valuePtr
-	case "state":
-		data.State = valuePtr
-	case "zip_code":
-		data.ZipCode = valuePtr
-	case "country":
-		data.Country = valuePtr
-	case "social_security_no":
-		data.SocialSecurityNo = valuePtr
-	case "employer":
-		data.Employer = valuePtr
-	case "job_title":
-		data.JobTitle = valuePtr
-	case "department":
-		data.Department = valuePtr
-	case "salary":
-		data.Salary = valuePtr
-	case "insurance_plan_id":
-		data.InsurancePlanID = valuePtr
-	case "insurance_carrier":
-		data.InsuranceCarrier = valuePtr
-	case "policy_number":
-		data.PolicyNumber = valuePtr
-	case "group_number":
-		data.GroupNumber = valuePtr
-	case "member_id":
-		data.MemberID = valuePtr
-	default:
+	field := data.FieldPointer(columnName)
+	if field == nil {
 		// Unknown column - log warning but continue
 		return fmt.Errorf("unknown meaningful column: %s", columnName)
 	}
+	*field = valuePtr
 	return nil
 }
 
@@ -1879,22 +1923,12 @@ func (c *LoadTestController) monitorInsertProgress(
 	}
 }
 
-// updateLoadTestError updates the load test with error information
+// updateLoadTestError marks the load test as failed, or cancelled, and notifies clients
 func (c *LoadTestController) updateLoadTestError(
 	ctx context.Context,
 	loadTest *LoadTest,
 	message string,
 	err error,
 ) {
-	log := c.log.Function("updateLoadTestError")
-
-	errorMsg := message + ": " + err.Error()
-	loadTest.Status = "failed"
-	loadTest.ErrorMessage = &errorMsg
-
-	if updateErr := c.loadTestRepo.Update(ctx, loadTest); updateErr != nil {
-		_ = log.Err("failed to update load test error", updateErr, "loadTestId", loadTest.ID)
-	}
-
-	_ = log.Err(message, err, "loadTestId", loadTest.ID)
+	recordRunFailure(ctx, c.loadTestRepo, c.wsManager, c.log.Function("updateLoadTestError"), loadTest, message, err)
 }
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"server/internal/logger"
+	. "server/internal/models"
+	"testing"
+)
+
+func TestParseAndValidateRow_DateCache(t *testing.T) {
+	controller := &LoadTestController{dateUtils: newDateColumnUtils(), log: logger.New("loadTestController")}
+	dates := newDateCache(controller.dateUtils)
+
+	headers := []string{"first_name", "birth_date", "start_date"}
+	headerIndex := map[string]int{"first_name": 0, "birth_date": 1, "start_date": 2}
+	records := [][]string{
+		{"Ann", "07/01/1985", "2024-01-01"},
+		{"Bob", "07/01/1985", ""},
+		{"Cy", "soon", "2024-01-01"},
+		{"Di", "soon", "1985"},
+	}
+
+	var rows []*TestData
+	var rejected int
+	for _, record := range records {
+		data := &TestData{}
+		if issues := controller.parseAndValidateRow(record, headers, headerIndex, data, dates); len(issues) > 0 {
+			rejected++
+		}
+		rows = append(rows, data)
+	}
+
+	// Empty values are not validated, so 7 dates were read, 4 of them distinct
+	stats := dates.Stats()
+	if stats.Hits != 3 || stats.Misses != 4 {
+		t.Errorf("Expected 3 hits and 4 misses, got %d and %d", stats.Hits, stats.Misses)
+	}
+	if rejected != 2 {
+		t.Errorf("Expected 2 rows with an invalid date, got %d", rejected)
+	}
+	if date := stringValue(rows[1].BirthDate); date != "1985-07-01" {
+		t.Errorf("Expected a cached birth_date of 1985-07-01, got %q", date)
+	}
+	if precision := stringValue(rows[3].StartDatePrecision); precision != "year" {
+		t.Errorf("Expected a start_date precision of year, got %q", precision)
+	}
+}
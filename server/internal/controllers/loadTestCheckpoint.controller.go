@@ -0,0 +1,535 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"server/internal/utils"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCheckpointNotFound = errors.New("no checkpoint found for load test")
+	ErrResumeNeedsUpload  = errors.New("the original file must be uploaded again to resume this import")
+)
+
+// batchMark records where a batch ended in the source so the checkpoint can advance past it
+type batchMark struct {
+	EndOffset int64
+	EndLine   int
+	Rows      int
+}
+
+// checkpointSaveInterval is how often the checkpoint is saved to the cache as batches commit.
+// Committed batches are recorded in the database with their rows, so a checkpoint saved less
+// often only has to carry the headers and batch size a resume needs.
+const checkpointSaveInterval = time.Second
+
+// checkpointTracker advances and persists a LoadTestCheckpoint as batches commit.
+// It is safe for concurrent use, and a nil tracker disables checkpointing.
+type checkpointTracker struct {
+	repo       repositories.LoadTestRepository
+	log        logger.Logger
+	mu         sync.Mutex
+	checkpoint LoadTestCheckpoint
+	resuming   bool
+	baseOffset int64
+	baseLine   int
+	skip       map[int]bool
+	committed  map[int]batchMark
+	savedAt    time.Time  // When the last save was started, zero again after one fails
+	saveMu     sync.Mutex // Keeps saves in order, so an older checkpoint never replaces a newer one
+	saved      time.Time  // UpdatedAt of the last checkpoint saved
+}
+
+// newCheckpointTracker starts checkpointing a fresh run. filePath is the CSV on disk for
+// generated runs and empty for uploads.
+func newCheckpointTracker(
+	repo repositories.LoadTestRepository,
+	loadTest *LoadTest,
+	filePath string,
+) *checkpointTracker {
+	return &checkpointTracker{
+		repo: repo,
+		log:  logger.New("checkpointTracker"),
+		checkpoint: LoadTestCheckpoint{
+			LoadTestID:  loadTest.ID,
+			Method:      loadTest.Method,
+			FilePath:    filePath,
+			BatchNumber: -1,
+		},
+		committed: make(map[int]batchMark),
+	}
+}
+
+// resumeCheckpointTracker continues from a saved checkpoint. The source must already be
+// positioned at checkpoint.ByteOffset.
+func resumeCheckpointTracker(
+	repo repositories.LoadTestRepository,
+	checkpoint *LoadTestCheckpoint,
+) *checkpointTracker {
+	tracker := &checkpointTracker{
+		repo:       repo,
+		log:        logger.New("checkpointTracker"),
+		checkpoint: *checkpoint,
+		resuming:   true,
+		baseOffset: checkpoint.ByteOffset,
+		baseLine:   checkpoint.LineNumber,
+		skip:       make(map[int]bool, len(checkpoint.CommittedAhead)),
+		committed:  make(map[int]batchMark),
+	}
+	for _, batchNum := range checkpoint.CommittedAhead {
+		tracker.skip[batchNum] = true
+	}
+	tracker.checkpoint.CommittedAhead = nil
+	return tracker
+}
+
+// resumed reports whether the run continues from a saved checkpoint
+func (t *checkpointTracker) resumed() bool {
+	return t != nil && t.resuming
+}
+
+// readHeaders returns the source headers, taking them from the checkpoint when resuming
+// because the resumed stream starts after the header row
+func (t *checkpointTracker) readHeaders(reader utils.RecordReader) ([]string, error) {
+	if t != nil && t.resuming {
+		return slices.Clone(t.checkpoint.Headers), nil
+	}
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	if t != nil {
+		t.mu.Lock()
+		t.checkpoint.Headers = slices.Clone(headers)
+		t.mu.Unlock()
+	}
+
+	return headers, nil
+}
+
+// batchSize returns the batch size recorded in the checkpoint, adopting defaultSize for a fresh
+// run. Resumed runs must reuse the original size so batch numbers line up.
+func (t *checkpointTracker) batchSize(defaultSize int) int {
+	if t == nil {
+		return defaultSize
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.checkpoint.BatchSize <= 0 {
+		t.checkpoint.BatchSize = defaultSize
+	}
+	return t.checkpoint.BatchSize
+}
+
+// firstBatch returns the batch number the parser should start counting from
+func (t *checkpointTracker) firstBatch() int {
+	if t == nil {
+		return 0
+	}
+	return t.checkpoint.BatchNumber + 1
+}
+
+// rowsCommitted returns the rows committed by earlier runs and this one
+func (t *checkpointTracker) rowsCommitted() int {
+	if t == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.checkpoint.RowsCommitted
+}
+
+// mark returns the absolute end position of the record the reader has just returned
+func (t *checkpointTracker) mark(reader utils.RecordReader, record []string, rows int) batchMark {
+	if t == nil || len(record) == 0 {
+		return batchMark{Rows: rows}
+	}
+
+	last := len(record) - 1
+	line, _ := reader.FieldPos(last)
+	if csvReaderOf(reader) != nil {
+		// A quoted CSV field can span lines
+		line += strings.Count(record[last], "\n")
+	}
+	return batchMark{
+		EndOffset: t.baseOffset + reader.InputOffset(),
+		EndLine:   t.baseLine + line,
+		Rows:      rows,
+	}
+}
+
+// endOfInput returns the position of the end of the source once the reader has hit io.EOF.
+// line is the starting line of the last record read.
+func (t *checkpointTracker) endOfInput(reader utils.RecordReader, line int, rows int) batchMark {
+	if t == nil {
+		return batchMark{Rows: rows}
+	}
+
+	return batchMark{
+		EndOffset: t.baseOffset + reader.InputOffset(),
+		EndLine:   t.baseLine + line,
+		Rows:      rows,
+	}
+}
+
+// skipBatch reports whether a batch was already committed by an earlier run. Skipped batches
+// still advance the checkpoint, but their rows are not counted again.
+func (t *checkpointTracker) skipBatch(ctx context.Context, batchNum int, mark batchMark) bool {
+	if t == nil || !t.skip[batchNum] {
+		return false
+	}
+
+	mark.Rows = 0
+	t.commit(ctx, batchNum, mark)
+	return true
+}
+
+// recordBatch writes a batch to the database in tx, the transaction committing its rows, so a
+// resume skips it even if the checkpoint was not saved after it committed
+func (t *checkpointTracker) recordBatch(ctx context.Context, tx *sql.Tx, batchNum int, mark batchMark) error {
+	if t == nil {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, repositories.INSERT_LOAD_TEST_BATCH,
+		t.checkpoint.LoadTestID, batchNum, mark.EndOffset, mark.EndLine, mark.Rows); err != nil {
+		return fmt.Errorf("failed to record committed batch %d: %w", batchNum, err)
+	}
+	return nil
+}
+
+// commit records a committed batch and persists the checkpoint, at most once per
+// checkpointSaveInterval
+func (t *checkpointTracker) commit(ctx context.Context, batchNum int, mark batchMark) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+
+	t.checkpoint.RowsCommitted += mark.Rows
+	t.committed[batchNum] = mark
+
+	// Advance the contiguous watermark as far as the committed batches allow
+	for {
+		next, ok := t.committed[t.checkpoint.BatchNumber+1]
+		if !ok {
+			break
+		}
+		delete(t.committed, t.checkpoint.BatchNumber+1)
+		t.checkpoint.BatchNumber++
+		t.checkpoint.ByteOffset = next.EndOffset
+		t.checkpoint.LineNumber = next.EndLine
+	}
+
+	t.checkpoint.CommittedAhead = t.checkpoint.CommittedAhead[:0]
+	for pending := range t.committed {
+		t.checkpoint.CommittedAhead = append(t.checkpoint.CommittedAhead, pending)
+	}
+	// Batches from an earlier run that have not been reached yet are still committed
+	for pending := range t.skip {
+		if pending > t.checkpoint.BatchNumber && !slices.Contains(t.checkpoint.CommittedAhead, pending) {
+			t.checkpoint.CommittedAhead = append(t.checkpoint.CommittedAhead, pending)
+		}
+	}
+	slices.Sort(t.checkpoint.CommittedAhead)
+	t.checkpoint.UpdatedAt = time.Now()
+
+	if t.checkpoint.UpdatedAt.Sub(t.savedAt) < checkpointSaveInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.savedAt = t.checkpoint.UpdatedAt
+	checkpoint := t.checkpoint
+	checkpoint.Headers = slices.Clone(t.checkpoint.Headers)
+	checkpoint.CommittedAhead = slices.Clone(t.checkpoint.CommittedAhead)
+	t.mu.Unlock()
+
+	// The cache is written outside the lock so workers committing meanwhile don't wait on it
+	t.saveMu.Lock()
+	defer t.saveMu.Unlock()
+	if !checkpoint.UpdatedAt.After(t.saved) {
+		return
+	}
+	if err := t.repo.SaveCheckpoint(ctx, &checkpoint); err != nil {
+		t.log.Function("commit").
+			Warn("failed to persist checkpoint", "loadTestId", checkpoint.LoadTestID, "batchNum", batchNum, "error", err)
+		// Retry on the next commit rather than waiting out the interval
+		t.mu.Lock()
+		t.savedAt = time.Time{}
+		t.mu.Unlock()
+		return
+	}
+	t.saved = checkpoint.UpdatedAt
+}
+
+// complete removes the checkpoint once every row has been committed
+func (t *checkpointTracker) complete(ctx context.Context) {
+	if t == nil {
+		return
+	}
+
+	if err := t.repo.DeleteCheckpoint(ctx, t.checkpoint.LoadTestID.String()); err != nil {
+		t.log.Function("complete").
+			Warn("failed to delete checkpoint", "loadTestId", t.checkpoint.LoadTestID, "error", err)
+	}
+}
+
+// GetCheckpoint returns the saved insertion checkpoint for a load test, or nil if none exists
+func (c *LoadTestController) GetCheckpoint(
+	ctx context.Context,
+	loadTestID string,
+) (*LoadTestCheckpoint, error) {
+	return c.loadTestRepo.GetCheckpoint(ctx, loadTestID)
+}
+
+// ResumeLoadTest continues an interrupted ludicrous or plaid insertion from its checkpoint.
+// Generated runs are queued to resume from the CSV left on disk; uploads must send the original
+// file again as input and are processed synchronously once they reach the front of the queue.
+func (c *LoadTestController) ResumeLoadTest(
+	ctx context.Context,
+	loadTestID string,
+	input io.Reader,
+	size int64,
+) (*LoadTest, error) {
+	log := c.log.Function("ResumeLoadTest")
+
+	loadTest, err := c.loadTestRepo.GetByID(ctx, loadTestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if loadTest.Status == "completed" {
+		return nil, fmt.Errorf("load test %s has already completed", loadTestID)
+	}
+
+	checkpoint, err := c.loadTestRepo.GetCheckpoint(ctx, loadTestID)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, ErrCheckpointNotFound
+	}
+
+	if checkpoint.FilePath != "" {
+		return c.queueResume(ctx, loadTest, checkpoint)
+	}
+	if input == nil {
+		return nil, ErrResumeNeedsUpload
+	}
+
+	// Registering the run up front guarantees a checkpoint is never resumed twice at once
+	runCtx, finish, err := c.runs.Start(loadTest.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	// The checkpoint offset is into the decoded stream, so the file is decoded as it was first time
+	encoding := ""
+	if loadTest.Encoding != nil {
+		encoding = *loadTest.Encoding
+	}
+	source, err := uploadSource(input, size, encoding)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+	if _, err := io.CopyN(io.Discard, source, checkpoint.ByteOffset); err != nil {
+		return nil, fmt.Errorf("failed to skip to checkpoint: %w", err)
+	}
+
+	opts, err := c.resumeImportOptions(ctx, loadTest, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	loadTest.Status = "queued"
+	loadTest.ErrorMessage = nil
+	if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
+		return nil, fmt.Errorf("failed to update load test: %w", err)
+	}
+
+	release, err := c.acquireUploadSlot(runCtx, loadTest)
+	if err != nil {
+		return loadTest, err
+	}
+	defer release()
+
+	log.Info("resuming load test from checkpoint",
+		"loadTestId", loadTest.ID,
+		"method", loadTest.Method,
+		"byteOffset", checkpoint.ByteOffset,
+		"batchNumber", checkpoint.BatchNumber,
+		"rowsCommitted", checkpoint.RowsCommitted)
+
+	if err := c.processImport(runCtx, loadTest, source, opts); err != nil {
+		return loadTest, err
+	}
+
+	return loadTest, nil
+}
+
+// queueResume schedules a generated run to continue from the CSV it left on disk
+func (c *LoadTestController) queueResume(
+	ctx context.Context,
+	loadTest *LoadTest,
+	checkpoint *LoadTestCheckpoint,
+) (*LoadTest, error) {
+	if c.runs.IsActive(loadTest.ID) || c.queue.Position(ctx, loadTest.ID) > 0 {
+		return nil, ErrLoadTestActive
+	}
+	if _, err := os.Stat(checkpoint.FilePath); err != nil {
+		return nil, fmt.Errorf("failed to open checkpointed CSV file: %w", err)
+	}
+
+	previousStatus := loadTest.Status
+	loadTest.Status = "queued"
+	loadTest.ErrorMessage = nil
+	if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
+		return nil, fmt.Errorf("failed to update load test: %w", err)
+	}
+
+	if err := c.queue.Enqueue(ctx, loadTest, LOAD_TEST_JOB_RESUME, 0); err != nil {
+		loadTest.Status = previousStatus
+		if updateErr := c.loadTestRepo.Update(ctx, loadTest); updateErr != nil {
+			_ = c.log.Function("queueResume").Err("failed to restore load test status", updateErr, "loadTestId", loadTest.ID)
+		}
+		return nil, err
+	}
+
+	return c.queue.withPosition(ctx, loadTest), nil
+}
+
+// resumeFromDisk runs a queued resume job, continuing a generated run from its checkpointed CSV
+func (c *LoadTestController) resumeFromDisk(ctx context.Context, loadTest *LoadTest) {
+	log := c.log.Function("resumeFromDisk")
+
+	checkpoint, err := c.loadTestRepo.GetCheckpoint(ctx, loadTest.ID.String())
+	if err == nil && checkpoint == nil {
+		err = ErrCheckpointNotFound
+	}
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Resume failed", err)
+		return
+	}
+
+	file, err := os.Open(checkpoint.FilePath)
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Resume failed", fmt.Errorf("failed to open checkpointed CSV file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Resume failed", fmt.Errorf("failed to stat checkpointed CSV file: %w", err))
+		return
+	}
+	if _, err := file.Seek(checkpoint.ByteOffset, io.SeekStart); err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Resume failed", fmt.Errorf("failed to seek to checkpoint: %w", err))
+		return
+	}
+
+	opts, err := c.resumeImportOptions(ctx, loadTest, checkpoint)
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Resume failed", err)
+		return
+	}
+
+	log.Info("resuming load test from checkpoint",
+		"loadTestId", loadTest.ID,
+		"method", loadTest.Method,
+		"byteOffset", checkpoint.ByteOffset,
+		"batchNumber", checkpoint.BatchNumber,
+		"rowsCommitted", checkpoint.RowsCommitted)
+
+	source := utils.NewProgressReader(file, info.Size()-checkpoint.ByteOffset)
+	if err := c.processImport(ctx, loadTest, source, opts); err != nil {
+		return
+	}
+
+	if err := os.Remove(checkpoint.FilePath); err != nil {
+		log.Warn("failed to remove checkpointed CSV file", "path", checkpoint.FilePath, "error", err)
+	}
+}
+
+// resumeImportOptions rebuilds the import settings for a resumed run and discards any rejections
+// recorded past the checkpoint, since those rows are about to be validated again
+func (c *LoadTestController) resumeImportOptions(
+	ctx context.Context,
+	loadTest *LoadTest,
+	checkpoint *LoadTestCheckpoint,
+) (*ImportOptions, error) {
+	if loadTest.Method != "ludicrous" && loadTest.Method != "plaid" {
+		return nil, fmt.Errorf("resume is not supported for the %s method", loadTest.Method)
+	}
+
+	opts := &ImportOptions{
+		Checkpoint: resumeCheckpointTracker(c.loadTestRepo, checkpoint),
+	}
+
+	if loadTest.MappingProfileID != nil {
+		profile, err := c.mappingRepo.GetByID(ctx, loadTest.MappingProfileID.String())
+		if err != nil {
+			return nil, fmt.Errorf("column mapping profile not found: %w", err)
+		}
+		opts.Mapping = profile
+	}
+	opts.Format = loadTest.Format
+	opts.Dialect = loadTest.Dialect
+	opts.DateOrder = stringValue(loadTest.DateOrder)
+	opts.DateOrders = loadTest.DateOrders
+	opts.RecordOffsets = loadTest.RecordOffsets
+	opts.Locale = utils.DateLocale(stringValue(loadTest.Locale))
+	timezone, columnTimezones, err := parseImportTimezones(
+		stringValue(loadTest.Timezone),
+		stringValue(loadTest.ColumnTimezones),
+	)
+	if err != nil {
+		return nil, err
+	}
+	opts.Timezone, opts.ColumnTimezones = timezone, columnTimezones
+
+	// Batches upserted before the interruption are not recounted, so the counts only cover the resumed rows
+	if loadTest.ImportMode == IMPORT_MODE_UPSERT && loadTest.UpsertKey != nil {
+		upsert, err := parseUpsertKey(*loadTest.UpsertKey)
+		if err != nil {
+			return nil, err
+		}
+		opts.Upsert = upsert
+	}
+
+	// Only uploads record rejections; generated runs insert every row
+	if loadTest.Source == "upload" {
+		loadTestID := loadTest.ID.String()
+		if err := c.importErrorRepo.DeleteFromLine(ctx, loadTestID, checkpoint.LineNumber+1); err != nil {
+			return nil, err
+		}
+		rejected, err := c.importErrorRepo.CountRowsByLoadTestID(ctx, loadTestID)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Rejections = newImportErrorRecorder(c.importErrorRepo, loadTest.ID)
+		opts.Rejections.lineOffset = checkpoint.LineNumber
+		opts.Rejections.rejected = int(rejected)
+	}
+
+	return opts, nil
+}
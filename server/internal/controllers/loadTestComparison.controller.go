@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"server/config"
 	"server/internal/database"
 	"server/internal/logger"
 	"server/internal/repositories"
@@ -21,6 +22,7 @@ func NewLoadTestComparisonController(
 	loadTestRepo repositories.LoadTestRepository,
 	testDataRepo repositories.TestDataRepository,
 	wsManager WSManager,
+	config config.Config,
 ) *LoadTestComparisonController {
 	return &LoadTestComparisonController{
 		optimizedController: NewOptimizedLoadTestController(
@@ -28,6 +30,7 @@ func NewLoadTestComparisonController(
 			loadTestRepo,
 			testDataRepo,
 			wsManager,
+			config,
 		),
 		log: logger.New("loadTestComparisonController"),
 	}
@@ -43,12 +46,15 @@ type ComparisonResult struct {
 
 // InsertionResult holds the results of a single insertion test
 type InsertionResult struct {
-	Method       string `json:"method"`
-	TotalTimeMs  int    `json:"total_time_ms"`
-	RecordsCount int    `json:"records_count"`
-	RowsPerSec   int    `json:"rows_per_second"`
-	Success      bool   `json:"success"`
-	ErrorMsg     string `json:"error_message,omitempty"`
+	Method          string `json:"method"`
+	TotalTimeMs     int    `json:"total_time_ms"`
+	ParseTimeMs     int    `json:"parse_time_ms"`
+	RecordsCount    int    `json:"records_count"`
+	RowsPerSec      int    `json:"rows_per_second"`
+	DateCacheHits   int    `json:"date_cache_hits"`
+	DateCacheMisses int    `json:"date_cache_misses"`
+	Success         bool   `json:"success"`
+	ErrorMsg        string `json:"error_message,omitempty"`
 }
 
 // CompareInsertionMethods runs both Optimized and Ludicrous insertion methods and compares their performance
@@ -150,6 +156,9 @@ func (c *LoadTestComparisonController) runInsertionTest(
 	totalTimeMs := timingResult.ParseTime + timingResult.InsertTime
 
 	result.TotalTimeMs = totalTimeMs
+	result.ParseTimeMs = timingResult.ParseTime
+	result.DateCacheHits = timingResult.DateCache.Hits
+	result.DateCacheMisses = timingResult.DateCache.Misses
 	if err != nil {
 		result.Success = false
 		result.ErrorMsg = err.Error()
@@ -162,6 +171,9 @@ func (c *LoadTestComparisonController) runInsertionTest(
 		c.log.Info("Insertion test completed",
 			"method", method,
 			"totalTimeMs", totalTimeMs,
+			"parseTimeMs", result.ParseTimeMs,
+			"dateCacheHits", result.DateCacheHits,
+			"dateCacheMisses", result.DateCacheMisses,
 			"rowsPerSec", result.RowsPerSec)
 	}
 
@@ -0,0 +1,572 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"server/config"
+	"server/internal/events"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	LOAD_TEST_QUEUE_POLL_INTERVAL    = 2 * time.Second
+	DEFAULT_LOAD_TEST_MAX_CONCURRENT = 2
+
+	LOAD_TEST_JOB_READ_BLOCK       = 5 * time.Second
+	LOAD_TEST_JOB_HEARTBEAT        = 10 * time.Second
+	LOAD_TEST_JOB_RECLAIM_INTERVAL = 15 * time.Second
+	LOAD_TEST_JOB_RECLAIM_IDLE     = 45 * time.Second // Pending this long without a heartbeat means the worker died
+	LOAD_TEST_JOB_STALE_AFTER      = 2 * time.Minute  // Running this long without a heartbeat means the stream entry was lost
+)
+
+// ErrLoadTestInterrupted is recorded for jobs whose worker stopped while the load test was running
+var ErrLoadTestInterrupted = errors.New("the server stopped while the load test was running")
+
+// JobRunner executes a claimed job. The context is the run's own and is cancelled through the
+// RunRegistry.
+type JobRunner func(ctx context.Context, job *LoadTestJob, loadTest *LoadTest)
+
+// LoadTestQueue runs load tests from a durable queue instead of a goroutine per request. Jobs are
+// stored in Postgres and dispatched in priority order, oldest first, while fewer than the
+// configured limit are running across all instances. Dispatched jobs go onto a Valkey stream that
+// every instance reads as part of one consumer group, so any worker can pick them up. Workers
+// heartbeat their jobs, and jobs left pending by a dead worker are reclaimed by another.
+type LoadTestQueue struct {
+	jobRepo      repositories.LoadTestJobRepository
+	loadTestRepo repositories.LoadTestRepository
+	runs         *RunRegistry
+	wsManager    WSManager
+	eventBus     *events.EventBus
+	log          logger.Logger
+	limit        int
+	workerID     string
+	wake         chan struct{}
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+func NewLoadTestQueue(
+	jobRepo repositories.LoadTestJobRepository,
+	loadTestRepo repositories.LoadTestRepository,
+	runs *RunRegistry,
+	wsManager WSManager,
+	eventBus *events.EventBus,
+	config config.Config,
+) *LoadTestQueue {
+	limit := config.LoadTestMaxConcurrent
+	if limit <= 0 {
+		limit = DEFAULT_LOAD_TEST_MAX_CONCURRENT
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &LoadTestQueue{
+		jobRepo:      jobRepo,
+		loadTestRepo: loadTestRepo,
+		runs:         runs,
+		wsManager:    wsManager,
+		eventBus:     eventBus,
+		log:          logger.New("loadTestQueue"),
+		limit:        limit,
+		workerID:     fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start joins the worker consumer group and begins dispatching, consuming and reclaiming jobs
+func (q *LoadTestQueue) Start(runner JobRunner) error {
+	if err := q.jobRepo.EnsureStream(q.ctx); err != nil {
+		return err
+	}
+
+	if err := q.eventBus.Subscribe(events.LOAD_TEST_CANCEL_CHANNEL, q.handleCancel); err != nil {
+		return err
+	}
+
+	go q.dispatch()
+	go q.consume(runner)
+	go q.reclaim(runner)
+	return nil
+}
+
+func (q *LoadTestQueue) Close() error {
+	q.cancel()
+	return nil
+}
+
+// Enqueue adds a job for a load test that has already been stored with the queued status
+func (q *LoadTestQueue) Enqueue(ctx context.Context, loadTest *LoadTest, kind string, priority int) error {
+	job := &LoadTestJob{
+		LoadTestID: loadTest.ID,
+		Kind:       kind,
+		Priority:   priority,
+		Status:     LOAD_TEST_JOB_QUEUED,
+	}
+	if err := q.jobRepo.Create(ctx, job); err != nil {
+		return fmt.Errorf("failed to queue load test: %w", err)
+	}
+
+	q.log.Function("Enqueue").Info("load test queued",
+		"loadTestId", loadTest.ID,
+		"kind", kind,
+		"priority", priority)
+
+	q.broadcastPositions(ctx)
+	q.notify()
+	return nil
+}
+
+// Acquire waits in the queue for a slot to run a load test on this instance, for uploads that
+// are streamed from the request and so cannot be handed to a worker. The load test, already
+// stored with the queued status, is marked running once the slot is claimed. The returned
+// function releases the slot; if ctx ends first, or the load test is removed from the queue,
+// the error is returned instead.
+func (q *LoadTestQueue) Acquire(ctx context.Context, loadTest *LoadTest) (func(), error) {
+	log := q.log.Function("Acquire")
+
+	now := time.Now()
+	job := &LoadTestJob{
+		LoadTestID:  loadTest.ID,
+		Kind:        LOAD_TEST_JOB_UPLOAD,
+		Status:      LOAD_TEST_JOB_QUEUED,
+		ClaimedBy:   &q.workerID,
+		HeartbeatAt: &now,
+	}
+	if err := q.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to queue load test: %w", err)
+	}
+
+	stopHeartbeat := q.heartbeatUpload(job.ID)
+	release := func() {
+		stopHeartbeat()
+		if err := q.jobRepo.Delete(context.Background(), job.ID); err != nil {
+			log.Er("failed to remove finished upload job", err, "jobId", job.ID)
+		}
+		q.notify()
+	}
+
+	q.broadcastPositions(ctx)
+
+	ticker := time.NewTicker(LOAD_TEST_QUEUE_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		claimed, err := q.jobRepo.ClaimUpload(ctx, job, q.limit)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			stopHeartbeat()
+			return nil, ErrLoadTestNotActive
+		}
+		if claimed {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			release()
+			q.broadcastPositions(context.Background())
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	q.broadcastPositions(ctx)
+
+	loadTest.Status = "running"
+	loadTest.QueuePosition = nil
+	if err := q.loadTestRepo.Update(ctx, loadTest); err != nil {
+		_ = log.Err("failed to mark load test as running", err, "loadTestId", loadTest.ID)
+	}
+
+	log.Info("upload job started",
+		"jobId", job.ID,
+		"loadTestId", loadTest.ID,
+		"method", loadTest.Method,
+		"worker", q.workerID)
+
+	return release, nil
+}
+
+// Remove drops a load test that is still waiting, reporting whether it was queued
+func (q *LoadTestQueue) Remove(ctx context.Context, loadTestID uuid.UUID) (bool, error) {
+	removed, err := q.jobRepo.DeleteQueued(ctx, loadTestID)
+	if err != nil || !removed {
+		return false, err
+	}
+
+	q.broadcastPositions(ctx)
+	return true, nil
+}
+
+// CancelRemote asks the instance running a load test to cancel it, reporting whether a job for the
+// load test exists
+func (q *LoadTestQueue) CancelRemote(ctx context.Context, loadTestID uuid.UUID) (bool, error) {
+	active, err := q.jobRepo.IsActive(ctx, loadTestID)
+	if err != nil || !active {
+		return false, err
+	}
+
+	if err := q.eventBus.PublishLoadTestCancel(loadTestID.String()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Position returns the 1-based queue position of a load test, or 0 if it is not waiting
+func (q *LoadTestQueue) Position(ctx context.Context, loadTestID uuid.UUID) int {
+	position, err := q.jobRepo.Position(ctx, loadTestID)
+	if err != nil {
+		q.log.Function("Position").Warn("failed to get queue position", "loadTestId", loadTestID, "error", err)
+		return 0
+	}
+	return position
+}
+
+// withPosition fills in the queue position of a load test that is waiting to run
+func (q *LoadTestQueue) withPosition(ctx context.Context, loadTest *LoadTest) *LoadTest {
+	if loadTest != nil && loadTest.Status == "queued" {
+		if position := q.Position(ctx, loadTest.ID); position > 0 {
+			loadTest.QueuePosition = &position
+		}
+	}
+	return loadTest
+}
+
+func (q *LoadTestQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch moves queued jobs onto the job stream whenever one is queued or finishes, polling as a
+// fallback for jobs queued or finished on other instances
+func (q *LoadTestQueue) dispatch() {
+	ticker := time.NewTicker(LOAD_TEST_QUEUE_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		q.dispatchAvailable()
+
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *LoadTestQueue) dispatchAvailable() {
+	dispatched := false
+	for q.ctx.Err() == nil {
+		job, err := q.jobRepo.Dispatch(q.ctx, q.limit)
+		if err != nil || job == nil {
+			break
+		}
+		dispatched = true
+	}
+
+	if dispatched {
+		q.broadcastPositions(q.ctx)
+	}
+}
+
+// consume reads jobs from the stream as this instance's consumer and runs each one
+func (q *LoadTestQueue) consume(runner JobRunner) {
+	for q.ctx.Err() == nil {
+		deliveries, err := q.jobRepo.ReadDeliveries(q.ctx, q.workerID, LOAD_TEST_JOB_READ_BLOCK)
+		if err != nil {
+			select {
+			case <-q.ctx.Done():
+				return
+			case <-time.After(LOAD_TEST_QUEUE_POLL_INTERVAL):
+			}
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			go q.execute(delivery, runner)
+		}
+	}
+}
+
+// reclaim takes over jobs from workers that stopped sending heartbeats, and requeues running jobs
+// that no worker holds at all
+func (q *LoadTestQueue) reclaim(runner JobRunner) {
+	log := q.log.Function("reclaim")
+
+	ticker := time.NewTicker(LOAD_TEST_JOB_RECLAIM_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deliveries, err := q.jobRepo.ReclaimDeliveries(q.ctx, q.workerID, LOAD_TEST_JOB_RECLAIM_IDLE)
+		if err == nil {
+			for _, delivery := range deliveries {
+				log.Warn("reclaimed load test job from an unresponsive worker",
+					"jobId", delivery.JobID,
+					"entryId", delivery.EntryID)
+				go q.execute(delivery, runner)
+			}
+		}
+
+		requeued, err := q.jobRepo.RequeueStale(q.ctx, time.Now().Add(-LOAD_TEST_JOB_STALE_AFTER))
+		if err == nil && requeued > 0 {
+			log.Warn("requeued load test jobs without a worker", "count", requeued)
+			q.notify()
+		}
+
+		// Uploads stop with the instance streaming them, so theirs fail rather than being requeued
+		abandoned, err := q.jobRepo.DeleteStaleUploads(q.ctx, time.Now().Add(-LOAD_TEST_JOB_STALE_AFTER))
+		if err == nil && len(abandoned) > 0 {
+			log.Warn("removed upload jobs whose instance stopped", "count", len(abandoned))
+			for _, job := range abandoned {
+				q.failAbandoned(job)
+			}
+			q.broadcastPositions(q.ctx)
+			q.notify()
+		}
+	}
+}
+
+// execute runs a job delivered from the stream under its own cancellable context, heartbeating
+// it until the run finishes. The job is removed once its load test reaches a terminal status; on
+// any other failure it is left running for RequeueStale to return to the queue.
+func (q *LoadTestQueue) execute(delivery *LoadTestJobDelivery, runner JobRunner) {
+	log := q.log.Function("execute")
+
+	defer func() {
+		if err := q.jobRepo.Acknowledge(context.Background(), delivery); err != nil {
+			log.Er("failed to acknowledge load test job", err, "entryId", delivery.EntryID)
+		}
+	}()
+
+	if delivery.JobID == uuid.Nil {
+		return
+	}
+
+	// A requeued or finished job is no longer owned by this entry
+	job, err := q.jobRepo.GetByID(q.ctx, delivery.JobID)
+	if err != nil || job.Status != LOAD_TEST_JOB_RUNNING {
+		return
+	}
+
+	if err := q.jobRepo.MarkClaimed(q.ctx, job, q.workerID); err != nil {
+		return
+	}
+
+	stopHeartbeat := q.heartbeat(delivery)
+	defer stopHeartbeat()
+
+	loadTest, err := q.loadTestRepo.GetByID(q.ctx, job.LoadTestID.String())
+	if err != nil {
+		log.Er("failed to get load test for job", err, "jobId", job.ID, "loadTestId", job.LoadTestID)
+		// A deleted load test will never run, so its job goes too
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			q.removeJob(job)
+		}
+		return
+	}
+
+	switch loadTest.Status {
+	case "queued":
+	case "running":
+		// The previous worker died mid-run, so continue from its checkpoint if this instance can
+		// reach the generated CSV
+		if !q.resumable(loadTest) {
+			recordRunFailure(q.ctx, q.loadTestRepo, q.wsManager, log, loadTest,
+				"Load test interrupted", ErrLoadTestInterrupted)
+			q.removeJob(job)
+			return
+		}
+		job.Kind = LOAD_TEST_JOB_RESUME
+	default:
+		// Already finished, failed or cancelled
+		q.removeJob(job)
+		return
+	}
+
+	// The run already active on this instance removes the job when it finishes
+	runCtx, finish, err := q.runs.Start(loadTest.ID)
+	if err != nil {
+		log.Er("failed to start load test run", err, "loadTestId", loadTest.ID)
+		return
+	}
+	defer finish()
+
+	// Runs after the panic recovery below, so a panicking run is failed before its job goes
+	defer q.removeJob(job)
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("load test job panicked", "panic", r, "loadTestId", loadTest.ID)
+			recordRunFailure(runCtx, q.loadTestRepo, q.wsManager, log, loadTest,
+				"Internal processing error", fmt.Errorf("goroutine panic: %v", r))
+		}
+	}()
+
+	loadTest.Status = "running"
+	if err := q.loadTestRepo.Update(runCtx, loadTest); err != nil {
+		_ = log.Err("failed to mark load test as running", err, "loadTestId", loadTest.ID)
+	}
+
+	log.Info("load test job started",
+		"jobId", job.ID,
+		"loadTestId", loadTest.ID,
+		"kind", job.Kind,
+		"method", loadTest.Method,
+		"worker", q.workerID)
+
+	runner(runCtx, job, loadTest)
+}
+
+// removeJob deletes a job whose load test has reached a terminal status and wakes the dispatcher
+func (q *LoadTestQueue) removeJob(job *LoadTestJob) {
+	if err := q.jobRepo.Delete(context.Background(), job.ID); err != nil {
+		q.log.Function("removeJob").Er("failed to remove finished load test job", err, "jobId", job.ID)
+	}
+	q.notify()
+}
+
+// failAbandoned records the failure of a load test whose upload job was removed as stale
+func (q *LoadTestQueue) failAbandoned(job *LoadTestJob) {
+	log := q.log.Function("failAbandoned")
+
+	loadTest, err := q.loadTestRepo.GetByID(q.ctx, job.LoadTestID.String())
+	if err != nil {
+		log.Er("failed to get load test for abandoned upload job", err, "jobId", job.ID, "loadTestId", job.LoadTestID)
+		return
+	}
+	if loadTest.Status != "queued" && loadTest.Status != "running" {
+		return
+	}
+
+	recordRunFailure(q.ctx, q.loadTestRepo, q.wsManager, log, loadTest,
+		"Load test interrupted", ErrLoadTestInterrupted)
+}
+
+// heartbeat refreshes a delivery until the returned function is called
+func (q *LoadTestQueue) heartbeat(delivery *LoadTestJobDelivery) func() {
+	log := q.log.Function("heartbeat")
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(LOAD_TEST_JOB_HEARTBEAT)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-q.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := q.jobRepo.Heartbeat(q.ctx, delivery, q.workerID); err != nil {
+					log.Warn("failed to heartbeat load test job", "jobId", delivery.JobID, "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// heartbeatUpload keeps an upload job from being treated as abandoned until the returned function
+// is called
+func (q *LoadTestQueue) heartbeatUpload(jobID uuid.UUID) func() {
+	log := q.log.Function("heartbeatUpload")
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(LOAD_TEST_JOB_HEARTBEAT)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-q.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := q.jobRepo.TouchUpload(q.ctx, jobID); err != nil {
+					log.Warn("failed to heartbeat upload job", "jobId", jobID, "error", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// resumable reports whether an interrupted load test has a checkpointed CSV on this instance
+func (q *LoadTestQueue) resumable(loadTest *LoadTest) bool {
+	checkpoint, err := q.loadTestRepo.GetCheckpoint(q.ctx, loadTest.ID.String())
+	if err != nil || checkpoint == nil || checkpoint.FilePath == "" {
+		return false
+	}
+
+	_, err = os.Stat(checkpoint.FilePath)
+	return err == nil
+}
+
+// handleCancel cancels a load test that another instance was asked to cancel, if it runs here
+func (q *LoadTestQueue) handleCancel(event events.Event) error {
+	id, ok := event.Data["loadTestId"].(string)
+	if !ok {
+		return nil
+	}
+
+	loadTestID, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+
+	if q.runs.Cancel(loadTestID) {
+		q.log.Function("handleCancel").Info("load test cancelled by remote request", "loadTestId", loadTestID)
+	}
+	return nil
+}
+
+// broadcastPositions tells every waiting load test where it currently stands in the queue
+func (q *LoadTestQueue) broadcastPositions(ctx context.Context) {
+	jobs, err := q.jobRepo.GetQueued(ctx)
+	if err != nil {
+		return
+	}
+
+	for i, job := range jobs {
+		position := i + 1
+		q.wsManager.SendLoadTestProgress(job.LoadTestID.String(), map[string]any{
+			"phase":           "queued",
+			"overallProgress": 0,
+			"phaseProgress":   0,
+			"currentPhase":    "Queued",
+			"rowsProcessed":   0,
+			"rowsPerSecond":   0,
+			"eta":             "Waiting...",
+			"queuePosition":   position,
+			"message":         fmt.Sprintf("Waiting for a free slot (position %d in queue)", position),
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	. "server/internal/models"
+	"sync"
+)
+
+// retryTracker collects the retries made for each batch across the workers of a run
+type retryTracker struct {
+	mu      sync.Mutex
+	batches BatchRetries
+}
+
+func newRetryTracker() *retryTracker {
+	return &retryTracker{batches: BatchRetries{}}
+}
+
+// record adds the retries made for a batch. Batches that succeeded first time are not recorded.
+func (t *retryTracker) record(batchNum, retries int) {
+	if t == nil || retries == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.batches[batchNum] += retries
+	t.mu.Unlock()
+}
+
+// result returns a copy of the retries recorded so far, or nil if no batch was retried
+func (t *retryTracker) result() BatchRetries {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.batches) == 0 {
+		return nil
+	}
+	batches := make(BatchRetries, len(t.batches))
+	for batchNum, retries := range t.batches {
+		batches[batchNum] = retries
+	}
+	return batches
+}
+
+// recordRetries stores the per-batch retry counts of a run on its load test
+func recordRetries(loadTest *LoadTest, retries BatchRetries) {
+	loadTest.BatchRetries = retries
+	loadTest.Retries = retries.Total()
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLoadTestActive    = errors.New("load test is still running")
+	ErrLoadTestNotActive = errors.New("load test is not running")
+	// ErrLoadTestCancelled is the cancellation cause for runs stopped through the API
+	ErrLoadTestCancelled = errors.New("load test cancelled")
+)
+
+// RunRegistry owns the context of every load test running in this process. Runs are detached
+// from the request that started them and can only be stopped through Cancel.
+type RunRegistry struct {
+	mu   sync.Mutex
+	runs map[uuid.UUID]context.CancelCauseFunc
+}
+
+func NewRunRegistry() *RunRegistry {
+	return &RunRegistry{
+		runs: make(map[uuid.UUID]context.CancelCauseFunc),
+	}
+}
+
+// Start registers a run and returns its context along with a function that must be called once
+// the run has finished. It fails with ErrLoadTestActive if the load test is already running.
+func (r *RunRegistry) Start(loadTestID uuid.UUID) (context.Context, func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.runs[loadTestID]; ok {
+		return nil, nil, ErrLoadTestActive
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	r.runs[loadTestID] = cancel
+
+	finish := func() {
+		r.mu.Lock()
+		delete(r.runs, loadTestID)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+
+	return ctx, finish, nil
+}
+
+// Cancel stops a running load test, reporting whether it was found
+func (r *RunRegistry) Cancel(loadTestID uuid.UUID) bool {
+	r.mu.Lock()
+	cancel, ok := r.runs[loadTestID]
+	r.mu.Unlock()
+
+	if ok {
+		cancel(ErrLoadTestCancelled)
+	}
+	return ok
+}
+
+// IsActive reports whether the load test is running in this process
+func (r *RunRegistry) IsActive(loadTestID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.runs[loadTestID]
+	return ok
+}
+
+// isCancelled reports whether ctx belongs to a run that was cancelled through the API
+func isCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrLoadTestCancelled)
+}
+
+// recordRunFailure stores why a run stopped and notifies clients. Runs cancelled through the API
+// end in the cancelled status; anything else is a failure.
+func recordRunFailure(
+	ctx context.Context,
+	repo repositories.LoadTestRepository,
+	wsManager WSManager,
+	log logger.Logger,
+	loadTest *LoadTest,
+	message string,
+	err error,
+) {
+	testID := loadTest.ID.String()
+	// The run context may already be cancelled, which must not stop the final status update
+	updateCtx := context.WithoutCancel(ctx)
+
+	if isCancelled(ctx) {
+		recordCancelled(updateCtx, repo, wsManager, log, loadTest, message)
+		return
+	}
+
+	errorMsg := message + ": " + err.Error()
+	loadTest.Status = "failed"
+	loadTest.ErrorMessage = &errorMsg
+
+	if updateErr := repo.Update(updateCtx, loadTest); updateErr != nil {
+		_ = log.Err("failed to update load test error", updateErr, "loadTestId", loadTest.ID)
+	}
+
+	wsManager.SendLoadTestError(testID, errorMsg)
+	_ = log.Err(message, err, "loadTestId", loadTest.ID)
+}
+
+// acquireUploadSlot waits for a slot to stream an upload on this instance, recording the outcome
+// on the load test if it never gets one. A load test removed from the queue by a cancellation on
+// another instance has already been recorded as cancelled there.
+func (c *LoadTestController) acquireUploadSlot(ctx context.Context, loadTest *LoadTest) (func(), error) {
+	release, err := c.queue.Acquire(ctx, loadTest)
+	if err != nil {
+		if !errors.Is(err, ErrLoadTestNotActive) {
+			c.updateLoadTestError(ctx, loadTest, "Import failed to start", err)
+		}
+		return nil, err
+	}
+	return release, nil
+}
+
+// recordCancelled moves a load test to the cancelled status and notifies clients
+func recordCancelled(
+	ctx context.Context,
+	repo repositories.LoadTestRepository,
+	wsManager WSManager,
+	log logger.Logger,
+	loadTest *LoadTest,
+	stage string,
+) {
+	testID := loadTest.ID.String()
+	loadTest.Status = "cancelled"
+	loadTest.ErrorMessage = nil
+
+	if updateErr := repo.Update(ctx, loadTest); updateErr != nil {
+		_ = log.Err("failed to update cancelled load test", updateErr, "loadTestId", loadTest.ID)
+	}
+
+	wsManager.SendLoadTestCancelled(testID, map[string]any{
+		"id":     testID,
+		"method": loadTest.Method,
+		"status": loadTest.Status,
+	})
+	log.Info("load test cancelled", "loadTestId", loadTest.ID, "stage", stage)
+}
+
+// CancelLoadTest stops a running load test or removes it from the queue. A running test winds
+// down asynchronously and ends in the cancelled status.
+func (c *LoadTestController) CancelLoadTest(ctx context.Context, loadTestID string) (*LoadTest, error) {
+	loadTest, err := c.loadTestRepo.GetByID(ctx, loadTestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.runs.Cancel(loadTest.ID) {
+		removed, err := c.queue.Remove(ctx, loadTest.ID)
+		if err != nil {
+			return loadTest, err
+		}
+		if !removed {
+			// The run may belong to a worker on another instance
+			requested, err := c.queue.CancelRemote(ctx, loadTest.ID)
+			if err != nil {
+				return loadTest, err
+			}
+			if !requested {
+				return loadTest, ErrLoadTestNotActive
+			}
+
+			c.log.Function("CancelLoadTest").Info("remote load test cancellation requested", "loadTestId", loadTest.ID)
+			return loadTest, nil
+		}
+
+		// A queued test never started, so it is cancelled right away
+		recordCancelled(ctx, c.loadTestRepo, c.wsManager, c.log.Function("CancelLoadTest"), loadTest, "queued")
+		return loadTest, nil
+	}
+
+	c.log.Function("CancelLoadTest").Info("load test cancellation requested", "loadTestId", loadTest.ID)
+	return loadTest, nil
+}
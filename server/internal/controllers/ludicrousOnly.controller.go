@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -34,6 +35,14 @@ func getMemStats() map[string]interface{} {
 	}
 }
 
+// LUDICROUS_FIELDS are the canonical fields the ludicrous method parses and inserts, in column order,
+// followed by the offsets and precision of its dates
+var LUDICROUS_FIELDS = []string{
+	"birth_date", "start_date", "end_date",
+	"first_name", "last_name", "email", "phone", "address_line_1", "city", "state", "zip_code", "employer",
+	DATE_OFFSETS_FIELD, "birth_date_precision", "start_date_precision", "end_date_precision",
+}
+
 type LudicrousOnlyController struct {
 	loadTestRepo repositories.LoadTestRepository
 	testDataRepo repositories.TestDataRepository
@@ -41,6 +50,8 @@ type LudicrousOnlyController struct {
 	log          logger.Logger
 	wsManager    WSManager
 	db           database.DB
+	queue        *LoadTestQueue
+	retryPolicy  database.RetryPolicy
 }
 
 func NewLudicrousOnlyController(
@@ -49,14 +60,17 @@ func NewLudicrousOnlyController(
 	db database.DB,
 	wsManager WSManager,
 	config config.Config,
+	queue *LoadTestQueue,
 ) *LudicrousOnlyController {
 	return &LudicrousOnlyController{
 		loadTestRepo: loadTestRepo,
 		testDataRepo: testDataRepo,
-		dateUtils:    utils.NewDateUtils(),
+		dateUtils:    newDateColumnUtils(),
 		log:          logger.New("ludicrousOnlyController"),
 		wsManager:    wsManager,
 		db:           db,
+		queue:        queue,
+		retryPolicy:  database.RetryPolicyFor(config, "ludicrous"),
 	}
 }
 
@@ -67,6 +81,11 @@ func (c *LudicrousOnlyController) CreateAndRunTest(
 ) (*LoadTest, error) {
 	log := c.log.Function("CreateAndRunTest")
 
+	locale, err := generatedLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
 	// Force method to ludicrous
 	const FixedTotalColumns = 25
 	const FixedDateColumns = 5
@@ -77,7 +96,9 @@ func (c *LudicrousOnlyController) CreateAndRunTest(
 		Columns:     FixedTotalColumns,
 		DateColumns: FixedDateColumns,
 		Method:      "ludicrous", // Force ludicrous method
-		Status:      "running",
+		Status:      "queued",
+		Source:      "generated",
+		Locale:      locale,
 	}
 
 	if err := c.loadTestRepo.Create(ctx, loadTest); err != nil {
@@ -85,20 +106,14 @@ func (c *LudicrousOnlyController) CreateAndRunTest(
 		return nil, fmt.Errorf("failed to create load test: %w", err)
 	}
 
-	// Process the load test asynchronously with recovery
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Error("processLoadTest goroutine panicked", "panic", r, "loadTestId", loadTest.ID)
-				c.updateLoadTestError(ctx, loadTest, "Internal processing error", fmt.Errorf("goroutine panic: %v", r))
-				c.wsManager.SendLoadTestError(loadTest.ID.String(), fmt.Sprintf("Internal processing error: %v", r))
-			}
-		}()
-		c.processLoadTest(ctx, loadTest)
-	}()
+	// The run waits in the queue until a slot is free
+	if err := c.queue.Enqueue(ctx, loadTest, LOAD_TEST_JOB_RUN, req.Priority); err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Failed to queue load test", err)
+		return nil, err
+	}
 
-	log.Info("ludicrous speed load test created and started", "loadTestId", loadTest.ID)
-	return loadTest, nil
+	log.Info("ludicrous speed load test created and queued", "loadTestId", loadTest.ID)
+	return c.queue.withPosition(ctx, loadTest), nil
 }
 
 // processLoadTest handles the ludicrous speed load test processing
@@ -114,7 +129,6 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 	select {
 	case <-processCtx.Done():
 		c.updateLoadTestError(ctx, loadTest, "Process cancelled before start", processCtx.Err())
-		c.wsManager.SendLoadTestError(testID, "Load test cancelled: "+processCtx.Err().Error())
 		return
 	default:
 	}
@@ -137,6 +151,7 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 		Rows:        loadTest.Rows,
 		DateColumns: loadTest.DateColumns,
 		FilePrefix:  "ludicrous_test",
+		Locale:      utils.DateLocale(stringValue(loadTest.Locale)),
 		Context:     processCtx,
 		ProgressCallback: func(phase string, progress float64, message string) {
 			c.wsManager.SendLoadTestProgress(testID, map[string]any{
@@ -154,7 +169,6 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 	csvResult, err := utils.GeneratePerformanceCSV(csvConfig)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "CSV generation failed", err)
-		c.wsManager.SendLoadTestError(testID, "CSV generation failed: "+err.Error())
 		return
 	}
 	csvPath := csvResult.FilePath
@@ -164,7 +178,6 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 	select {
 	case <-processCtx.Done():
 		c.updateLoadTestError(ctx, loadTest, "Process cancelled after CSV generation", processCtx.Err())
-		c.wsManager.SendLoadTestError(testID, "Load test cancelled: "+processCtx.Err().Error())
 		return
 	default:
 	}
@@ -183,9 +196,27 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 
 	// Start timing for parse + insert only (excluding CSV generation)
 	parseInsertStartTime := time.Now()
+	file, err := os.Open(csvPath)
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Ludicrous insertion failed", err)
+		return
+	}
+	defer file.Close()
+
+	source, err := decompressedSource(file, 0)
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Ludicrous insertion failed", err)
+		return
+	}
+	defer source.Close()
+
+	// Checkpoint committed batches so an interrupted run can be resumed from the CSV on disk
+	opts := &ImportOptions{Checkpoint: newCheckpointTracker(c.loadTestRepo, loadTest, csvPath)}
+
 	timingResult, err := c.insertLudicrousStreaming(
 		processCtx,
-		csvPath,
+		source,
+		opts,
 		loadTest.ID,
 		loadTest.Rows,
 		parseInsertStartTime,
@@ -193,7 +224,6 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 	)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "Ludicrous insertion failed", err)
-		c.wsManager.SendLoadTestError(testID, "Ludicrous insertion failed: "+err.Error())
 		return
 	}
 
@@ -207,15 +237,19 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 	loadTest.InsertTime = &insertTime
 	loadTest.TotalTime = &totalTime
 	loadTest.Status = "completed"
+	recordRetries(loadTest, timingResult.BatchRetries)
+	recordDateCache(loadTest, timingResult.DateCache)
 
 	if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
 		_ = log.Err("failed to update completed load test", err, "loadTestId", loadTest.ID)
 	}
+	opts.Checkpoint.complete(ctx)
 
 	// Send completion notification
 	c.wsManager.SendLoadTestComplete(testID, map[string]any{
 		"id":          loadTest.ID.String(),
 		"rows":        loadTest.Rows,
+		"retries":     loadTest.Retries,
 		"columns":     loadTest.Columns,
 		"dateColumns": loadTest.DateColumns,
 		"method":      loadTest.Method,
@@ -224,6 +258,8 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 		"parseTime":   parseTime,
 		"insertTime":  insertTime,
 		"totalTime":   totalTime,
+		"dateCacheHits":   loadTest.DateCacheHits,
+		"dateCacheMisses": loadTest.DateCacheMisses,
 	})
 
 	log.Info("ludicrous speed load test completed successfully",
@@ -231,6 +267,24 @@ func (c *LudicrousOnlyController) processLoadTest(ctx context.Context, loadTest
 		"totalTime", totalTime)
 }
 
+// ImportStream runs the ludicrous speed streaming insertion directly against an uploaded file
+func (c *LudicrousOnlyController) ImportStream(
+	ctx context.Context,
+	loadTest *LoadTest,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
+) (LudicrousTimingResult, error) {
+	return c.insertLudicrousStreaming(
+		ctx,
+		source,
+		opts,
+		loadTest.ID,
+		loadTest.Rows,
+		time.Now(),
+		loadTest.ID.String(),
+	)
+}
+
 // generateLudicrousCSVFile creates a CSV file optimized for ludicrous speed method
 func (c *LudicrousOnlyController) generateLudicrousCSVFile(
 	ctx context.Context,
@@ -328,6 +382,7 @@ func (c *LudicrousOnlyController) generateLudicrousCSVFile(
 	}
 
 	// Generate data rows with ludicrous speed optimizations
+	locale := utils.DateLocale(stringValue(loadTest.Locale))
 	for i := 0; i < loadTest.Rows; i++ {
 		// Check for cancellation every 10,000 rows for better performance
 		if i > 0 && i%10000 == 0 {
@@ -338,7 +393,7 @@ func (c *LudicrousOnlyController) generateLudicrousCSVFile(
 			}
 		}
 		
-		row := c.generateLudicrousDataRow(allColumns, selectedDateColumnMap, allDateColumnMap, r)
+		row := c.generateLudicrousDataRow(allColumns, selectedDateColumnMap, allDateColumnMap, locale, r)
 		if err := writer.Write(row); err != nil {
 			return "", 0, fmt.Errorf("failed to write row %d: %w", i, err)
 		}
@@ -391,6 +446,7 @@ func (c *LudicrousOnlyController) selectRandomDateColumns(
 func (c *LudicrousOnlyController) generateLudicrousDataRow(
 	headers []string,
 	selectedDateColumnMap, allDateColumnMap map[string]bool,
+	locale utils.DateLocale,
 	rng *rand.Rand,
 ) []string {
 	row := make([]string, len(headers))
@@ -398,7 +454,7 @@ func (c *LudicrousOnlyController) generateLudicrousDataRow(
 	for i, header := range headers {
 		if allDateColumnMap[header] {
 			if selectedDateColumnMap[header] {
-				row[i] = c.generateLudicrousDateValue(rng)
+				row[i] = c.generateLudicrousDateValue(locale, rng)
 			} else {
 				row[i] = ""
 			}
@@ -410,8 +466,9 @@ func (c *LudicrousOnlyController) generateLudicrousDataRow(
 	return row
 }
 
-// generateLudicrousDateValue creates a date value optimized for ludicrous speed
-func (c *LudicrousOnlyController) generateLudicrousDateValue(rng *rand.Rand) string {
+// generateLudicrousDateValue creates a date value optimized for ludicrous speed, written in
+// locale's formats when it has its own
+func (c *LudicrousOnlyController) generateLudicrousDateValue(locale utils.DateLocale, rng *rand.Rand) string {
 	// Fewer formats for better performance
 	formats := []string{
 		"2006-01-02", "01/02/2006", "01-02-2006", "2006/01/02",
@@ -422,6 +479,9 @@ func (c *LudicrousOnlyController) generateLudicrousDateValue(rng *rand.Rand) str
 	day := rng.Intn(28) + 1
 
 	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if localized, ok := utils.FormatRandomInLocale(date, locale, rng); ok {
+		return localized
+	}
 	format := formats[rng.Intn(len(formats))]
 
 	return date.Format(format)
@@ -593,14 +653,18 @@ func (c *LudicrousOnlyController) generateLudicrousMeaningfulColumnValue(
 
 // LudicrousTimingResult contains timing breakdown specific to ludicrous method
 type LudicrousTimingResult struct {
-	ParseTime  int
-	InsertTime int
+	ParseTime        int
+	InsertTime       int
+	RecordsProcessed int
+	BatchRetries     BatchRetries
+	DateCache        utils.DateCacheStats
 }
 
 // insertLudicrousStreaming performs ludicrous speed streaming insertion
 func (c *LudicrousOnlyController) insertLudicrousStreaming(
 	ctx context.Context,
-	csvPath string,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
 	loadTestID uuid.UUID,
 	totalRecords int,
 	startTime time.Time,
@@ -610,26 +674,21 @@ func (c *LudicrousOnlyController) insertLudicrousStreaming(
 
 	// Ludicrous speed configuration - back to proven settings
 	numWorkers := runtime.NumCPU() * 2 // Double the workers
-	batchSize := 2000                  // Back to working batch size
-	bufferSize := numWorkers * 4       // Keep buffer at 4x workers to avoid overload
+	batchSize := opts.checkpoint().batchSize(2000) // Back to working batch size
+	bufferSize := numWorkers * 4                   // Keep buffer at 4x workers to avoid overload
 
 	log.Info("Starting ludicrous speed streaming insertion",
 		"totalRecords", totalRecords,
 		"workers", numWorkers,
 		"batchSize", batchSize)
 
-	file, err := os.Open(csvPath)
-	if err != nil {
-		return LudicrousTimingResult{}, fmt.Errorf("failed to open CSV file: %w", err)
-	}
-	defer file.Close()
-
 	// Initialize progress tracking
 	progress := &Progress{
 		TotalRecords:     totalRecords,
 		TotalBatches:     (totalRecords + batchSize - 1) / batchSize,
 		StartTime:        startTime,
-		RecordsProcessed: 0,
+		Source:           source,
+		RecordsProcessed: opts.checkpoint().rowsCommitted(), // Rows committed before a resume
 		BatchesProcessed: 0,
 	}
 
@@ -642,19 +701,21 @@ func (c *LudicrousOnlyController) insertLudicrousStreaming(
 	go c.monitorLudicrousProgress(progress, testID, progressDone)
 
 	// Start worker goroutines
+	retries := newRetryTracker()
 	var workerWG sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		workerWG.Add(1)
-		go c.ludicrousWorker(ctx, i, batchChan, errorChan, progress, batchSize, &workerWG)
+		go c.ludicrousWorker(ctx, i, batchChan, errorChan, progress, opts.checkpoint(), opts.upsert(), retries, &workerWG)
 	}
 
-	// Start CSV parser with cancellation context
+	// Start CSV parser with cancellation context, memoizing the dates it validates for the run
+	dates := newDateCache(c.dateUtils)
 	parserDone := make(chan error, 1)
 	parserCtx, cancelParser := context.WithCancel(ctx)
 	defer cancelParser()
 	
 	parseStartTime := time.Now()
-	go c.parseLudicrousCSVStreaming(file, loadTestID, batchChan, parserDone, batchSize, parserCtx)
+	go c.parseLudicrousCSVStreaming(source, opts, loadTestID, batchChan, parserDone, batchSize, dates, parserCtx)
 
 	// Wait for parser or worker errors with comprehensive error handling
 	var parseErr error
@@ -785,41 +846,54 @@ func (c *LudicrousOnlyController) insertLudicrousStreaming(
 	// Calculate actual insert time (total - parse time)
 	actualInsertTime := totalParseInsertTime - int(parseTime.Milliseconds())
 
+	dateCache := dates.Stats()
 	log.Info("ludicrous speed streaming insertion completed",
 		"totalRecords", progress.RecordsProcessed,
 		"parseTimeMs", parseTime.Milliseconds(),
-		"insertTimeMs", actualInsertTime)
+		"insertTimeMs", actualInsertTime,
+		"retries", retries.result().Total(),
+		"dateCacheHits", dateCache.Hits,
+		"dateCacheMisses", dateCache.Misses)
 
 	return LudicrousTimingResult{
-		ParseTime:  int(parseTime.Milliseconds()),
-		InsertTime: actualInsertTime,
+		ParseTime:        int(parseTime.Milliseconds()),
+		InsertTime:       actualInsertTime,
+		RecordsProcessed: progress.RecordsProcessed,
+		BatchRetries:     retries.result(),
+		DateCache:        dateCache,
 	}, nil
 }
 
 // parseLudicrousCSVStreaming parses CSV for ludicrous speed method
 func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
-	file *os.File,
+	input io.Reader,
+	opts *ImportOptions,
 	loadTestID uuid.UUID,
 	batchChan chan<- *BatchData,
 	done chan<- error,
 	batchSize int,
+	dates *utils.DateCache,
 	ctx context.Context,
 ) {
 	log := c.log.Function("parseLudicrousCSVStreaming")
 	
-	reader := csv.NewReader(file)
+	reader := opts.recordReader(input)
+	rejections := opts.rejections()
+
+	checkpoint := opts.checkpoint()
 
-	// Read header
-	headers, err := reader.Read()
+	// Read header, or take it from the checkpoint when resuming mid-file
+	headers, err := checkpoint.readHeaders(reader)
 	if err != nil {
 		log.Error("Failed to read CSV headers", "error", err)
 		done <- fmt.Errorf("failed to read CSV headers: %w", err)
 		return
 	}
+	fields := opts.resolveFields(headers)
 
 	// Create simplified setters for ludicrous speed parsing
 	setters := make([]func(td *TestData, val string), len(headers))
-	for i, header := range headers {
+	for i, header := range fields {
 		switch header {
 		case "first_name":
 			setters[i] = func(td *TestData, val string) { td.FirstName = &val }
@@ -839,31 +913,34 @@ func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
 			setters[i] = func(td *TestData, val string) { td.ZipCode = &val }
 		case "employer":
 			setters[i] = func(td *TestData, val string) { td.Employer = &val }
+		case DATE_OFFSETS_FIELD:
+			setters[i] = func(td *TestData, val string) { td.DateOffsets = &val }
 		case "birth_date", "start_date", "end_date":
 			h := header
 			setters[i] = func(td *TestData, val string) {
 				if val != "" {
-					// Simplified date validation for ludicrous speed
-					if len(val) >= 8 { // Basic length check
-						switch h {
-						case "birth_date":
-							td.BirthDate = &val
-						case "start_date":
-							td.StartDate = &val
-						case "end_date":
-							td.EndDate = &val
-						}
+					// Repeated dates are answered from the run's cache, so most values skip validation
+					if result := dates.ValidateAndConvert(val); result.IsValid {
+						setNormalizedDate(td, h, result)
+					} else if len(val) >= 8 { // Basic length check for values the validator can't read
+						*td.FieldPointer(h) = &val
 					}
 				}
 			}
 		default:
 			setters[i] = func(td *TestData, val string) {}
+			// Upserts need the whole natural key even where it falls outside the ludicrous columns
+			if opts.upsert().hasField(header) {
+				h := header
+				setters[i] = func(td *TestData, val string) { *td.FieldPointer(h) = &val }
+			}
 		}
 	}
 
 	currentBatch := make([]*TestData, 0, batchSize)
-	batchNum := 0
+	batchNum := checkpoint.firstBatch()
 	rowsRead := 0
+	lastLine := 0
 
 	for {
 		// Check for cancellation before reading each row
@@ -875,7 +952,7 @@ func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
 		default:
 		}
 		
-		row, err := reader.Read()
+		row, line, err := rejections.nextRecord(ctx, reader)
 		if err != nil {
 			if err.Error() == "EOF" {
 				log.Info("Reached end of CSV file", "totalRowsRead", rowsRead, "finalBatchNum", batchNum)
@@ -887,6 +964,7 @@ func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
 		}
 		
 		rowsRead++
+		lastLine = line
 
 		testData := &TestData{
 			// Let database handle ID generation automatically
@@ -900,6 +978,15 @@ func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
 			}
 		}
 
+		// Uploaded rows with structural problems or dates that fail the basic check are rejected
+		if rejections != nil {
+			issues := append(checkRowStructure(row, fields), dateFieldIssues(row, fields, testData)...)
+			if len(issues) > 0 {
+				rejections.RejectRow(ctx, line, issues, row)
+				continue
+			}
+		}
+
 		currentBatch = append(currentBatch, testData)
 
 		// Send batch when full
@@ -907,6 +994,14 @@ func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
 			batchData := &BatchData{
 				Records:  currentBatch,
 				BatchNum: batchNum,
+				Mark:     checkpoint.mark(reader, row, len(currentBatch)),
+			}
+
+			// Batches already committed before a resume only advance the checkpoint
+			if checkpoint.skipBatch(ctx, batchNum, batchData.Mark) {
+				currentBatch = make([]*TestData, 0, batchSize)
+				batchNum++
+				continue
 			}
 			
 			// Send batch with context awareness
@@ -939,6 +1034,11 @@ func (c *LudicrousOnlyController) parseLudicrousCSVStreaming(
 		batchData := &BatchData{
 			Records:  currentBatch,
 			BatchNum: batchNum,
+			Mark:     checkpoint.endOfInput(reader, lastLine, len(currentBatch)),
+		}
+		if checkpoint.skipBatch(ctx, batchNum, batchData.Mark) {
+			done <- nil
+			return
 		}
 		select {
 		case batchChan <- batchData:
@@ -960,7 +1060,9 @@ func (c *LudicrousOnlyController) ludicrousWorker(
 	batchChan <-chan *BatchData,
 	errorChan chan<- error,
 	progress *Progress,
-	batchSize int,
+	checkpoint *checkpointTracker,
+	upsert *upsertImport,
+	retries *retryTracker,
 	wg *sync.WaitGroup,
 ) {
 	defer wg.Done()
@@ -987,18 +1089,31 @@ func (c *LudicrousOnlyController) ludicrousWorker(
 		default:
 		}
 
-		err := c.insertBatchWithRawSQLLudicrous(ctx, sqlDB, batch.Records)
+		// Transient failures such as deadlocks or a dropped connection are retried before failing the run
+		retried, err := c.retryPolicy.Run(ctx, func() error {
+			if upsert == nil {
+				return c.insertBatchWithRawSQLLudicrous(ctx, sqlDB, batch, checkpoint)
+			}
+			counts, err := c.upsertBatchWithRawSQLLudicrous(ctx, sqlDB, batch, checkpoint, upsert)
+			if err == nil {
+				upsert.record(counts)
+			}
+			return err
+		})
+		retries.record(batch.BatchNum, retried)
 		
 		if err != nil {
 			log.Error("Batch insert failed", 
 				"batchNum", batch.BatchNum, 
 				"records", len(batch.Records),
+				"retries", retried,
 				"error", err)
 			errorChan <- fmt.Errorf("ludicrous worker %d failed to insert batch %d: %w", workerID, batch.BatchNum, err)
 			return
 		}
 
 		batchesProcessed++
+		checkpoint.commit(ctx, batch.BatchNum, batch.Mark)
 
 		// Update progress
 		progress.mu.Lock()
@@ -1015,8 +1130,10 @@ func (c *LudicrousOnlyController) ludicrousWorker(
 func (c *LudicrousOnlyController) insertBatchWithRawSQLLudicrous(
 	ctx context.Context,
 	sqlDB *sql.DB,
-	records []*TestData,
+	batch *BatchData,
+	checkpoint *checkpointTracker,
 ) error {
+	records := batch.Records
 	if len(records) == 0 {
 		return nil
 	}
@@ -1041,50 +1158,34 @@ func (c *LudicrousOnlyController) insertBatchWithRawSQLLudicrous(
 	}()
 
 	// Simplified SQL for ludicrous speed - only essential columns
-	const baseSQL = `INSERT INTO test_data (
-		load_test_id, birth_date, start_date, end_date,
-		first_name, last_name, email, phone, address_line1, city, state, zip_code, employer
-	) VALUES `
+	columns := make([]string, 0, len(LUDICROUS_FIELDS)+1)
+	columns = append(columns, "load_test_id")
+	for _, field := range LUDICROUS_FIELDS {
+		columns = append(columns, TestDataColumn(field))
+	}
+	baseSQL := "INSERT INTO test_data (" + strings.Join(columns, ", ") + ") VALUES "
 
 	// Build VALUES clauses
 	var valueClauses []string
-	args := make(
-		[]interface{},
-		0,
-		len(records)*13,
-	) // 13 columns (removed id, created_at, updated_at)
+	args := make([]interface{}, 0, len(records)*len(columns))
 
 	for i, record := range records {
 		// Build placeholders
-		placeholders := make([]string, 13)
-		for j := 0; j < 13; j++ {
-			placeholders[j] = fmt.Sprintf("$%d", i*13+j+1)
+		placeholders := make([]string, len(columns))
+		for j := range columns {
+			placeholders[j] = fmt.Sprintf("$%d", i*len(columns)+j+1)
 		}
 		valueClauses = append(valueClauses, "("+strings.Join(placeholders, ", ")+")")
 
-		// Helper function to safely dereference string pointers
-		getStringValue := func(ptr *string) interface{} {
-			if ptr == nil {
-				return nil
+		args = append(args, record.LoadTestID)
+		for _, field := range LUDICROUS_FIELDS {
+			// Safely dereference string pointers
+			if value := *record.FieldPointer(field); value != nil {
+				args = append(args, *value)
+			} else {
+				args = append(args, nil)
 			}
-			return *ptr
 		}
-
-		args = append(args,
-			record.LoadTestID,
-			getStringValue(record.BirthDate),
-			getStringValue(record.StartDate),
-			getStringValue(record.EndDate),
-			getStringValue(record.FirstName),
-			getStringValue(record.LastName),
-			getStringValue(record.Email),
-			getStringValue(record.Phone),
-			getStringValue(record.AddressLine1),
-			getStringValue(record.City),
-			getStringValue(record.State),
-			getStringValue(record.ZipCode),
-			getStringValue(record.Employer),
-		)
 	}
 
 	// Combine SQL
@@ -1100,6 +1201,11 @@ func (c *LudicrousOnlyController) insertBatchWithRawSQLLudicrous(
 			err,
 		)
 	}
+
+	if err := checkpoint.recordBatch(txCtx, tx, batch.BatchNum, batch.Mark); err != nil {
+		tx.Rollback()
+		return err
+	}
 	
 	// Commit the transaction
 	if err = tx.Commit(); err != nil {
@@ -1109,6 +1215,54 @@ func (c *LudicrousOnlyController) insertBatchWithRawSQLLudicrous(
 	return nil
 }
 
+// upsertBatchWithRawSQLLudicrous upserts the ludicrous columns plus the natural key on the key
+func (c *LudicrousOnlyController) upsertBatchWithRawSQLLudicrous(
+	ctx context.Context,
+	sqlDB *sql.DB,
+	batch *BatchData,
+	checkpoint *checkpointTracker,
+	upsert *upsertImport,
+) (UpsertCounts, error) {
+	records := batch.Records
+	if len(records) == 0 {
+		return UpsertCounts{}, nil
+	}
+
+	txCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := sqlDB.BeginTx(txCtx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on return unless commit is successful
+
+	statement, args := repositories.BuildTestDataUpsert(records, upsert.withKeyFields(LUDICROUS_FIELDS), upsert.Columns)
+	rows, err := tx.QueryContext(txCtx, statement, args...)
+	if err != nil {
+		return UpsertCounts{}, fmt.Errorf(
+			"ludicrous raw SQL batch upsert failed (records: %d): %w",
+			len(records),
+			err,
+		)
+	}
+
+	counts, err := repositories.CountUpserted(rows, len(records))
+	if err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to read upserted rows: %w", err)
+	}
+
+	if err := checkpoint.recordBatch(txCtx, tx, batch.BatchNum, batch.Mark); err != nil {
+		return UpsertCounts{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return counts, nil
+}
+
 // monitorLudicrousProgress sends progress updates for ludicrous speed method
 func (c *LudicrousOnlyController) monitorLudicrousProgress(
 	progress *Progress,
@@ -1144,19 +1298,8 @@ func (c *LudicrousOnlyController) sendProgressUpdate(
 
 	elapsed := time.Since(progress.StartTime)
 	
-	// Safe progress calculation with validation
-	var overallProgress float64
-	if progress.TotalRecords > 0 && progress.RecordsProcessed >= 0 {
-		overallProgress = float64(progress.RecordsProcessed) / float64(progress.TotalRecords)
-		// Clamp to valid range
-		if overallProgress > 1.0 {
-			overallProgress = 1.0
-		} else if overallProgress < 0 {
-			overallProgress = 0
-		}
-	} else {
-		overallProgress = 0
-	}
+	// Safe progress calculation with validation (fraction is clamped to [0, 1])
+	overallProgress := progress.fraction()
 	
 	phaseProgress := overallProgress * 100
 
@@ -1224,24 +1367,14 @@ func (c *LudicrousOnlyController) sendProgressUpdate(
 
 // sendHeartbeat function removed - heartbeats were causing UI issues
 
-// updateLoadTestError updates load test with error information for ludicrous speed method
+// updateLoadTestError marks the load test as failed, or cancelled, and notifies clients
 func (c *LudicrousOnlyController) updateLoadTestError(
 	ctx context.Context,
 	loadTest *LoadTest,
 	message string,
 	err error,
 ) {
-	log := c.log.Function("updateLoadTestError")
-
-	errorMsg := message + ": " + err.Error()
-	loadTest.Status = "failed"
-	loadTest.ErrorMessage = &errorMsg
-
-	if updateErr := c.loadTestRepo.Update(ctx, loadTest); updateErr != nil {
-		_ = log.Err("failed to update load test error", updateErr, "loadTestId", loadTest.ID)
-	}
-
-	_ = log.Err(message, err, "loadTestId", loadTest.ID)
+	recordRunFailure(ctx, c.loadTestRepo, c.wsManager, c.log.Function("updateLoadTestError"), loadTest, message, err)
 }
 
 // GetLoadTestByID retrieves a load test by ID
@@ -1249,7 +1382,11 @@ func (c *LudicrousOnlyController) GetLoadTestByID(
 	ctx context.Context,
 	id string,
 ) (*LoadTest, error) {
-	return c.loadTestRepo.GetByID(ctx, id)
+	loadTest, err := c.loadTestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.queue.withPosition(ctx, loadTest), nil
 }
 
 // GetAllLoadTests retrieves all load tests
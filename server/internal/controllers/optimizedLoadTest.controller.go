@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"server/config"
 	"server/internal/database"
 	"server/internal/logger"
 	. "server/internal/models"
@@ -37,6 +38,7 @@ type OptimizedLoadTestController struct {
 	dateUtils    *utils.DateUtils
 	log          logger.Logger
 	wsManager    WSManager
+	config       config.Config
 }
 
 // InsertMethod defines the insertion approach
@@ -52,14 +54,16 @@ type WorkerConfig struct {
 	NumWorkers    int
 	BatchSize     int
 	BufferSize    int
-	BatchesPerTxn int          // Number of batches per transaction
-	InsertMethod  InsertMethod // Which insertion method to use
+	BatchesPerTxn int                  // Number of batches per transaction
+	InsertMethod  InsertMethod         // Which insertion method to use
+	Retry         database.RetryPolicy // Applied to raw SQL batches that fail with a transient database error
 }
 
 // BatchData represents a batch of records ready for insertion
 type BatchData struct {
 	Records  []*TestData
 	BatchNum int
+	Mark     batchMark // Source position after the batch, used for resume checkpoints
 }
 
 // Progress represents the current state of the insertion process
@@ -69,22 +73,47 @@ type Progress struct {
 	TotalRecords     int
 	TotalBatches     int
 	StartTime        time.Time
+	Source           *utils.ProgressReader // Set for streamed imports, used when TotalRecords is unknown
 	mu               sync.RWMutex
 }
 
+// fraction returns the completed fraction in the range [0, 1].
+// Callers must hold at least a read lock.
+func (p *Progress) fraction() float64 {
+	return streamFraction(p.RecordsProcessed, p.TotalRecords, p.Source)
+}
+
+// streamFraction calculates completion from the record count when the total is known,
+// falling back to bytes consumed from the source for uploads of unknown length
+func streamFraction(processed, total int, source *utils.ProgressReader) float64 {
+	if total > 0 {
+		fraction := float64(processed) / float64(total)
+		if fraction > 1 {
+			return 1
+		}
+		return fraction
+	}
+	if source != nil {
+		return source.Fraction()
+	}
+	return 0
+}
+
 func NewOptimizedLoadTestController(
 	db database.DB,
 	loadTestRepo repositories.LoadTestRepository,
 	testDataRepo repositories.TestDataRepository,
 	wsManager WSManager,
+	config config.Config,
 ) *OptimizedLoadTestController {
 	return &OptimizedLoadTestController{
 		db:           db,
 		loadTestRepo: loadTestRepo,
 		testDataRepo: testDataRepo,
-		dateUtils:    utils.NewDateUtils(),
+		dateUtils:    newDateColumnUtils(),
 		log:          logger.New("optimizedLoadTestController"),
 		wsManager:    wsManager,
+		config:       config,
 	}
 }
 
@@ -101,7 +130,7 @@ func DefaultWorkerConfig() *WorkerConfig {
 }
 
 // RawSQLWorkerConfig provides configuration optimized for raw SQL insertion
-func RawSQLWorkerConfig() *WorkerConfig {
+func RawSQLWorkerConfig(config config.Config) *WorkerConfig {
 	numWorkers := runtime.NumCPU()
 	return &WorkerConfig{
 		NumWorkers:    numWorkers,
@@ -109,13 +138,16 @@ func RawSQLWorkerConfig() *WorkerConfig {
 		BufferSize:    numWorkers * 4,
 		BatchesPerTxn: 4,
 		InsertMethod:  InsertMethodRawSQL,
+		Retry:         database.RetryPolicyFor(config, "optimized"),
 	}
 }
 
 // TimingResult contains the timing breakdown for database operations
 type TimingResult struct {
-	ParseTime  int // milliseconds spent parsing CSV
-	InsertTime int // milliseconds spent inserting to database
+	ParseTime    int                  // milliseconds spent parsing CSV
+	InsertTime   int                  // milliseconds spent inserting to database
+	BatchRetries BatchRetries         // Retries made for batches that hit transient database errors
+	DateCache    utils.DateCacheStats // Date validations answered from and added to the run's cache
 }
 
 // InsertOptimizedWithProgress performs streaming CSV parsing with concurrent batch processing using GORM
@@ -141,7 +173,7 @@ func (c *OptimizedLoadTestController) InsertOptimizedWithRawSQL(
 	startTime time.Time,
 	testID string,
 ) (TimingResult, error) {
-	config := RawSQLWorkerConfig()
+	config := RawSQLWorkerConfig(c.config)
 	return c.insertWithConfig(ctx, csvPath, loadTestID, totalRecords, startTime, testID, config)
 }
 
@@ -161,6 +193,7 @@ func (c *OptimizedLoadTestController) InsertLudicrousSpeed(
 		BufferSize:    runtime.NumCPU() * 6, // Larger buffer for ludicrous
 		BatchesPerTxn: 1,                    // Minimal transaction overhead
 		InsertMethod:  InsertMethodRawSQL,
+		Retry:         database.RetryPolicyFor(c.config, "ludicrous"),
 	}
 	return c.insertWithConfig(ctx, csvPath, loadTestID, totalRecords, startTime, testID, config)
 }
@@ -222,11 +255,12 @@ func (c *OptimizedLoadTestController) insertWithConfig(
 	go c.monitorOptimizedProgress(progress, testID, progressDone)
 
 	// Start worker goroutines
+	retries := newRetryTracker()
 	var workerWG sync.WaitGroup
 	workerStartTime := time.Now()
 	for i := 0; i < config.NumWorkers; i++ {
 		workerWG.Add(1)
-		go c.insertWorker(ctx, i, batchChan, errorChan, progress, config, &workerWG)
+		go c.insertWorker(ctx, i, batchChan, errorChan, progress, config, retries, &workerWG)
 	}
 	c.log.Info(
 		"Started workers",
@@ -236,10 +270,11 @@ func (c *OptimizedLoadTestController) insertWithConfig(
 		time.Since(workerStartTime),
 	)
 
-	// Start CSV parser (producer)
+	// Start CSV parser (producer), memoizing the dates it validates for the run
+	dates := newDateCache(c.dateUtils)
 	parserDone := make(chan error, 1)
 	parseStartTime := time.Now()
-	go c.parseCSVStreaming(file, loadTestID, batchChan, parserDone, config)
+	go c.parseCSVStreaming(file, loadTestID, batchChan, parserDone, config, dates)
 
 	// Wait for either parser to finish or worker error
 	var parseErr error
@@ -258,6 +293,7 @@ func (c *OptimizedLoadTestController) insertWithConfig(
 		}
 	}
 	parseTime := time.Since(parseStartTime)
+	dateCache := dates.Stats()
 	if parseTime.Seconds() > 0 {
 		c.log.Info(
 			"CSV parsing completed",
@@ -265,9 +301,11 @@ func (c *OptimizedLoadTestController) insertWithConfig(
 			parseTime,
 			"recordsPerSecond",
 			float64(totalRecords)/parseTime.Seconds(),
+			"dateCacheHitRate",
+			dateCache.HitRate(),
 		)
 	} else {
-		c.log.Info("CSV parsing completed", "duration", parseTime)
+		c.log.Info("CSV parsing completed", "duration", parseTime, "dateCacheHitRate", dateCache.HitRate())
 	}
 
 	// Close batch channel to signal workers to finish
@@ -321,6 +359,8 @@ func (c *OptimizedLoadTestController) insertWithConfig(
 		"totalTime", time.Since(startTime),
 		"indexDropTime", indexDropTime,
 		"parseTime", parseTime,
+		"dateCacheHits", dateCache.Hits,
+		"dateCacheMisses", dateCache.Misses,
 		"workersWaitTime", workersWaitTime,
 		"indexRecreateTime", indexRecreateTime,
 		"finalProcessed", finalProcessed,
@@ -351,8 +391,10 @@ func (c *OptimizedLoadTestController) insertWithConfig(
 		"rowsPerSecond", rowsPerSecond)
 
 	return TimingResult{
-		ParseTime:  int(parseTime.Milliseconds()),
-		InsertTime: insertTime,
+		ParseTime:    int(parseTime.Milliseconds()),
+		InsertTime:   insertTime,
+		BatchRetries: retries.result(),
+		DateCache:    dateCache,
 	}, nil
 }
 
@@ -363,6 +405,7 @@ func (c *OptimizedLoadTestController) parseCSVStreaming(
 	batchChan chan<- *BatchData,
 	done chan<- error,
 	config *WorkerConfig,
+	dates *utils.DateCache,
 ) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -429,17 +472,9 @@ func (c *OptimizedLoadTestController) parseCSVStreaming(
 			// Need to capture header for the closure
 			h := header
 			setters[i] = func(td *TestData, val string) {
-				validationResult := c.dateUtils.GetValidator().ValidateAndConvert(val)
+				validationResult := dates.ValidateAndConvert(val)
 				if validationResult.IsValid {
-					dateStr := validationResult.ParsedTime.Format("2006-01-02")
-					switch h {
-					case "birth_date":
-						td.BirthDate = &dateStr
-					case "start_date":
-						td.StartDate = &dateStr
-					case "end_date":
-						td.EndDate = &dateStr
-					}
+					setNormalizedDate(td, h, validationResult)
 				}
 			}
 		default:
@@ -536,6 +571,7 @@ func (c *OptimizedLoadTestController) insertWorker(
 	errorChan chan<- error,
 	progress *Progress,
 	config *WorkerConfig,
+	retries *retryTracker,
 	wg *sync.WaitGroup,
 ) {
 	defer wg.Done()
@@ -544,7 +580,11 @@ func (c *OptimizedLoadTestController) insertWorker(
 		var err error
 		switch config.InsertMethod {
 		case InsertMethodRawSQL:
-			err = c.insertBatchWithRawSQL(ctx, batch.Records, config)
+			var retried int
+			retried, err = config.Retry.Run(ctx, func() error {
+				return c.insertBatchWithRawSQL(ctx, batch.Records, config)
+			})
+			retries.record(batch.BatchNum, retried)
 		case InsertMethodGORM:
 			fallthrough
 		default:
@@ -909,6 +949,7 @@ func (c *OptimizedLoadTestController) executeStreamingCopy(
 		"employer", "job_title", "department", "salary",
 		"insurance_plan_id", "insurance_carrier", "policy_number",
 		"group_number", "member_id",
+		"birth_date_precision", "start_date_precision", "end_date_precision",
 	}
 	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("test_data", copyColumns...))
 	if err != nil {
@@ -953,9 +994,9 @@ func (c *OptimizedLoadTestController) executeStreamingCopy(
 
 		// This is a bit verbose, but it's fast. No map creation per row.
 		values[0] = loadTestIDStr
-		values[1] = c.getNormalizedDateOrNull(getValue("birth_date"))
-		values[2] = c.getNormalizedDateOrNull(getValue("start_date"))
-		values[3] = c.getNormalizedDateOrNull(getValue("end_date"))
+		values[1], values[24] = c.getNormalizedDateOrNull(getValue("birth_date"))
+		values[2], values[25] = c.getNormalizedDateOrNull(getValue("start_date"))
+		values[3], values[26] = c.getNormalizedDateOrNull(getValue("end_date"))
 		values[4] = c.getValueOrNull(getValue("first_name"))
 		values[5] = c.getValueOrNull(getValue("last_name"))
 		values[6] = c.getValueOrNull(getValue("email"))
@@ -1014,15 +1055,16 @@ func (c *OptimizedLoadTestController) getValueOrNull(value string) interface{} {
 	return nil
 }
 
-// getNormalizedDateOrNull validates and normalizes a date string, or returns nil
-func (c *OptimizedLoadTestController) getNormalizedDateOrNull(value string) interface{} {
+// getNormalizedDateOrNull validates and normalizes a date string along with its precision, or
+// returns nils
+func (c *OptimizedLoadTestController) getNormalizedDateOrNull(value string) (interface{}, interface{}) {
 	if value != "" {
 		result := c.dateUtils.GetValidator().ValidateAndConvert(value)
 		if result.IsValid {
-			return result.ParsedTime.Format("2006-01-02")
+			return result.Normalized(), string(result.Precision)
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 // monitorPlaidCopyProgress sends real-time progress updates for COPY operation
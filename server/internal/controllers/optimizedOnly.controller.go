@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -27,6 +28,8 @@ type OptimizedOnlyController struct {
 	log          logger.Logger
 	wsManager    WSManager
 	db           database.DB
+	queue        *LoadTestQueue
+	retryPolicy  database.RetryPolicy
 }
 
 func NewOptimizedOnlyController(
@@ -35,20 +38,28 @@ func NewOptimizedOnlyController(
 	db database.DB,
 	wsManager WSManager,
 	config config.Config,
+	queue *LoadTestQueue,
 ) *OptimizedOnlyController {
 	return &OptimizedOnlyController{
 		loadTestRepo: loadTestRepo,
 		testDataRepo: testDataRepo,
-		dateUtils:    utils.NewDateUtils(),
+		dateUtils:    newDateColumnUtils(),
 		log:          logger.New("optimizedOnlyController"),
 		wsManager:    wsManager,
 		db:           db,
+		queue:        queue,
+		retryPolicy:  database.RetryPolicyFor(config, "optimized"),
 	}
 }
 
 // CreateAndRunTest creates a new optimized load test and starts the performance test
 func (c *OptimizedOnlyController) CreateAndRunTest(ctx context.Context, req *CreateLoadTestRequest) (*LoadTest, error) {
 	log := c.log.Function("CreateAndRunTest")
+
+	locale, err := generatedLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
 	
 	// Force method to optimized
 	const FixedTotalColumns = 25
@@ -60,7 +71,9 @@ func (c *OptimizedOnlyController) CreateAndRunTest(ctx context.Context, req *Cre
 		Columns:       FixedTotalColumns,
 		DateColumns:   FixedDateColumns,
 		Method:        "optimized", // Force optimized method
-		Status:        "running",
+		Status:        "queued",
+		Source:        "generated",
+		Locale:        locale,
 	}
 
 	if err := c.loadTestRepo.Create(ctx, loadTest); err != nil {
@@ -68,11 +81,14 @@ func (c *OptimizedOnlyController) CreateAndRunTest(ctx context.Context, req *Cre
 		return nil, fmt.Errorf("failed to create load test: %w", err)
 	}
 
-	// Process the load test asynchronously
-	go c.processLoadTest(ctx, loadTest)
+	// The run waits in the queue until a slot is free
+	if err := c.queue.Enqueue(ctx, loadTest, LOAD_TEST_JOB_RUN, req.Priority); err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Failed to queue load test", err)
+		return nil, err
+	}
 
-	log.Info("optimized load test created and started", "loadTestId", loadTest.ID)
-	return loadTest, nil
+	log.Info("optimized load test created and queued", "loadTestId", loadTest.ID)
+	return c.queue.withPosition(ctx, loadTest), nil
 }
 
 // processLoadTest handles the optimized load test processing
@@ -98,6 +114,7 @@ func (c *OptimizedOnlyController) processLoadTest(ctx context.Context, loadTest
 		Rows:        loadTest.Rows,
 		DateColumns: loadTest.DateColumns,
 		FilePrefix:  "optimized_test",
+		Locale:      utils.DateLocale(stringValue(loadTest.Locale)),
 		Context:     ctx,
 		ProgressCallback: func(phase string, progress float64, message string) {
 			c.wsManager.SendLoadTestProgress(testID, map[string]any{
@@ -115,7 +132,6 @@ func (c *OptimizedOnlyController) processLoadTest(ctx context.Context, loadTest
 	csvResult, err := utils.GeneratePerformanceCSV(csvConfig)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "CSV generation failed", err)
-		c.wsManager.SendLoadTestError(testID, "CSV generation failed: "+err.Error())
 		return
 	}
 	csvPath := csvResult.FilePath
@@ -135,10 +151,23 @@ func (c *OptimizedOnlyController) processLoadTest(ctx context.Context, loadTest
 	
 	// Start timing for parse + insert only (excluding CSV generation)
 	parseInsertStartTime := time.Now()
-	timingResult, err := c.insertOptimizedStreaming(ctx, csvPath, loadTest.ID, loadTest.Rows, parseInsertStartTime, testID)
+	file, err := os.Open(csvPath)
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Optimized insertion failed", err)
+		return
+	}
+	defer file.Close()
+
+	source, err := decompressedSource(file, 0)
+	if err != nil {
+		c.updateLoadTestError(ctx, loadTest, "Optimized insertion failed", err)
+		return
+	}
+	defer source.Close()
+
+	timingResult, err := c.insertOptimizedStreaming(ctx, source, nil, loadTest.ID, loadTest.Rows, parseInsertStartTime, testID)
 	if err != nil {
 		c.updateLoadTestError(ctx, loadTest, "Optimized insertion failed", err)
-		c.wsManager.SendLoadTestError(testID, "Optimized insertion failed: "+err.Error())
 		return
 	}
 	
@@ -152,6 +181,8 @@ func (c *OptimizedOnlyController) processLoadTest(ctx context.Context, loadTest
 	loadTest.InsertTime = &insertTime
 	loadTest.TotalTime = &totalTime
 	loadTest.Status = "completed"
+	recordRetries(loadTest, timingResult.BatchRetries)
+	recordDateCache(loadTest, timingResult.DateCache)
 	
 	if err := c.loadTestRepo.Update(ctx, loadTest); err != nil {
 		_ = log.Err("failed to update completed load test", err, "loadTestId", loadTest.ID)
@@ -169,6 +200,9 @@ func (c *OptimizedOnlyController) processLoadTest(ctx context.Context, loadTest
 		"parseTime":    parseTime,
 		"insertTime":   insertTime,
 		"totalTime":    totalTime,
+		"retries":      loadTest.Retries,
+		"dateCacheHits":   loadTest.DateCacheHits,
+		"dateCacheMisses": loadTest.DateCacheMisses,
 	})
 	
 	log.Info("optimized load test completed successfully", 
@@ -176,6 +210,16 @@ func (c *OptimizedOnlyController) processLoadTest(ctx context.Context, loadTest
 		"totalTime", totalTime)
 }
 
+// ImportStream runs the optimized streaming insertion directly against an uploaded file
+func (c *OptimizedOnlyController) ImportStream(
+	ctx context.Context,
+	loadTest *LoadTest,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
+) (OptimizedTimingResult, error) {
+	return c.insertOptimizedStreaming(ctx, source, opts, loadTest.ID, loadTest.Rows, time.Now(), loadTest.ID.String())
+}
+
 // generateOptimizedCSVFile creates a CSV file optimized for the optimized method
 func (c *OptimizedOnlyController) generateOptimizedCSVFile(loadTest *LoadTest) (string, int, error) {
 	log := c.log.Function("generateOptimizedCSVFile")
@@ -268,8 +312,9 @@ func (c *OptimizedOnlyController) generateOptimizedCSVFile(loadTest *LoadTest) (
 	}
 	
 	// Generate data rows
+	locale := utils.DateLocale(stringValue(loadTest.Locale))
 	for i := 0; i < loadTest.Rows; i++ {
-		row := c.generateOptimizedDataRow(allColumns, selectedDateColumnMap, allDateColumnMap, r)
+		row := c.generateOptimizedDataRow(allColumns, selectedDateColumnMap, allDateColumnMap, locale, r)
 		if err := writer.Write(row); err != nil {
 			return "", 0, fmt.Errorf("failed to write row %d: %w", i, err)
 		}
@@ -309,13 +354,13 @@ func (c *OptimizedOnlyController) selectRandomDateColumns(allDateColumns []strin
 }
 
 // generateOptimizedDataRow creates a data row optimized for the optimized method
-func (c *OptimizedOnlyController) generateOptimizedDataRow(headers []string, selectedDateColumnMap, allDateColumnMap map[string]bool, rng *rand.Rand) []string {
+func (c *OptimizedOnlyController) generateOptimizedDataRow(headers []string, selectedDateColumnMap, allDateColumnMap map[string]bool, locale utils.DateLocale, rng *rand.Rand) []string {
 	row := make([]string, len(headers))
 	
 	for i, header := range headers {
 		if allDateColumnMap[header] {
 			if selectedDateColumnMap[header] {
-				row[i] = c.generateOptimizedDateValue(rng)
+				row[i] = c.generateOptimizedDateValue(locale, rng)
 			} else {
 				row[i] = ""
 			}
@@ -327,8 +372,9 @@ func (c *OptimizedOnlyController) generateOptimizedDataRow(headers []string, sel
 	return row
 }
 
-// generateOptimizedDateValue creates a date value optimized for the optimized method
-func (c *OptimizedOnlyController) generateOptimizedDateValue(rng *rand.Rand) string {
+// generateOptimizedDateValue creates a date value optimized for the optimized method, written in
+// locale's formats when it has its own
+func (c *OptimizedOnlyController) generateOptimizedDateValue(locale utils.DateLocale, rng *rand.Rand) string {
 	formats := []string{
 		"01/02/2006", "1/2/2006", "01/02/06", "1/2/06",
 		"01-02-2006", "1-2-2006", "01-02-06", "1-2-06",
@@ -340,6 +386,9 @@ func (c *OptimizedOnlyController) generateOptimizedDateValue(rng *rand.Rand) str
 	day := rng.Intn(28) + 1
 	
 	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if localized, ok := utils.FormatRandomInLocale(date, locale, rng); ok {
+		return localized
+	}
 	format := formats[rng.Intn(len(formats))]
 	
 	return date.Format(format)
@@ -432,14 +481,18 @@ func (c *OptimizedOnlyController) generateOptimizedMeaningfulColumnValue(columnN
 
 // OptimizedTimingResult contains timing breakdown specific to optimized method
 type OptimizedTimingResult struct {
-	ParseTime  int
-	InsertTime int
+	ParseTime        int
+	InsertTime       int
+	RecordsProcessed int
+	BatchRetries     BatchRetries
+	DateCache        utils.DateCacheStats
 }
 
 // insertOptimizedStreaming performs optimized streaming insertion
 func (c *OptimizedOnlyController) insertOptimizedStreaming(
 	ctx context.Context,
-	csvPath string,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
 	loadTestID uuid.UUID,
 	totalRecords int,
 	startTime time.Time,
@@ -457,17 +510,12 @@ func (c *OptimizedOnlyController) insertOptimizedStreaming(
 		"workers", numWorkers,
 		"batchSize", batchSize)
 
-	file, err := os.Open(csvPath)
-	if err != nil {
-		return OptimizedTimingResult{}, fmt.Errorf("failed to open CSV file: %w", err)
-	}
-	defer file.Close()
-
 	// Initialize progress tracking
 	progress := &Progress{
 		TotalRecords:     totalRecords,
 		TotalBatches:     (totalRecords + batchSize - 1) / batchSize,
 		StartTime:        startTime,
+		Source:           source,
 		RecordsProcessed: 0,
 		BatchesProcessed: 0,
 	}
@@ -481,16 +529,21 @@ func (c *OptimizedOnlyController) insertOptimizedStreaming(
 	go c.monitorOptimizedProgress(progress, testID, progressDone)
 
 	// Start worker goroutines
+	retries := newRetryTracker()
 	var workerWG sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		workerWG.Add(1)
-		go c.optimizedWorker(ctx, i, batchChan, errorChan, progress, batchSize, &workerWG)
+		go c.optimizedWorker(ctx, i, batchChan, errorChan, progress, batchSize, retries, &workerWG)
 	}
 
-	// Start CSV parser
+	// Start CSV parser with its own context so it can be stopped when a worker fails, memoizing
+	// the dates it validates for the run
+	dates := newDateCache(c.dateUtils)
 	parserDone := make(chan error, 1)
+	parserCtx, cancelParser := context.WithCancel(ctx)
+	defer cancelParser()
 	parseStartTime := time.Now()
-	go c.parseOptimizedCSVStreaming(file, loadTestID, batchChan, parserDone, batchSize)
+	go c.parseOptimizedCSVStreaming(parserCtx, source, opts, loadTestID, batchChan, parserDone, batchSize, dates)
 
 	// Wait for parser
 	var parseErr error
@@ -503,6 +556,9 @@ func (c *OptimizedOnlyController) insertOptimizedStreaming(
 		}
 	case workerErr := <-errorChan:
 		if workerErr != nil {
+			// The parser must stop sending before the batch channel can be closed
+			cancelParser()
+			<-parserDone
 			close(batchChan)
 			progressDone <- true
 			return OptimizedTimingResult{}, fmt.Errorf("worker failed: %w", workerErr)
@@ -540,26 +596,37 @@ func (c *OptimizedOnlyController) insertOptimizedStreaming(
 	// Calculate actual insert time (total - parse time)
 	actualInsertTime := totalParseInsertTime - int(parseTime.Milliseconds())
 
+	dateCache := dates.Stats()
 	log.Info("optimized streaming insertion completed",
 		"totalRecords", progress.RecordsProcessed,
 		"parseTimeMs", parseTime.Milliseconds(),
-		"insertTimeMs", actualInsertTime)
+		"insertTimeMs", actualInsertTime,
+		"retries", retries.result().Total(),
+		"dateCacheHits", dateCache.Hits,
+		"dateCacheMisses", dateCache.Misses)
 
 	return OptimizedTimingResult{
-		ParseTime:  int(parseTime.Milliseconds()),
-		InsertTime: actualInsertTime,
+		ParseTime:        int(parseTime.Milliseconds()),
+		InsertTime:       actualInsertTime,
+		RecordsProcessed: progress.RecordsProcessed,
+		BatchRetries:     retries.result(),
+		DateCache:        dateCache,
 	}, nil
 }
 
 // parseOptimizedCSVStreaming parses CSV for optimized method
 func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
-	file *os.File,
+	ctx context.Context,
+	input io.Reader,
+	opts *ImportOptions,
 	loadTestID uuid.UUID,
 	batchChan chan<- *BatchData,
 	done chan<- error,
 	batchSize int,
+	dates *utils.DateCache,
 ) {
-	reader := csv.NewReader(file)
+	reader := opts.recordReader(input)
+	rejections := opts.rejections()
 
 	// Read header
 	headers, err := reader.Read()
@@ -567,10 +634,11 @@ func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
 		done <- fmt.Errorf("failed to read CSV headers: %w", err)
 		return
 	}
+	fields := opts.resolveFields(headers)
 
-	// Create setters for optimized parsing
+	// Create setters for optimized parsing, keyed by the canonical field for each header
 	setters := make([]func(td *TestData, val string), len(headers))
-	for i, header := range headers {
+	for i, header := range fields {
 		switch header {
 		case "first_name":
 			setters[i] = func(td *TestData, val string) { td.FirstName = &val }
@@ -612,21 +680,15 @@ func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
 			setters[i] = func(td *TestData, val string) { td.GroupNumber = &val }
 		case "member_id":
 			setters[i] = func(td *TestData, val string) { td.MemberID = &val }
+		case DATE_OFFSETS_FIELD:
+			setters[i] = func(td *TestData, val string) { td.DateOffsets = &val }
 		case "birth_date", "start_date", "end_date":
 			h := header
 			setters[i] = func(td *TestData, val string) {
 				if val != "" {
-					validationResult := c.dateUtils.GetValidator().ValidateAndConvert(val)
+					validationResult := dates.ValidateAndConvert(val)
 					if validationResult.IsValid {
-						dateStr := validationResult.ParsedTime.Format("2006-01-02")
-						switch h {
-						case "birth_date":
-							td.BirthDate = &dateStr
-						case "start_date":
-							td.StartDate = &dateStr
-						case "end_date":
-							td.EndDate = &dateStr
-						}
+						setNormalizedDate(td, h, validationResult)
 					}
 				}
 			}
@@ -639,7 +701,12 @@ func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
 	batchNum := 0
 
 	for {
-		row, err := reader.Read()
+		if err := ctx.Err(); err != nil {
+			done <- fmt.Errorf("CSV parsing cancelled: %w", err)
+			return
+		}
+
+		row, line, err := rejections.nextRecord(ctx, reader)
 		if err != nil {
 			if err.Error() == "EOF" {
 				break
@@ -660,6 +727,15 @@ func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
 			}
 		}
 
+		// Uploaded rows with structural problems or unparseable dates are rejected rather than inserted
+		if rejections != nil {
+			issues := append(checkRowStructure(row, fields), dateFieldIssues(row, fields, testData)...)
+			if len(issues) > 0 {
+				rejections.RejectRow(ctx, line, issues, row)
+				continue
+			}
+		}
+
 		currentBatch = append(currentBatch, testData)
 
 		// Send batch when full
@@ -668,7 +744,12 @@ func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
 				Records:  currentBatch,
 				BatchNum: batchNum,
 			}
-			batchChan <- batchData
+			select {
+			case batchChan <- batchData:
+			case <-ctx.Done():
+				done <- fmt.Errorf("CSV parsing cancelled: %w", ctx.Err())
+				return
+			}
 			currentBatch = make([]*TestData, 0, batchSize)
 			batchNum++
 		}
@@ -680,7 +761,12 @@ func (c *OptimizedOnlyController) parseOptimizedCSVStreaming(
 			Records:  currentBatch,
 			BatchNum: batchNum,
 		}
-		batchChan <- batchData
+		select {
+		case batchChan <- batchData:
+		case <-ctx.Done():
+			done <- fmt.Errorf("CSV parsing cancelled: %w", ctx.Err())
+			return
+		}
 	}
 
 	done <- nil
@@ -694,16 +780,20 @@ func (c *OptimizedOnlyController) optimizedWorker(
 	errorChan chan<- error,
 	progress *Progress,
 	batchSize int,
+	retries *retryTracker,
 	wg *sync.WaitGroup,
 ) {
 	defer wg.Done()
 
 	for batch := range batchChan {
-		// Use GORM batch insert optimized for this method
-		db := c.db.SQLWithContext(ctx)
-		err := db.CreateInBatches(batch.Records, batchSize).Error
+		// Use GORM batch insert optimized for this method, retrying transient failures such as deadlocks
+		retried, err := c.retryPolicy.Run(ctx, func() error {
+			return c.db.SQLWithContext(ctx).CreateInBatches(batch.Records, batchSize).Error
+		})
+		retries.record(batch.BatchNum, retried)
 		if err != nil {
-			errorChan <- fmt.Errorf("optimized worker %d failed to insert batch %d: %w", workerID, batch.BatchNum, err)
+			errorChan <- fmt.Errorf("optimized worker %d failed to insert batch %d after %d retries: %w",
+				workerID, batch.BatchNum, retried, err)
 			return
 		}
 
@@ -734,7 +824,7 @@ func (c *OptimizedOnlyController) monitorOptimizedProgress(
 			progress.mu.RLock()
 
 			elapsed := time.Since(progress.StartTime)
-			overallProgress := float64(progress.RecordsProcessed) / float64(progress.TotalRecords)
+			overallProgress := progress.fraction()
 			phaseProgress := overallProgress * 100
 
 			var eta string
@@ -776,24 +866,18 @@ func (c *OptimizedOnlyController) monitorOptimizedProgress(
 	}
 }
 
-// updateLoadTestError updates load test with error information for optimized method
+// updateLoadTestError marks the load test as failed, or cancelled, and notifies clients
 func (c *OptimizedOnlyController) updateLoadTestError(ctx context.Context, loadTest *LoadTest, message string, err error) {
-	log := c.log.Function("updateLoadTestError")
-	
-	errorMsg := message + ": " + err.Error()
-	loadTest.Status = "failed"
-	loadTest.ErrorMessage = &errorMsg
-	
-	if updateErr := c.loadTestRepo.Update(ctx, loadTest); updateErr != nil {
-		_ = log.Err("failed to update load test error", updateErr, "loadTestId", loadTest.ID)
-	}
-	
-	_ = log.Err(message, err, "loadTestId", loadTest.ID)
+	recordRunFailure(ctx, c.loadTestRepo, c.wsManager, c.log.Function("updateLoadTestError"), loadTest, message, err)
 }
 
 // GetLoadTestByID retrieves a load test by ID
 func (c *OptimizedOnlyController) GetLoadTestByID(ctx context.Context, id string) (*LoadTest, error) {
-	return c.loadTestRepo.GetByID(ctx, id)
+	loadTest, err := c.loadTestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.queue.withPosition(ctx, loadTest), nil
 }
 
 // GetAllLoadTests retrieves all load tests
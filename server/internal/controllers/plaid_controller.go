@@ -3,13 +3,16 @@ package controllers
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
 	"server/config"
+	"server/internal/database"
 	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/repositories"
+	"server/internal/utils"
 	"sync"
 	"time"
 
@@ -18,11 +21,25 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// plaidBatchSize is the number of rows copied and committed per transaction, which is also the
+// granularity of resume checkpoints
+const plaidBatchSize = 10000
+
+// plaidBatch is a run of parsed rows copied by a single worker transaction
+type plaidBatch struct {
+	Num     int
+	Records [][]interface{}
+	Mark    batchMark
+}
+
 // PlaidTimingResult holds the timing metrics for the operation.
 type PlaidTimingResult struct {
-	ParseTime  int
-	InsertTime int
-	TotalTime  int
+	ParseTime        int
+	InsertTime       int
+	TotalTime        int
+	RecordsProcessed int
+	BatchRetries     BatchRetries
+	DateCache        utils.DateCacheStats
 }
 
 // PlaidController manages the database operations.
@@ -31,6 +48,8 @@ type PlaidController struct {
 	config    config.Config
 	wsManager WSManager
 	db        *sql.DB
+	dateUtils *utils.DateUtils
+	retryPolicy database.RetryPolicy
 }
 
 // NewPlaidController creates a new PlaidController instance.
@@ -59,6 +78,8 @@ func NewPlaidController(config config.Config, wsManager WSManager) (*PlaidContro
 		config:    config,
 		wsManager: wsManager,
 		db:        db,
+		dateUtils: newDateColumnUtils(),
+		retryPolicy: database.RetryPolicyFor(config, "plaid"),
 	}, nil
 }
 
@@ -76,6 +97,7 @@ func (c *PlaidController) RunPlaidCopy(
 	csvPath string,
 	loadTestID uuid.UUID,
 	totalRecords int,
+	opts *ImportOptions,
 ) (PlaidTimingResult, error) {
 	file, err := os.Open(csvPath)
 	if err != nil {
@@ -83,12 +105,43 @@ func (c *PlaidController) RunPlaidCopy(
 	}
 	defer file.Close()
 
+	source, err := decompressedSource(file, 0)
+	if err != nil {
+		return PlaidTimingResult{}, err
+	}
+	defer source.Close()
+
 	timingResult, err := c.executeConcurrentStreamingCopy(
 		ctx,
-		file,
+		source,
 		loadTestID,
 		totalRecords,
 		loadTestID.String(),
+		nil,
+		opts,
+	)
+	if err != nil {
+		return PlaidTimingResult{}, fmt.Errorf("concurrent streaming COPY failed: %w", err)
+	}
+
+	return timingResult, nil
+}
+
+// RunPlaidCopyFromReader runs the concurrent copy operation directly from a streamed upload.
+func (c *PlaidController) RunPlaidCopyFromReader(
+	ctx context.Context,
+	source *utils.ProgressReader,
+	loadTestID uuid.UUID,
+	opts *ImportOptions,
+) (PlaidTimingResult, error) {
+	timingResult, err := c.executeConcurrentStreamingCopy(
+		ctx,
+		source,
+		loadTestID,
+		0,
+		loadTestID.String(),
+		source,
+		opts,
 	)
 	if err != nil {
 		return PlaidTimingResult{}, fmt.Errorf("concurrent streaming COPY failed: %w", err)
@@ -103,26 +156,38 @@ func (c *PlaidController) RunPlaidCopy(
 // utilize multiple CPU cores and database connections.
 func (c *PlaidController) executeConcurrentStreamingCopy(
 	ctx context.Context,
-	file *os.File,
+	input io.Reader,
 	loadTestID uuid.UUID,
 	totalRecords int,
 	testID string,
+	source *utils.ProgressReader,
+	opts *ImportOptions,
 ) (PlaidTimingResult, error) {
-	// Pre-defined columns for the database table.
-	dbColumns := []string{
-		"load_test_id", "birth_date", "start_date", "end_date",
-		"first_name", "last_name", "email", "phone", "address_line1", "address_line2",
-		"city", "state", "zip_code", "country", "social_security_no",
-		"employer", "job_title", "department", "salary",
-		"insurance_plan_id", "insurance_carrier", "policy_number",
-		"group_number", "member_id",
+	// Columns for the database table, derived from the canonical field order:
+	// load_test_id, the three date fields, the meaningful fields, the date offsets sidecar, then
+	// the precision of each date, which is set along with the date.
+	fields := TestDataImportFields()
+	dbColumns := make([]string, 0, len(fields)+1)
+	dbColumns = append(dbColumns, "load_test_id")
+	for _, field := range fields {
+		dbColumns = append(dbColumns, TestDataColumn(field))
 	}
+	firstPrecision := len(dbColumns) - len(TestDataDateFields)
+
+	// Dates outside the fast paths are validated once per distinct value for the run
+	dates := newDateCache(c.dateUtils)
 
 	// Producer-consumer pattern setup
 	var wg sync.WaitGroup
-	// Channel to send parsed rows to the workers. Using a buffered channel prevents the producer from blocking.
-	recordsChan := make(chan []interface{}, 1000)
+	numWorkers := runtime.NumCPU()
+	checkpoint := opts.checkpoint()
+	batchSize := checkpoint.batchSize(plaidBatchSize)
+	// Channel to send parsed batches to the workers. Each batch is copied and committed on its own
+	// so progress can be checkpointed; the buffer keeps every worker busy without holding the whole file.
+	batchChan := make(chan *plaidBatch, numWorkers)
 	errChan := make(chan error, 1)
+	retries := newRetryTracker()
+	upsert := opts.upsert()
 
 	// Timing variables - use wall-clock time instead of summed worker time
 	parseStartTime := time.Now()
@@ -132,7 +197,6 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 	// ------------------
 	// Consumer (Worker) Goroutines
 	// ------------------
-	numWorkers := runtime.NumCPU()
 	wg.Add(numWorkers)
 	for i := 0; i < numWorkers; i++ {
 		go func(workerID int) {
@@ -145,56 +209,41 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 				}
 				return
 			}
-			defer workerDB.Close()
-
-			tx, err := workerDB.BeginTx(ctx, nil)
-			if err != nil {
-				select {
-				case errChan <- fmt.Errorf("worker %d failed to begin transaction: %w", workerID, err):
-				default:
-				}
-				return
-			}
-			defer tx.Rollback() // Rollback on return unless commit is successful
-
-			// Using a dedicated statement for each worker's transaction.
-			stmt, err := tx.PrepareContext(ctx, pq.CopyIn("test_data", dbColumns...))
-			if err != nil {
-				select {
-				case errChan <- fmt.Errorf("worker %d failed to prepare COPY statement: %w", workerID, err):
-				default:
+			defer func() {
+				if workerDB != nil {
+					workerDB.Close()
 				}
-				return
-			}
-			defer stmt.Close()
-
-			for record := range recordsChan {
-				_, err := stmt.Exec(record...)
+			}()
+
+			for batch := range batchChan {
+				// Transient failures are retried, replacing the worker's connection if it was lost
+				retried, err := c.retryPolicy.Run(ctx, func() error {
+					if workerDB == nil {
+						conn, err := c.db.Conn(ctx)
+						if err != nil {
+							return err
+						}
+						workerDB = conn
+					}
+					counts, err := c.copyBatch(ctx, workerDB, fields, dbColumns, batch, checkpoint, upsert)
+					if database.IsLostConnection(err) {
+						workerDB.Close()
+						workerDB = nil
+					}
+					if err == nil {
+						upsert.record(counts)
+					}
+					return err
+				})
+				retries.record(batch.Num, retried)
 				if err != nil {
 					select {
-					case errChan <- fmt.Errorf("worker %d failed to execute COPY: %w", workerID, err):
+					case errChan <- fmt.Errorf("worker %d failed to copy batch %d: %w", workerID, batch.Num, err):
 					default:
 					}
 					return
 				}
-			}
-
-			// Finalize the COPY operation.
-			_, err = stmt.Exec()
-			if err != nil {
-				select {
-				case errChan <- fmt.Errorf("worker %d failed to finalize COPY operation: %w", workerID, err):
-				default:
-				}
-				return
-			}
-
-			if err := tx.Commit(); err != nil {
-				select {
-				case errChan <- fmt.Errorf("worker %d failed to commit transaction: %w", workerID, err):
-				default:
-				}
-				return
+				checkpoint.commit(ctx, batch.Num, batch.Mark)
 			}
 		}(i)
 	}
@@ -202,49 +251,100 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 	// ------------------
 	// Producer (Main) Goroutine
 	// ------------------
-	reader := csv.NewReader(file)
-	headers, err := reader.Read()
+	reader := opts.recordReader(input)
+	rejections := opts.rejections()
+	headers, err := checkpoint.readHeaders(reader)
 	if err != nil {
-		close(recordsChan)
+		close(batchChan)
 		return PlaidTimingResult{}, fmt.Errorf("failed to read CSV headers: %w", err)
 	}
+	resolvedFields := opts.resolveFields(headers)
 
-	// Column mapping: Map the source CSV header names to their index in the target DB columns slice.
+	// Column mapping: Map the canonical field resolved for each source header to its index.
 	// This is done once to avoid repeated map lookups inside the loop.
 	headerIndexMap := make(map[string]int, len(headers))
-	for i, h := range headers {
-		headerIndexMap[h] = i
+	for i, field := range resolvedFields {
+		if field != "" {
+			headerIndexMap[field] = i
+		}
 	}
 
 	// Create a fast mapping from dbColumns to the CSV record's index
 	columnMapping := make([]int, len(dbColumns))
-	for i, col := range dbColumns {
-		if idx, ok := headerIndexMap[col]; ok {
-			columnMapping[i] = idx
+	columnMapping[0] = -1 // 'load_test_id' is not read from the CSV
+	for i, field := range fields {
+		if idx, ok := headerIndexMap[field]; ok {
+			columnMapping[i+1] = idx
 		} else {
-			// This handles columns that might not exist in the CSV, e.g., 'load_test_id'.
-			columnMapping[i] = -1
+			// This handles fields that do not exist in the CSV
+			columnMapping[i+1] = -1
 		}
 	}
 
-	rowCount := 0
+	// The producer stops when the run is cancelled or when every worker has exited early
+	producerCtx, stopProducer := context.WithCancel(ctx)
+	defer stopProducer()
+	producerDone := make(chan struct{})
+
+	rowCount := checkpoint.rowsCommitted()
 	var parseEndTime time.Time
 	go func() {
-		defer close(recordsChan)
+		defer close(producerDone)
+		defer close(batchChan)
+		batchNum := checkpoint.firstBatch()
+		batch := make([][]interface{}, 0, batchSize)
+		lastLine := 0
+
+		// stop reports why the producer gave up unless a worker already reported an error
+		stop := func(err error) {
+			select {
+			case errChan <- err:
+			default:
+			}
+		}
+
+		// dispatch hands a full batch to the workers unless an earlier run already committed it
+		dispatch := func(mark batchMark) bool {
+			if checkpoint.skipBatch(ctx, batchNum, mark) {
+				rowCount -= len(batch)
+			} else {
+				select {
+				case batchChan <- &plaidBatch{Num: batchNum, Records: batch, Mark: mark}:
+				case <-producerCtx.Done():
+					stop(fmt.Errorf("COPY cancelled: %w", producerCtx.Err()))
+					return false
+				}
+			}
+			batch = make([][]interface{}, 0, batchSize)
+			batchNum++
+			return true
+		}
+
 		for {
-			csvRecord, err := reader.Read()
+			if err := producerCtx.Err(); err != nil {
+				stop(fmt.Errorf("COPY cancelled: %w", err))
+				return
+			}
+
+			csvRecord, line, err := rejections.nextRecord(ctx, reader)
 			if err == io.EOF {
+				if len(batch) > 0 && !dispatch(checkpoint.endOfInput(reader, lastLine, len(batch))) {
+					return
+				}
 				parseEndTime = time.Now()
 				break
 			}
 			if err != nil {
-				select {
-				case errChan <- fmt.Errorf("failed to read CSV row: %w", err):
-				default:
-				}
+				stop(fmt.Errorf("failed to read CSV row: %w", err))
 				return
 			}
 
+			// Uploaded rows with structural problems or unparseable dates are rejected rather than copied
+			var issues []rowIssue
+			if rejections != nil {
+				issues = checkRowStructure(csvRecord, resolvedFields)
+			}
+
 			// Create the target record with a single pass, using the pre-calculated mapping
 			record := make([]interface{}, len(dbColumns))
 			for i, csvIndex := range columnMapping {
@@ -253,11 +353,22 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 					record[i] = loadTestID.String()
 				case 1, 2, 3: // "birth_date", "start_date", "end_date"
 					if csvIndex != -1 && csvIndex < len(csvRecord) {
-						record[i] = c.getNormalizedDateOrNull(csvRecord[csvIndex])
+						record[i], record[firstPrecision+i-1] = c.getNormalizedDateOrNull(dates, csvRecord[csvIndex])
+						if rejections != nil && record[i] == nil && csvRecord[csvIndex] != "" {
+							issues = append(issues, rowIssue{
+								Column:   fields[i-1],
+								RawValue: csvRecord[csvIndex],
+								Reason:   IMPORT_ERROR_INVALID_DATE,
+								Message:  "unrecognized date format",
+							})
+						}
 					} else {
 						record[i] = nil
 					}
 				default: // All other columns
+					if i >= firstPrecision {
+						continue // Set along with the dates
+					}
 					if csvIndex != -1 && csvIndex < len(csvRecord) {
 						record[i] = c.getValueOrNull(csvRecord[csvIndex])
 					} else {
@@ -266,17 +377,26 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 				}
 			}
 
-			recordsChan <- record
+			lastLine = line
+			if len(issues) > 0 {
+				rejections.RejectRow(ctx, line, issues, csvRecord)
+				continue
+			}
+
+			batch = append(batch, record)
 			rowCount++
+			if len(batch) >= batchSize && !dispatch(checkpoint.mark(reader, csvRecord, len(batch))) {
+				return
+			}
 
 			if time.Since(lastUpdateTime) > 2*time.Second {
 				elapsed := time.Since(startTime)
 				rowsPerSecond := int(float64(rowCount) / elapsed.Seconds())
-				progress := float64(rowCount) / float64(totalRecords) * 100
+				progress := streamFraction(rowCount, totalRecords, source) * 100
 				
 				// Calculate ETA
 				var eta string
-				if rowCount > 0 && rowsPerSecond > 0 {
+				if rowCount > 0 && rowsPerSecond > 0 && totalRecords > 0 {
 					remaining := totalRecords - rowCount
 					etaSeconds := remaining / rowsPerSecond
 					if etaSeconds < 60 {
@@ -309,9 +429,11 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 		}
 	}()
 
-	// Wait for all workers to finish
+	// Wait for all workers to finish, then release the producer in case the workers exited early
 	wg.Wait()
 	insertEndTime := time.Now()
+	stopProducer()
+	<-producerDone
 
 	// Check if any error occurred during the process
 	select {
@@ -326,12 +448,81 @@ func (c *PlaidController) executeConcurrentStreamingCopy(
 	totalTimeMs := int(insertEndTime.Sub(startTime).Milliseconds())
 
 	return PlaidTimingResult{
-		ParseTime:  parseTimeMs,
-		InsertTime: insertTimeMs,
-		TotalTime:  totalTimeMs,
+		ParseTime:        parseTimeMs,
+		InsertTime:       insertTimeMs,
+		TotalTime:        totalTimeMs,
+		RecordsProcessed: rowCount,
+		BatchRetries:     retries.result(),
+		DateCache:        dates.Stats(),
 	}, nil
 }
 
+// copyBatch streams one batch into test_data with COPY in its own transaction, recording it as
+// committed for the checkpoint in the same transaction. Upserts COPY into the session's staging
+// table and merge it into test_data on the natural key before committing.
+func (c *PlaidController) copyBatch(
+	ctx context.Context,
+	conn *sql.Conn,
+	fields []string,
+	dbColumns []string,
+	batch *plaidBatch,
+	checkpoint *checkpointTracker,
+	upsert *upsertImport,
+) (UpsertCounts, error) {
+	records := batch.Records
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on return unless commit is successful
+
+	table := "test_data"
+	if upsert != nil {
+		if _, err := tx.ExecContext(ctx, repositories.CREATE_TEST_DATA_UPSERT_STAGING); err != nil {
+			return UpsertCounts{}, fmt.Errorf("failed to create upsert staging table: %w", err)
+		}
+		table = repositories.TEST_DATA_UPSERT_STAGING
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, dbColumns...))
+	if err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to prepare COPY statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, record := range records {
+		if _, err := stmt.ExecContext(ctx, record...); err != nil {
+			return UpsertCounts{}, fmt.Errorf("failed to execute COPY: %w", err)
+		}
+	}
+
+	// Finalize the COPY operation.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to finalize COPY operation: %w", err)
+	}
+
+	var counts UpsertCounts
+	if upsert != nil {
+		rows, err := tx.QueryContext(ctx, repositories.BuildTestDataUpsertFromStaging(fields, upsert.Columns))
+		if err != nil {
+			return UpsertCounts{}, fmt.Errorf("failed to upsert staged rows: %w", err)
+		}
+		if counts, err = repositories.CountUpserted(rows, len(records)); err != nil {
+			return UpsertCounts{}, fmt.Errorf("failed to read upserted rows: %w", err)
+		}
+	}
+
+	if err := checkpoint.recordBatch(ctx, tx, batch.Num, batch.Mark); err != nil {
+		return UpsertCounts{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpsertCounts{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return counts, nil
+}
+
 func (c *PlaidController) getValueOrNull(value string) interface{} {
 	if value != "" {
 		return value
@@ -339,14 +530,20 @@ func (c *PlaidController) getValueOrNull(value string) interface{} {
 	return nil
 }
 
-func (c *PlaidController) getNormalizedDateOrNull(value string) interface{} {
+// getNormalizedDateOrNull normalizes a date to its precision, returning the precision alongside
+func (c *PlaidController) getNormalizedDateOrNull(dates *utils.DateCache, value string) (interface{}, interface{}) {
 	if value != "" {
-		if t, err := time.Parse("2006-01-02", value); err == nil {
-			return t
+		day := string(utils.PrecisionDay)
+		if _, err := time.Parse("2006-01-02", value); err == nil {
+			return value, day
 		}
 		if t, err := time.Parse("01/02/2006", value); err == nil {
-			return t
+			return t.Format(time.DateOnly), day
+		}
+		// Fall back to the full validator for the less common formats
+		if result := dates.ValidateAndConvert(value); result.IsValid {
+			return result.Normalized(), string(result.Precision)
 		}
 	}
-	return nil
+	return nil, nil
 }
@@ -125,6 +125,35 @@ func (cb *CacheBuilder) Set() error {
 		Error()
 }
 
+// SetNX sets the value only if the key does not exist yet, reporting whether it was set
+func (cb *CacheBuilder) SetNX() (bool, error) {
+	if cb.err != nil {
+		return false, cb.err
+	}
+
+	ctx, cancel := cb.createTimeoutContext()
+	defer cancel()
+
+	if cb.key == "" {
+		return false, fmt.Errorf("key is required")
+	}
+
+	if cb.value == "" {
+		return false, fmt.Errorf("value is required")
+	}
+
+	err := cb.cache.Do(ctx, cb.cache.B().Set().Key(cb.key).Value(cb.value).Nx().Ex(cb.ttl).Build()).
+		Error()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (cb *CacheBuilder) Get(result any) (bool, error) {
 	if cb.err != nil {
 		return false, cb.err
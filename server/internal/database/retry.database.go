@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"server/config"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// RETRYABLE_SQLSTATE_PREFIXES are the SQLSTATE classes, or narrower code prefixes, that describe
+// transient failures worth retrying:
+//   - 08:  connection exceptions
+//   - 40:  transaction rollbacks, including deadlocks (40P01) and serialization failures (40001)
+//   - 53:  insufficient resources, such as too many connections (53300)
+//   - 57P: operator intervention that ends the session, such as admin shutdown (57P01)
+//
+// 57014 (query_canceled) is deliberately excluded because it is how a cancelled run surfaces.
+var RETRYABLE_SQLSTATE_PREFIXES = []string{"08", "40", "53", "57P"}
+
+// RetryPolicy controls how a worker retries a batch that failed with a transient
+// database error. Delays grow exponentially from BaseDelay up to MaxDelay with full jitter.
+type RetryPolicy struct {
+	MaxAttempts int // Total attempts per batch, including the first
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DEFAULT_RETRY_POLICIES holds the retry policy for each insertion method that retries batches
+var DEFAULT_RETRY_POLICIES = map[string]RetryPolicy{
+	"optimized": {MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"ludicrous": {MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"plaid":     {MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second},
+}
+
+// RetryPolicyFor returns the retry policy for an insertion method with any configured overrides
+func RetryPolicyFor(config config.Config, method string) RetryPolicy {
+	policy, ok := DEFAULT_RETRY_POLICIES[method]
+	if !ok {
+		policy = RetryPolicy{MaxAttempts: 1}
+	}
+
+	var maxAttempts int
+	switch method {
+	case "optimized":
+		maxAttempts = config.LoadTestRetryOptimizedMaxAttempts
+	case "ludicrous":
+		maxAttempts = config.LoadTestRetryLudicrousMaxAttempts
+	case "plaid":
+		maxAttempts = config.LoadTestRetryPlaidMaxAttempts
+	}
+	if maxAttempts > 0 {
+		policy.MaxAttempts = maxAttempts
+	}
+
+	if config.LoadTestRetryBaseDelayMs > 0 {
+		policy.BaseDelay = time.Duration(config.LoadTestRetryBaseDelayMs) * time.Millisecond
+	}
+	if config.LoadTestRetryMaxDelayMs > 0 {
+		policy.MaxDelay = time.Duration(config.LoadTestRetryMaxDelayMs) * time.Millisecond
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+
+	return policy
+}
+
+// Run calls attempt until it succeeds, fails with an error that is not transient, the attempts
+// are used up or ctx is done. It returns the number of retries made along with the last error.
+func (p RetryPolicy) Run(ctx context.Context, attempt func() error) (int, error) {
+	retries := 0
+	for {
+		err := attempt()
+		if err == nil || retries+1 >= p.MaxAttempts || !IsTransientDBError(err) {
+			return retries, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return retries, err
+		case <-time.After(p.backoff(retries)):
+		}
+		retries++
+	}
+}
+
+// backoff returns a random delay between zero and the exponential ceiling for the given retry
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay << retry
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// IsTransientDBError reports whether err is a database failure that may succeed when retried,
+// either by its SQLSTATE class or because the connection to the server was lost
+func IsTransientDBError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if code := sqlState(err); code != "" {
+		for _, prefix := range RETRYABLE_SQLSTATE_PREFIXES {
+			if strings.HasPrefix(code, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return isConnectionError(err)
+}
+
+// isConnectionError reports whether err means the connection broke rather than the statement failing
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr *net.OpError
+	return errors.As(err, &netErr)
+}
+
+// IsLostConnection reports whether err leaves the connection unusable, so a retry needs a new one
+func IsLostConnection(err error) bool {
+	if err == nil {
+		return false
+	}
+	code := sqlState(err)
+	return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "57P") || (code == "" && isConnectionError(err))
+}
+
+// sqlState extracts the SQLSTATE code from a lib/pq or pgx error
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+
+	return ""
+}
@@ -12,6 +12,12 @@ import (
 	"github.com/valkey-io/valkey-go"
 )
 
+// Channels used to coordinate load tests across instances
+const (
+	LOAD_TEST_CHANNEL        = "loadtest"
+	LOAD_TEST_CANCEL_CHANNEL = "loadtest.cancel"
+)
+
 type Event struct {
 	ID        string         `json:"id"`
 	Type      string         `json:"type"`
@@ -23,11 +29,15 @@ type Event struct {
 
 type EventHandler func(event Event) error
 
+// ORDERED_EVENT_BUFFER is how many events an ordered channel holds while its handlers catch up
+const ORDERED_EVENT_BUFFER = 256
+
 type EventBus struct {
 	client   valkey.Client
 	logger   logger.Logger
 	config   config.Config
 	handlers map[string][]EventHandler
+	ordered  map[string]chan Event // Queues of the channels subscribed with SubscribeOrdered
 	mutex    sync.RWMutex
 	ctx      context.Context
 	cancel   context.CancelFunc
@@ -41,6 +51,7 @@ func New(client valkey.Client, config config.Config) *EventBus {
 		logger:   logger.New("EventBus"),
 		config:   config,
 		handlers: make(map[string][]EventHandler),
+		ordered:  make(map[string]chan Event),
 		ctx:      ctx,
 		cancel:   cancel,
 	}
@@ -69,8 +80,21 @@ func (eb *EventBus) Publish(channel string, event Event) error {
 	ctx, cancel := context.WithTimeout(eb.ctx, 5*time.Second)
 	defer cancel()
 
+	eb.mutex.RLock()
+	queue, ordered := eb.ordered[channel]
+	eb.mutex.RUnlock()
+
 	err = eb.client.Do(ctx, eb.client.B().Publish().Channel(channel).Message(string(eventData)).Build()).
 		Error()
+	if err != nil && ordered {
+		// Clients of this instance still get the event, even though other instances miss it
+		log.Warn("failed to publish event to valkey, delivering it locally",
+			"channel", channel,
+			"eventID", event.ID,
+			"error", err)
+		eb.enqueue(queue, event)
+		return nil
+	}
 	if err != nil {
 		return log.Err(
 			"failed to publish event to valkey",
@@ -84,8 +108,11 @@ func (eb *EventBus) Publish(channel string, event Event) error {
 
 	log.Info("Event published", "channel", channel, "eventID", event.ID, "eventType", event.Type)
 
-	// Also notify local handlers
-	eb.notifyLocalHandlers(channel, event)
+	// Ordered channels are delivered locally by this instance's own subscription, like on every
+	// other instance, so each handler sees each event once and in order
+	if !ordered {
+		eb.notifyLocalHandlers(channel, event)
+	}
 
 	return nil
 }
@@ -94,17 +121,85 @@ func (eb *EventBus) Subscribe(channel string, handler EventHandler) error {
 	log := eb.logger.Function("Subscribe")
 
 	eb.mutex.Lock()
+	first := len(eb.handlers[channel]) == 0
 	eb.handlers[channel] = append(eb.handlers[channel], handler)
 	eb.mutex.Unlock()
 
 	log.Info("Handler subscribed to channel", "channel", channel)
 
 	// Start listening to this channel if it's the first handler
-	go eb.listenToChannel(channel)
+	if first {
+		go eb.listenToChannel(channel)
+	}
+
+	return nil
+}
+
+// SubscribeOrdered registers a handler for a channel whose events must be handled in the order
+// they were published, such as the progress of a load test. Events are handled one at a time on
+// a single goroutine per channel and only through the subscription, so a publishing instance does
+// not see its own events twice. If publishing fails, the event is still handled locally.
+func (eb *EventBus) SubscribeOrdered(channel string, handler EventHandler) error {
+	log := eb.logger.Function("SubscribeOrdered")
+
+	eb.mutex.Lock()
+	queue, exists := eb.ordered[channel]
+	if !exists {
+		queue = make(chan Event, ORDERED_EVENT_BUFFER)
+		eb.ordered[channel] = queue
+	}
+	eb.handlers[channel] = append(eb.handlers[channel], handler)
+	eb.mutex.Unlock()
+
+	log.Info("Handler subscribed to ordered channel", "channel", channel)
+
+	if !exists {
+		go eb.deliverOrdered(channel, queue)
+		go eb.listenToChannel(channel)
+	}
 
 	return nil
 }
 
+// enqueue adds an event to an ordered channel's queue, waiting while the queue is full
+func (eb *EventBus) enqueue(queue chan Event, event Event) {
+	select {
+	case queue <- event:
+	case <-eb.ctx.Done():
+	}
+}
+
+// deliverOrdered hands each queued event of an ordered channel to its handlers in turn
+func (eb *EventBus) deliverOrdered(channel string, queue chan Event) {
+	log := eb.logger.Function("deliverOrdered")
+
+	for {
+		select {
+		case <-eb.ctx.Done():
+			return
+		case event := <-queue:
+			eb.mutex.RLock()
+			handlers := eb.handlers[channel]
+			eb.mutex.RUnlock()
+
+			for i, handler := range handlers {
+				if err := handler(event); err != nil {
+					log.Er(
+						"handler failed",
+						err,
+						"channel",
+						channel,
+						"eventID",
+						event.ID,
+						"handlerIndex",
+						i,
+					)
+				}
+			}
+		}
+	}
+}
+
 func (eb *EventBus) notifyLocalHandlers(channel string, event Event) {
 	log := eb.logger.Function("notifyLocalHandlers")
 
@@ -161,6 +256,14 @@ func (eb *EventBus) listenToChannel(channel string) {
 				"eventType",
 				event.Type,
 			)
+
+			eb.mutex.RLock()
+			queue, ordered := eb.ordered[channel]
+			eb.mutex.RUnlock()
+			if ordered {
+				eb.enqueue(queue, event)
+				return
+			}
 			eb.notifyLocalHandlers(channel, event)
 		},
 	)
@@ -195,6 +298,23 @@ func (eb *EventBus) PublishUserLogout(userID string) error {
 	})
 }
 
+// PublishLoadTestEvent relays a load test notification to the websocket clients of every instance
+func (eb *EventBus) PublishLoadTestEvent(eventType string, data map[string]any) error {
+	return eb.Publish(LOAD_TEST_CHANNEL, Event{
+		Type: eventType,
+		Data: data,
+	})
+}
+
+// PublishLoadTestCancel asks whichever instance is running a load test to cancel it
+func (eb *EventBus) PublishLoadTestCancel(loadTestID string) error {
+	return eb.Publish(LOAD_TEST_CANCEL_CHANNEL, Event{
+		Type: "loadtest_cancel",
+		Data: map[string]any{
+			"loadTestId": loadTestID,
+		},
+	})
+}
 
 func (eb *EventBus) PublishCacheInvalidation(resourceType string, resourceID string, userIDs []string) error {
 	return eb.Publish("cache.invalidation", Event{
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"server/internal/app"
+	"server/internal/controllers"
+	"server/internal/logger"
+	. "server/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ColumnMappingHandler struct {
+	Handler
+	controller *controllers.ColumnMappingController
+}
+
+func NewColumnMappingHandler(app app.App, router fiber.Router) *ColumnMappingHandler {
+	log := logger.New("handlers").File("columnMapping_handler")
+	return &ColumnMappingHandler{
+		controller: app.ColumnMappingController,
+		Handler: Handler{
+			log:        log,
+			router:     router,
+			middleware: app.Middleware,
+		},
+	}
+}
+
+func (h *ColumnMappingHandler) Register() {
+	mappings := h.router.Group("/column-mappings")
+	mappings.Post("/", h.createProfile)
+	mappings.Get("/", h.getProfiles)
+	mappings.Get("/:id", h.getProfile)
+	mappings.Put("/:id", h.updateProfile)
+	mappings.Delete("/:id", h.deleteProfile)
+}
+
+func (h *ColumnMappingHandler) createProfile(c *fiber.Ctx) error {
+	log := h.log.Function("createProfile")
+
+	var request ColumnMappingProfileRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Er("failed to parse column mapping request", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to parse column mapping request"})
+	}
+
+	profile, err := h.controller.CreateProfile(c.Context(), &request)
+	if err != nil {
+		log.Er("failed to create column mapping profile", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to create column mapping profile", "error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "success", "profile": profile})
+}
+
+func (h *ColumnMappingHandler) getProfiles(c *fiber.Ctx) error {
+	log := h.log.Function("getProfiles")
+
+	profiles, err := h.controller.GetAllProfiles(c.Context())
+	if err != nil {
+		log.Er("failed to get column mapping profiles", err)
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"message": "failed to get column mapping profiles", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "profiles": profiles})
+}
+
+func (h *ColumnMappingHandler) getProfile(c *fiber.Ctx) error {
+	log := h.log.Function("getProfile")
+
+	profile, err := h.controller.GetProfileByID(c.Context(), c.Params("id"))
+	if err != nil {
+		log.Er("failed to get column mapping profile", err)
+		return c.Status(fiber.StatusNotFound).
+			JSON(fiber.Map{"message": "column mapping profile not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "profile": profile})
+}
+
+func (h *ColumnMappingHandler) updateProfile(c *fiber.Ctx) error {
+	log := h.log.Function("updateProfile")
+
+	var request ColumnMappingProfileRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Er("failed to parse column mapping request", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to parse column mapping request"})
+	}
+
+	profile, err := h.controller.UpdateProfile(c.Context(), c.Params("id"), &request)
+	if err != nil {
+		log.Er("failed to update column mapping profile", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to update column mapping profile", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "profile": profile})
+}
+
+func (h *ColumnMappingHandler) deleteProfile(c *fiber.Ctx) error {
+	log := h.log.Function("deleteProfile")
+
+	if err := h.controller.DeleteProfile(c.Context(), c.Params("id")); err != nil {
+		log.Er("failed to delete column mapping profile", err)
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"message": "failed to delete column mapping profile", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "success"})
+}
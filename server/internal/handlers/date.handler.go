@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"server/internal/app"
+	"server/internal/controllers"
+	"server/internal/logger"
+	. "server/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DateHandler struct {
+	Handler
+	controller *controllers.DateController
+}
+
+func NewDateHandler(app app.App, router fiber.Router) *DateHandler {
+	log := logger.New("handlers").File("date_handler")
+	return &DateHandler{
+		controller: app.DateController,
+		Handler: Handler{
+			log:        log,
+			router:     router,
+			middleware: app.Middleware,
+		},
+	}
+}
+
+func (h *DateHandler) Register() {
+	dates := h.router.Group("/dates")
+	dates.Post("/normalize", h.normalizeDates)
+	dates.Post("/detect", h.detectDates)
+}
+
+// normalizeDates reads a JSON sample of "values" the way an import would and writes each one in
+// the "outputFormat": ISO 8601 to its precision by default, "all" for every supported format, or
+// a supported layout such as "01/02/2006". "timezone", "dateOrder" and "locale" are read as for
+// imports. The response holds a result per value and a histogram of the formats found.
+func (h *DateHandler) normalizeDates(c *fiber.Ctx) error {
+	log := h.log.Function("normalizeDates")
+
+	var request DateBatchRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Er("failed to parse date normalize request", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to parse date normalize request"})
+	}
+
+	result, err := h.controller.Normalize(&request)
+	if err != nil {
+		log.Er("failed to normalize dates", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to normalize dates", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "dates": result})
+}
+
+// detectDates reads a JSON sample of "values" like normalizeDates, reporting the format,
+// precision and language of each value without converting it
+func (h *DateHandler) detectDates(c *fiber.Ctx) error {
+	log := h.log.Function("detectDates")
+
+	var request DateBatchRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Er("failed to parse date detect request", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to parse date detect request"})
+	}
+
+	result, err := h.controller.Detect(&request)
+	if err != nil {
+		log.Er("failed to detect dates", err)
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "failed to detect dates", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "dates": result})
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"server/internal/app"
+	"server/internal/controllers"
+	"server/internal/logger"
+	. "server/internal/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxImportFieldSize caps how much of a non-file form field is read into memory
+const maxImportFieldSize = 1024
+
+// maxIdempotencyKeySize caps the length of the Idempotency-Key header
+const maxIdempotencyKeySize = 255
+
+type ImportHandler struct {
+	Handler
+	controller *controllers.LoadTestController
+}
+
+func NewImportHandler(app app.App, router fiber.Router) *ImportHandler {
+	log := logger.New("handlers").File("import_handler")
+	return &ImportHandler{
+		controller: app.LoadTestController,
+		Handler: Handler{
+			log:        log,
+			router:     router,
+			middleware: app.Middleware,
+		},
+	}
+}
+
+func (h *ImportHandler) Register() {
+	imports := h.router.Group("/imports")
+	imports.Post("/", h.createImport)
+}
+
+// createImport streams a multipart upload into the import pipeline. Form fields must precede the
+// "file" part, which is spooled to disk rather than buffered; the fields are listed in the README.
+// A repeated file or Idempotency-Key returns the earlier load test with Idempotent-Replayed set.
+func (h *ImportHandler) createImport(c *fiber.Ctx) error {
+	log := h.log.Function("createImport")
+
+	boundary := string(c.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "expected a multipart/form-data request"})
+	}
+
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	reader := multipart.NewReader(body, boundary)
+
+	var request CreateImportRequest
+	request.IdempotencyKey = strings.TrimSpace(c.Get("Idempotency-Key"))
+	if len(request.IdempotencyKey) > maxIdempotencyKeySize {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"message": "Idempotency-Key must be at most 255 characters"})
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"message": "file is required"})
+		}
+		if err != nil {
+			log.Er("failed to read multipart section", err)
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"message": "failed to read upload", "error": err.Error()})
+		}
+
+		switch part.FormName() {
+		case "file":
+			request.FileName = part.FileName()
+			if request.Method == "" {
+				return c.Status(fiber.StatusBadRequest).
+					JSON(fiber.Map{"message": "method must be sent before the file"})
+			}
+
+			loadTest, err := h.controller.CreateAndRunImport(c.Context(), &request, part)
+			if errors.Is(err, controllers.ErrIdempotencyKeyReused) {
+				return c.Status(fiber.StatusUnprocessableEntity).
+					JSON(fiber.Map{"message": "Idempotency-Key was already used for a different file"})
+			}
+			if errors.Is(err, controllers.ErrUploadTooLarge) {
+				return c.Status(fiber.StatusRequestEntityTooLarge).
+					JSON(fiber.Map{"message": "upload is too large", "error": err.Error()})
+			}
+			if loadTest == nil {
+				log.Er("failed to create import", err)
+				return c.Status(fiber.StatusBadRequest).
+					JSON(fiber.Map{"message": "failed to create import", "error": err.Error()})
+			}
+			if err != nil {
+				log.Er("import failed", err, "loadTestId", loadTest.ID)
+				return c.Status(fiber.StatusUnprocessableEntity).
+					JSON(fiber.Map{"message": "import failed", "error": err.Error(), "loadTest": loadTest})
+			}
+
+			if loadTest.Replayed {
+				c.Set("Idempotent-Replayed", "true")
+			}
+			return c.JSON(fiber.Map{"message": "success", "loadTest": loadTest})
+		case "method", "mappingProfileId", "format", "encoding", "mode", "upsertKey",
+			"delimiter", "quote", "escape", "headerRow", "sheet",
+			"dateOrder", "timezone", "columnTimezones", "recordOffsets", "locale":
+			value, err := io.ReadAll(io.LimitReader(part, maxImportFieldSize))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).
+					JSON(fiber.Map{"message": "failed to read form field", "error": err.Error()})
+			}
+			setImportField(&request, part.FormName(), strings.TrimSpace(string(value)))
+		default:
+			// Unknown fields are drained and ignored
+			if _, err := io.Copy(io.Discard, part); err != nil {
+				return c.Status(fiber.StatusBadRequest).
+					JSON(fiber.Map{"message": "failed to read upload", "error": err.Error()})
+			}
+		}
+	}
+}
+
+// setImportField assigns a multipart form value to the matching import request field
+func setImportField(request *CreateImportRequest, name, value string) {
+	switch name {
+	case "method":
+		request.Method = value
+	case "mappingProfileId":
+		request.MappingProfileID = value
+	case "format":
+		request.Format = value
+	case "encoding":
+		request.Encoding = value
+	case "mode":
+		request.Mode = value
+	case "upsertKey":
+		request.UpsertKey = value
+	case "delimiter":
+		request.Delimiter = value
+	case "quote":
+		request.Quote = value
+	case "escape":
+		request.Escape = value
+	case "headerRow":
+		request.HeaderRow = value
+	case "sheet":
+		request.Sheet = value
+	case "dateOrder":
+		request.DateOrder = value
+	case "timezone":
+		request.Timezone = value
+	case "columnTimezones":
+		request.ColumnTimezones = value
+	case "recordOffsets":
+		request.RecordOffsets = value
+	case "locale":
+		request.Locale = value
+	}
+}
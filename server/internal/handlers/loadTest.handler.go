@@ -1,6 +1,13 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"server/internal/app"
 	loadTestController "server/internal/controllers"
 	"server/internal/logger"
@@ -9,6 +16,12 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// Pagination bounds for the import error listing
+const (
+	DEFAULT_IMPORT_ERRORS_LIMIT = 100
+	MAX_IMPORT_ERRORS_LIMIT     = 1000
+)
+
 type LoadTestHandler struct {
 	Handler
 	controller loadTestController.LoadTestController
@@ -32,6 +45,11 @@ func (h *LoadTestHandler) Register() {
 	loadTests.Get("/performance-summary", h.getPerformanceSummary)
 	loadTests.Get("/overall-summary", h.getOverallSummary)
 	loadTests.Get("/:id", h.getLoadTest)
+	loadTests.Get("/:id/errors", h.getImportErrors)
+	loadTests.Get("/:id/errors/download", h.downloadImportErrors)
+	loadTests.Get("/:id/fhir", h.exportFHIR)
+	loadTests.Post("/:id/resume", h.resumeLoadTest)
+	loadTests.Post("/:id/cancel", h.cancelLoadTest)
 	loadTests.Get("/", h.getLoadTests)
 }
 
@@ -74,6 +92,211 @@ func (h *LoadTestHandler) getLoadTest(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "success", "loadTest": loadTest})
 }
 
+func (h *LoadTestHandler) getImportErrors(c *fiber.Ctx) error {
+	log := h.log.Function("getImportErrors")
+
+	id := c.Params("id")
+	if _, err := h.controller.GetLoadTestByID(c.Context(), id); err != nil {
+		log.Er("failed to get load test", err)
+		return c.Status(fiber.StatusNotFound).
+			JSON(fiber.Map{"message": "load test not found"})
+	}
+
+	offset := max(c.QueryInt("offset", 0), 0)
+	limit := c.QueryInt("limit", DEFAULT_IMPORT_ERRORS_LIMIT)
+	if limit <= 0 || limit > MAX_IMPORT_ERRORS_LIMIT {
+		limit = MAX_IMPORT_ERRORS_LIMIT
+	}
+
+	importErrors, total, err := h.controller.GetImportErrors(c.Context(), id, offset, limit)
+	if err != nil {
+		log.Er("failed to get import errors", err, "loadTestId", id)
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"message": "failed to get import errors", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"errors":  importErrors,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
+
+// downloadImportErrors streams the rejected rows for a load test as a CSV attachment
+func (h *LoadTestHandler) downloadImportErrors(c *fiber.Ctx) error {
+	log := h.log.Function("downloadImportErrors")
+
+	id := c.Params("id")
+	if _, err := h.controller.GetLoadTestByID(c.Context(), id); err != nil {
+		log.Er("failed to get load test", err)
+		return c.Status(fiber.StatusNotFound).
+			JSON(fiber.Map{"message": "load test not found"})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="load-test-%s-errors.csv"`, id))
+
+	streamBody(c, func(ctx context.Context, w io.Writer) {
+		if err := h.controller.WriteImportErrorsCSV(ctx, id, w); err != nil {
+			log.Er("failed to stream import errors", err, "loadTestId", id)
+		}
+	})
+
+	return nil
+}
+
+// exportFHIR streams the test data of a load test as FHIR R4 Patient and Coverage resources in
+// newline-delimited JSON
+func (h *LoadTestHandler) exportFHIR(c *fiber.Ctx) error {
+	log := h.log.Function("exportFHIR")
+
+	id := c.Params("id")
+	if _, err := h.controller.GetLoadTestByID(c.Context(), id); err != nil {
+		log.Er("failed to get load test", err)
+		return c.Status(fiber.StatusNotFound).
+			JSON(fiber.Map{"message": "load test not found"})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/fhir+ndjson")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="load-test-%s.ndjson"`, id))
+
+	streamBody(c, func(ctx context.Context, w io.Writer) {
+		if err := h.controller.WriteFHIRExport(ctx, id, w); err != nil {
+			log.Er("failed to stream FHIR export", err, "loadTestId", id)
+		}
+	})
+
+	return nil
+}
+
+// resumeLoadTest continues an interrupted insertion from its checkpoint. Generated runs resume
+// from the CSV on disk; uploads must send the original file again as a multipart "file" part.
+func (h *LoadTestHandler) resumeLoadTest(c *fiber.Ctx) error {
+	log := h.log.Function("resumeLoadTest")
+
+	id := c.Params("id")
+	if _, err := h.controller.GetLoadTestByID(c.Context(), id); err != nil {
+		log.Er("failed to get load test", err)
+		return c.Status(fiber.StatusNotFound).
+			JSON(fiber.Map{"message": "load test not found"})
+	}
+
+	var input io.Reader
+	if boundary := string(c.Request().Header.MultipartFormBoundary()); boundary != "" {
+		body := c.Context().RequestBodyStream()
+		if body == nil {
+			body = bytes.NewReader(c.Body())
+		}
+
+		part, err := findFilePart(multipart.NewReader(body, boundary))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"message": "failed to read upload", "error": err.Error()})
+		}
+		input = part
+	}
+
+	loadTest, err := h.controller.ResumeLoadTest(
+		c.Context(),
+		id,
+		input,
+		int64(c.Request().Header.ContentLength()),
+	)
+	if err != nil {
+		log.Er("failed to resume load test", err, "loadTestId", id)
+
+		status := fiber.StatusInternalServerError
+		switch {
+		case loadTest != nil:
+			status = fiber.StatusUnprocessableEntity
+		case errors.Is(err, loadTestController.ErrCheckpointNotFound):
+			status = fiber.StatusNotFound
+		case errors.Is(err, loadTestController.ErrLoadTestActive):
+			status = fiber.StatusConflict
+		case errors.Is(err, loadTestController.ErrResumeNeedsUpload):
+			status = fiber.StatusBadRequest
+		}
+
+		return c.Status(status).
+			JSON(fiber.Map{"message": "failed to resume load test", "error": err.Error(), "loadTest": loadTest})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "loadTest": loadTest})
+}
+
+// cancelLoadTest stops a running load test. The run winds down in the background and reports the
+// cancelled status over the websocket once it has stopped.
+func (h *LoadTestHandler) cancelLoadTest(c *fiber.Ctx) error {
+	log := h.log.Function("cancelLoadTest")
+
+	id := c.Params("id")
+	loadTest, err := h.controller.CancelLoadTest(c.Context(), id)
+	if err != nil {
+		log.Er("failed to cancel load test", err, "loadTestId", id)
+
+		if loadTest == nil {
+			return c.Status(fiber.StatusNotFound).
+				JSON(fiber.Map{"message": "load test not found"})
+		}
+		return c.Status(fiber.StatusConflict).
+			JSON(fiber.Map{"message": "failed to cancel load test", "error": err.Error(), "loadTest": loadTest})
+	}
+
+	return c.JSON(fiber.Map{"message": "success", "loadTest": loadTest})
+}
+
+// findFilePart skips ahead to the "file" part of a multipart body, draining any other fields
+func findFilePart(reader *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is required")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		if _, err := io.Copy(io.Discard, part); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// streamBody sets write to produce the response body once the handler has returned. Its context
+// ends when the server shuts down or a write to the client fails, so a stream stops as soon as
+// its client disconnects instead of reading the rest of its rows for nobody.
+func streamBody(c *fiber.Ctx, write func(ctx context.Context, w io.Writer)) {
+	requestCtx := c.Context()
+	requestCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(requestCtx)
+		defer cancel()
+		write(ctx, &clientWriter{writer: w, cancel: cancel})
+	})
+}
+
+// clientWriter flushes every write through to the client, cancelling the stream on the first
+// one that fails
+type clientWriter struct {
+	writer *bufio.Writer
+	cancel context.CancelFunc
+}
+
+func (w *clientWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if err == nil {
+		err = w.writer.Flush()
+	}
+	if err != nil {
+		w.cancel()
+	}
+	return n, err
+}
+
 func (h *LoadTestHandler) getLoadTests(c *fiber.Ctx) error {
 	log := h.log.Function("getLoadTests")
 
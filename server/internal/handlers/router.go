@@ -24,6 +24,9 @@ func Router(router fiber.Router, app *app.App) (err error) {
 	NewLoadTestHandler(*app, api).Register()
 	NewOptimizedLoadTestHandler(*app, api).Register()
 	NewLudicrousLoadTestHandler(*app, api).Register()
+	NewImportHandler(*app, api).Register()
+	NewColumnMappingHandler(*app, api).Register()
+	NewDateHandler(*app, api).Register()
 
 	return nil
 }
@@ -0,0 +1,424 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ColumnMapping maps source columns onto a single canonical TestData field
+type ColumnMapping struct {
+	Field   string   `json:"field"`           // Canonical field name, e.g. "birth_date"
+	Aliases []string `json:"aliases"`         // Source header names, matched ignoring case and whitespace
+	Index   *int     `json:"index,omitempty"` // Zero-based source column position, takes precedence over aliases
+}
+
+// ColumnMappings is stored as a jsonb column
+type ColumnMappings []ColumnMapping
+
+func (m ColumnMappings) Value() (driver.Value, error) {
+	if m == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *ColumnMappings) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("unsupported column mappings type: %T", value)
+	}
+}
+
+// Array handling modes for FlattenRules
+const (
+	FLATTEN_ARRAYS_JSON  = "json"  // Arrays are kept whole as JSON text
+	FLATTEN_ARRAYS_INDEX = "index" // Elements are flattened under their index, e.g. "phones.0"
+	FLATTEN_ARRAYS_FIRST = "first" // Only the first element is kept, under the array's own key
+)
+
+// FlattenRules controls how nested objects in JSON records are flattened into named columns.
+// Nested keys are joined with Separator, so {"address": {"city": "Boise"}} yields "address.city",
+// which a mapping can then list as an alias.
+type FlattenRules struct {
+	Separator string `json:"separator,omitempty"` // Joins nested keys, "." by default
+	MaxDepth  int    `json:"maxDepth,omitempty"`  // Objects nested deeper are kept as JSON text, 0 for no limit
+	Arrays    string `json:"arrays,omitempty"`    // One of the FLATTEN_ARRAYS modes, "json" by default
+}
+
+func (r FlattenRules) Value() (driver.Value, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (r *FlattenRules) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*r = FlattenRules{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("unsupported flatten rules type: %T", value)
+	}
+}
+
+// Validate checks the array mode and depth limit
+func (r FlattenRules) Validate() error {
+	switch r.Arrays {
+	case "", FLATTEN_ARRAYS_JSON, FLATTEN_ARRAYS_INDEX, FLATTEN_ARRAYS_FIRST:
+	default:
+		return fmt.Errorf("unknown array flattening mode: %s", r.Arrays)
+	}
+	if r.MaxDepth < 0 {
+		return fmt.Errorf("invalid flattening depth %d", r.MaxDepth)
+	}
+	return nil
+}
+
+// Flatten calls emit with the flattened key and text of every value in object, in key order.
+// Nulls are skipped; numbers keep their source text when decoded with json.Decoder.UseNumber.
+func (r FlattenRules) Flatten(object map[string]any, emit func(key, value string)) {
+	r.flatten("", 0, object, emit)
+}
+
+func (r FlattenRules) flatten(key string, depth int, value any, emit func(key, value string)) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		if key != "" && r.MaxDepth > 0 && depth >= r.MaxDepth {
+			emit(key, jsonText(v))
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for child := range v {
+			keys = append(keys, child)
+		}
+		slices.Sort(keys)
+		for _, child := range keys {
+			r.flatten(r.join(key, child), depth+1, v[child], emit)
+		}
+	case []any:
+		switch r.Arrays {
+		case FLATTEN_ARRAYS_INDEX:
+			for i, element := range v {
+				r.flatten(r.join(key, strconv.Itoa(i)), depth+1, element, emit)
+			}
+		case FLATTEN_ARRAYS_FIRST:
+			if len(v) > 0 {
+				r.flatten(key, depth, v[0], emit)
+			}
+		default:
+			emit(key, jsonText(v))
+		}
+	case string:
+		emit(key, v)
+	case json.Number:
+		emit(key, v.String())
+	case bool:
+		emit(key, strconv.FormatBool(v))
+	default:
+		emit(key, fmt.Sprint(v))
+	}
+}
+
+func (r FlattenRules) join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if r.Separator == "" {
+		return prefix + "." + key
+	}
+	return prefix + r.Separator + key
+}
+
+func jsonText(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// Trim modes for FixedWidthField
+const (
+	FIXED_WIDTH_TRIM_BOTH  = "both"  // Padding is removed from both ends
+	FIXED_WIDTH_TRIM_LEFT  = "left"  // Padding is removed from the start, for right-aligned numbers
+	FIXED_WIDTH_TRIM_RIGHT = "right" // Padding is removed from the end, for left-aligned text
+	FIXED_WIDTH_TRIM_NONE  = "none"  // The value is kept exactly as it appears
+)
+
+// FixedWidthField locates one field in a fixed-width record. Positions count characters after
+// the source has been transcoded, which are bytes for the single-byte encodings mainframes export.
+type FixedWidthField struct {
+	Name   string `json:"name"`           // Source field name, resolved onto a TestData field like a header
+	Start  int    `json:"start"`          // One-based position of the first character
+	Length int    `json:"length"`         // Number of characters
+	Trim   string `json:"trim,omitempty"` // One of the FIXED_WIDTH_TRIM modes, "both" by default
+	Pad    string `json:"pad,omitempty"`  // Padding character removed by Trim, a space by default
+}
+
+// FixedWidthLayout lists the fields of a fixed-width record. It is stored as a jsonb column.
+type FixedWidthLayout []FixedWidthField
+
+func (l FixedWidthLayout) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (l *FixedWidthLayout) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return fmt.Errorf("unsupported fixed-width layout type: %T", value)
+	}
+}
+
+// Validate checks that every field has a name, a position and trim rules, and that no two
+// fields overlap
+func (l FixedWidthLayout) Validate() error {
+	names := make(map[string]bool, len(l))
+	for _, field := range l {
+		if strings.TrimSpace(field.Name) == "" {
+			return fmt.Errorf("fixed-width field at position %d has no name", field.Start)
+		}
+		if names[NormalizeHeader(field.Name)] {
+			return fmt.Errorf("fixed-width field defined more than once: %s", field.Name)
+		}
+		names[NormalizeHeader(field.Name)] = true
+
+		if field.Start < 1 || field.Length < 1 {
+			return fmt.Errorf(
+				"invalid position %d and length %d for fixed-width field %s",
+				field.Start,
+				field.Length,
+				field.Name,
+			)
+		}
+		switch field.Trim {
+		case "", FIXED_WIDTH_TRIM_BOTH, FIXED_WIDTH_TRIM_LEFT, FIXED_WIDTH_TRIM_RIGHT, FIXED_WIDTH_TRIM_NONE:
+		default:
+			return fmt.Errorf("unknown trim mode for fixed-width field %s: %s", field.Name, field.Trim)
+		}
+		if utf8.RuneCountInString(field.Pad) > 1 {
+			return fmt.Errorf("pad for fixed-width field %s must be a single character", field.Name)
+		}
+	}
+
+	sorted := slices.Clone(l)
+	slices.SortFunc(sorted, func(a, b FixedWidthField) int { return a.Start - b.Start })
+	for i := 1; i < len(sorted); i++ {
+		previous := sorted[i-1]
+		if sorted[i].Start < previous.Start+previous.Length {
+			return fmt.Errorf("fixed-width fields %s and %s overlap", previous.Name, sorted[i].Name)
+		}
+	}
+
+	return nil
+}
+
+// Names returns the field names in layout order, which serve as the headers of the source
+func (l FixedWidthLayout) Names() []string {
+	names := make([]string, len(l))
+	for i, field := range l {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// Split cuts a record into its field values in layout order. Fields that fall past the end of a
+// short record are empty, as transfers often strip trailing spaces.
+func (l FixedWidthLayout) Split(line string) []string {
+	chars := []rune(line)
+	values := make([]string, len(l))
+	for i, field := range l {
+		start := min(field.Start-1, len(chars))
+		end := min(start+field.Length, len(chars))
+		values[i] = field.trim(string(chars[start:end]))
+	}
+	return values
+}
+
+func (f FixedWidthField) trim(value string) string {
+	pad := f.Pad
+	if pad == "" {
+		pad = " "
+	}
+
+	switch f.Trim {
+	case FIXED_WIDTH_TRIM_NONE:
+		return value
+	case FIXED_WIDTH_TRIM_LEFT:
+		return strings.TrimLeft(value, pad)
+	case FIXED_WIDTH_TRIM_RIGHT:
+		return strings.TrimRight(value, pad)
+	default:
+		return strings.Trim(value, pad)
+	}
+}
+
+type ColumnMappingProfile struct {
+	BaseUUIDModel
+	Name        string           `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
+	Description *string          `gorm:"type:text"                              json:"description,omitempty"`
+	Mappings    ColumnMappings   `gorm:"type:jsonb;not null"                    json:"mappings"`
+	Flatten     FlattenRules     `gorm:"type:jsonb;not null;default:'{}'"       json:"flatten"`
+	Layout      FixedWidthLayout `gorm:"type:jsonb;not null;default:'[]'"       json:"layout"` // Field positions for fixed-width sources
+}
+
+type ColumnMappingProfileRequest struct {
+	Name        string           `json:"name"`
+	Description *string          `json:"description"`
+	Mappings    ColumnMappings   `json:"mappings"`
+	Flatten     FlattenRules     `json:"flatten"`
+	Layout      FixedWidthLayout `json:"layout"`
+}
+
+// NormalizeHeader lowercases a header and collapses whitespace, hyphens and dots to underscores
+// so that "Birth Date", "birth-date" and " BIRTH_DATE " all compare equal
+func NormalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+	header = strings.NewReplacer("-", " ", ".", " ", "_", " ").Replace(header)
+	return strings.Join(strings.Fields(header), "_")
+}
+
+// Validate checks that every mapping targets a known field and that positions are not reused
+func (p *ColumnMappingProfile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if err := p.Flatten.Validate(); err != nil {
+		return err
+	}
+	if err := p.Layout.Validate(); err != nil {
+		return err
+	}
+
+	fields := make(map[string]bool, len(p.Mappings))
+	indexes := make(map[int]string, len(p.Mappings))
+	for _, mapping := range p.Mappings {
+		if !IsTestDataField(mapping.Field) {
+			return fmt.Errorf("unknown field: %s", mapping.Field)
+		}
+		if fields[mapping.Field] {
+			return fmt.Errorf("field mapped more than once: %s", mapping.Field)
+		}
+		fields[mapping.Field] = true
+
+		if mapping.Index != nil {
+			if *mapping.Index < 0 {
+				return fmt.Errorf("invalid index %d for field %s", *mapping.Index, mapping.Field)
+			}
+			if existing, ok := indexes[*mapping.Index]; ok {
+				return fmt.Errorf(
+					"index %d mapped to both %s and %s",
+					*mapping.Index,
+					existing,
+					mapping.Field,
+				)
+			}
+			indexes[*mapping.Index] = mapping.Field
+		}
+	}
+
+	return nil
+}
+
+// ResolveHeaders returns the canonical TestData field for each source column, or "" when the
+// column is not mapped. Positional mappings win over aliases, and headers that already match a
+// canonical field name are always accepted. A nil profile only performs canonical matching.
+func (p *ColumnMappingProfile) ResolveHeaders(headers []string) []string {
+	fields := make([]string, len(headers))
+	assigned := make(map[string]bool, len(headers))
+
+	if p != nil {
+		for _, mapping := range p.Mappings {
+			if mapping.Index != nil && *mapping.Index < len(fields) {
+				fields[*mapping.Index] = mapping.Field
+				assigned[mapping.Field] = true
+			}
+		}
+	}
+
+	aliases := p.aliases()
+	for i, header := range headers {
+		if fields[i] != "" {
+			continue
+		}
+
+		field := resolveAlias(aliases, header)
+		if field != "" && !assigned[field] {
+			fields[i] = field
+			assigned[field] = true
+		}
+	}
+
+	return fields
+}
+
+// KeyResolver returns a function that maps a named source column, such as a flattened JSON key,
+// onto its canonical TestData field, or "" when it is not mapped. Positional mappings do not apply.
+func (p *ColumnMappingProfile) KeyResolver() func(key string) string {
+	aliases := p.aliases()
+	return func(key string) string {
+		return resolveAlias(aliases, key)
+	}
+}
+
+// aliases maps every normalized alias and field name of the profile onto its field
+func (p *ColumnMappingProfile) aliases() map[string]string {
+	aliases := make(map[string]string)
+	if p == nil {
+		return aliases
+	}
+	for _, mapping := range p.Mappings {
+		for _, alias := range mapping.Aliases {
+			aliases[NormalizeHeader(alias)] = mapping.Field
+		}
+		aliases[NormalizeHeader(mapping.Field)] = mapping.Field
+	}
+	return aliases
+}
+
+func resolveAlias(aliases map[string]string, header string) string {
+	normalized := NormalizeHeader(header)
+	if field, ok := aliases[normalized]; ok {
+		return field
+	}
+	if IsTestDataField(normalized) {
+		return normalized
+	}
+	return ""
+}
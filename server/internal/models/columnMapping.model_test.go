@@ -0,0 +1,169 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFixedWidthLayout_Split(t *testing.T) {
+	testCases := []struct {
+		name     string
+		layout   FixedWidthLayout
+		line     string
+		expected []string
+	}{
+		{
+			"trim both by default",
+			FixedWidthLayout{{Name: "a", Start: 1, Length: 5}, {Name: "b", Start: 6, Length: 5}},
+			" Ann  Lee ",
+			[]string{"Ann", "Lee"},
+		},
+		{
+			"trim left with zero padding",
+			FixedWidthLayout{{Name: "salary", Start: 1, Length: 7, Trim: FIXED_WIDTH_TRIM_LEFT, Pad: "0"}},
+			"0052000",
+			[]string{"52000"},
+		},
+		{
+			"trim right",
+			FixedWidthLayout{{Name: "name", Start: 1, Length: 6, Trim: FIXED_WIDTH_TRIM_RIGHT}},
+			"  Ann ",
+			[]string{"  Ann"},
+		},
+		{
+			"trim none",
+			FixedWidthLayout{{Name: "code", Start: 1, Length: 4, Trim: FIXED_WIDTH_TRIM_NONE}},
+			" A1 ",
+			[]string{" A1 "},
+		},
+		{
+			"gaps between fields are skipped",
+			FixedWidthLayout{{Name: "a", Start: 1, Length: 2}, {Name: "b", Start: 5, Length: 2}},
+			"AAxxBB",
+			[]string{"AA", "BB"},
+		},
+		{
+			"fields out of position order",
+			FixedWidthLayout{{Name: "b", Start: 3, Length: 2}, {Name: "a", Start: 1, Length: 2}},
+			"AABB",
+			[]string{"BB", "AA"},
+		},
+		{
+			"short record",
+			FixedWidthLayout{{Name: "a", Start: 1, Length: 3}, {Name: "b", Start: 4, Length: 3}, {Name: "c", Start: 7, Length: 3}},
+			"AAAB",
+			[]string{"AAA", "B", ""},
+		},
+		{
+			"positions count characters",
+			FixedWidthLayout{{Name: "a", Start: 1, Length: 6}, {Name: "b", Start: 7, Length: 2}},
+			"MüllerDE",
+			[]string{"Müller", "DE"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.layout.Split(tc.line)
+			if !slices.Equal(result, tc.expected) {
+				t.Errorf("Expected %q for %q, got %q", tc.expected, tc.line, result)
+			}
+		})
+	}
+}
+
+func TestFixedWidthLayout_Validate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		layout      FixedWidthLayout
+		expectedErr string
+	}{
+		{"empty", FixedWidthLayout{}, ""},
+		{
+			"valid",
+			FixedWidthLayout{
+				{Name: "member_id", Start: 1, Length: 6},
+				{Name: "salary", Start: 7, Length: 7, Trim: FIXED_WIDTH_TRIM_LEFT, Pad: "0"},
+			},
+			"",
+		},
+		{
+			"adjacent fields out of order",
+			FixedWidthLayout{{Name: "b", Start: 4, Length: 3}, {Name: "a", Start: 1, Length: 3}},
+			"",
+		},
+		{"missing name", FixedWidthLayout{{Name: " ", Start: 1, Length: 3}}, "has no name"},
+		{
+			"duplicate name",
+			FixedWidthLayout{{Name: "Member ID", Start: 1, Length: 3}, {Name: "member_id", Start: 4, Length: 3}},
+			"defined more than once",
+		},
+		{"zero start", FixedWidthLayout{{Name: "a", Start: 0, Length: 3}}, "invalid position"},
+		{"zero length", FixedWidthLayout{{Name: "a", Start: 1, Length: 0}}, "invalid position"},
+		{"unknown trim", FixedWidthLayout{{Name: "a", Start: 1, Length: 3, Trim: "middle"}}, "unknown trim mode"},
+		{"long pad", FixedWidthLayout{{Name: "a", Start: 1, Length: 3, Pad: "00"}}, "single character"},
+		{
+			"overlap",
+			FixedWidthLayout{{Name: "a", Start: 1, Length: 4}, {Name: "b", Start: 4, Length: 2}},
+			"overlap",
+		},
+		{
+			"overlap out of order",
+			FixedWidthLayout{{Name: "b", Start: 5, Length: 2}, {Name: "a", Start: 1, Length: 5}},
+			"overlap",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.layout.Validate()
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("Expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestColumnMappingProfile_ResolveHeaders(t *testing.T) {
+	profile := &ColumnMappingProfile{
+		Mappings: []ColumnMapping{{Field: "first_name", Aliases: []string{"Given Name"}}},
+	}
+
+	testCases := []struct {
+		name     string
+		profile  *ColumnMappingProfile
+		headers  []string
+		expected []string
+	}{
+		{"canonical", nil, []string{"First Name", "birth_date", "notes"}, []string{"first_name", "birth_date", ""}},
+		{"alias", profile, []string{"given name", "last_name"}, []string{"first_name", "last_name"}},
+		{"repeated field", nil, []string{"email", "Email"}, []string{"email", ""}},
+		{
+			"sidecars are not mapped",
+			nil,
+			[]string{"birth_date", "birth_date_precision", "date_offsets"},
+			[]string{"birth_date", "", ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.profile.ResolveHeaders(tc.headers)
+			if !slices.Equal(result, tc.expected) {
+				t.Errorf("Expected fields %q, got %q", tc.expected, result)
+			}
+		})
+	}
+
+	sidecar := &ColumnMappingProfile{Name: "sidecar", Mappings: []ColumnMapping{{Field: DATE_OFFSETS_FIELD}}}
+	if err := sidecar.Validate(); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("Expected an unknown field error, got %v", err)
+	}
+}
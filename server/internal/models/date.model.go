@@ -0,0 +1,20 @@
+package models
+
+// MAX_DATE_BATCH_VALUES caps the values read by one request to /api/dates
+const MAX_DATE_BATCH_VALUES = 10000
+
+// Output formats of /api/dates/normalize besides the supported layouts, such as "01/02/2006"
+const (
+	DATE_OUTPUT_ISO8601 = "iso8601" // ISO 8601 to the precision each value was written to, the default
+	DATE_OUTPUT_ALL     = "all"     // Every supported format as well
+)
+
+// DateBatchRequest is the body of /api/dates/normalize and /api/dates/detect, which read a sample
+// of values the way an import would
+type DateBatchRequest struct {
+	Values       []string `json:"values"`
+	Timezone     string   `json:"timezone"`     // IANA zone of times without an offset, e.g. "America/Chicago"; UTC when empty
+	DateOrder    string   `json:"dateOrder"`    // One of the DATE_ORDER modes; each value is read on its own when empty
+	Locale       string   `json:"locale"`       // Language of month and weekday names, e.g. "fr"; detected when empty
+	OutputFormat string   `json:"outputFormat"` // A DATE_OUTPUT mode or supported layout; only used by normalize
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord links an Idempotency-Key header or the SHA-256 of an uploaded file to the
+// load test it created, so a repeated submission returns that load test instead of importing again
+type IdempotencyRecord struct {
+	LoadTestID uuid.UUID `json:"loadTestId"`
+	Checksum   string    `json:"checksum"` // Hex-encoded SHA-256 of the upload
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reason codes recorded for rejected import rows
+const (
+	IMPORT_ERROR_INVALID_DATE    = "invalid_date"
+	IMPORT_ERROR_TOO_MANY_FIELDS = "too_many_fields"
+	IMPORT_ERROR_ENCODING        = "encoding_error"
+	IMPORT_ERROR_MALFORMED_ROW   = "malformed_row"
+)
+
+type ImportError struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuidv7()"             json:"id"`
+	LoadTestID uuid.UUID `gorm:"type:uuid;not null;index"                          json:"loadTestId"`
+	LineNumber int       `gorm:"not null"                                          json:"lineNumber"`
+	Column     *string   `gorm:"type:varchar(255)"                                 json:"column,omitempty"`
+	RawValue   *string   `gorm:"type:text"                                         json:"rawValue,omitempty"`
+	ReasonCode string    `gorm:"type:varchar(30);not null"                         json:"reasonCode"` // 'invalid_date', 'too_many_fields', 'encoding_error', 'malformed_row'
+	Message    string    `gorm:"type:text"                                         json:"message"`
+	RawRow     *string   `gorm:"type:text"                                         json:"rawRow,omitempty"` // Rejected row re-encoded as CSV
+	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;not null" json:"createdAt"`
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 	"github.com/google/uuid"
 )
@@ -8,24 +12,314 @@ import (
 type LoadTest struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuidv7()" json:"id"`
 	Rows         int       `gorm:"not null"                              json:"rows"`
+	RejectedRows int       `gorm:"not null;default:0"                    json:"rejectedRows"` // Rows recorded in import_errors
+	UnwrittenErrors int    `gorm:"not null;default:0"                    json:"unwrittenErrors"` // Rejection issues that failed to be written to import_errors
+	ReplacementRows int    `gorm:"not null;default:0"                    json:"replacementRows"` // Rows holding U+FFFD after transcoding
 	Columns      int       `gorm:"not null"                              json:"columns"`
 	DateColumns  int       `gorm:"not null"                              json:"dateColumns"` // Number of date columns populated (0-10)
 	Method       string    `gorm:"type:varchar(20);not null"             json:"method"`      // 'brute_force', 'batched', 'plaid', 'optimized', or 'ludicrous'
-	Status       string    `gorm:"type:varchar(20);not null"             json:"status"`      // 'running', 'completed', 'failed'
+	Status       string    `gorm:"type:varchar(20);not null"             json:"status"`      // 'queued', 'running', 'completed', 'failed', 'cancelled'
 	CSVGenTime   *int      `gorm:"type:int"                              json:"csvGenTime"`  // milliseconds
 	ParseTime    *int      `gorm:"type:int"                              json:"parseTime"`   // milliseconds
 	InsertTime   *int      `gorm:"type:int"                              json:"insertTime"`  // milliseconds
 	TotalTime    *int      `gorm:"type:int"                              json:"totalTime"`   // milliseconds
 	ErrorMessage *string   `gorm:"type:text"                             json:"errorMessage,omitempty"`
+	Retries      int       `gorm:"not null;default:0"                    json:"retries"` // Batch attempts repeated after transient database errors
+	BatchRetries BatchRetries `gorm:"type:jsonb"                         json:"batchRetries,omitempty"`
+	DateCacheHits int      `gorm:"not null;default:0"                    json:"dateCacheHits"` // Date values answered by the run's validation cache
+	DateCacheMisses int    `gorm:"not null;default:0"                    json:"dateCacheMisses"` // Date values the run validated
+	Source       string    `gorm:"type:varchar(20);not null;default:'generated'" json:"source"` // 'generated' or 'upload'
+	FileName     *string   `gorm:"type:varchar(255)"                     json:"fileName,omitempty"`
+	MappingProfileID *uuid.UUID `gorm:"type:uuid"                        json:"mappingProfileId,omitempty"`
+	Format       string    `gorm:"type:varchar(20);not null;default:'csv'" json:"format"` // 'csv', 'ndjson', 'fixed-width', 'x12' or 'xlsx'
+	Encoding     *string   `gorm:"type:varchar(20)"                      json:"encoding,omitempty"` // Source character encoding of uploads
+	Dialect      *CSVDialect `gorm:"type:jsonb"                          json:"dialect,omitempty"` // Layout of CSV uploads, sniffed or overridden
+	Sheet        *string   `gorm:"type:varchar(255)"                     json:"sheet,omitempty"` // Worksheet requested from XLSX uploads
+	EnvelopeErrors EnvelopeErrors `gorm:"type:jsonb"                     json:"envelopeErrors,omitempty"` // Envelope problems found in X12 uploads
+	DateOrder    *string   `gorm:"type:varchar(10)"                      json:"dateOrder,omitempty"` // One of the DATE_ORDER modes requested for uploads
+	DateOrders   DateOrders `gorm:"type:jsonb"                           json:"dateOrders,omitempty"` // Day and month order used for each date column
+	Timezone     *string   `gorm:"type:varchar(64)"                      json:"timezone,omitempty"` // IANA zone of upload times without an offset; UTC when empty
+	ColumnTimezones *string `gorm:"type:varchar(255)"                    json:"columnTimezones,omitempty"` // Per date field zones, e.g. "start_date=America/Chicago"
+	RecordOffsets bool     `gorm:"not null;default:false"                json:"recordOffsets"` // Original offsets are kept in test_data.date_offsets
+	Locale       *string   `gorm:"type:varchar(10)"                      json:"locale,omitempty"` // Language of month and weekday names in uploads; detected when empty
+	ImportMode   string    `gorm:"type:varchar(10);not null;default:'insert'" json:"importMode"` // 'insert' or 'upsert'
+	UpsertKey    *string   `gorm:"type:varchar(255)"                     json:"upsertKey,omitempty"` // Comma-separated natural key fields for upserts
+	InsertedRows int       `gorm:"not null;default:0"                    json:"insertedRows"`
+	UpdatedRows  int       `gorm:"not null;default:0"                    json:"updatedRows"`
+	UnchangedRows int      `gorm:"not null;default:0"                    json:"unchangedRows"`
 	CreatedAt    time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;not null" json:"createdAt"`
+	QueuePosition *int     `gorm:"-"                                     json:"queuePosition,omitempty"` // Set while queued
+	Replayed     bool      `gorm:"-"                                     json:"replayed,omitempty"` // Set when a repeated import returns this load test
+}
+
+// BatchRetries counts the repeated attempts of each batch that hit a transient database error,
+// keyed by batch number. It is stored as a jsonb column.
+type BatchRetries map[int]int
+
+// Total returns the number of repeated attempts across all batches
+func (r BatchRetries) Total() int {
+	total := 0
+	for _, retries := range r {
+		total += retries
+	}
+	return total
+}
+
+func (r BatchRetries) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (r *BatchRetries) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("unsupported batch retries type: %T", value)
+	}
+}
+
+// EnvelopeError describes an interchange, functional group or transaction set envelope of an X12
+// upload whose trailer does not match its header, or a segment found outside its envelope
+type EnvelopeError struct {
+	Segment       string `json:"segment"`                 // Segment ID, e.g. "SE"
+	Position      int    `json:"position"`                // One-based position of the segment in the file
+	ControlNumber string `json:"controlNumber,omitempty"` // Control number of the envelope
+	Message       string `json:"message"`
+}
+
+// EnvelopeErrors is stored as a jsonb column
+type EnvelopeErrors []EnvelopeError
+
+func (e EnvelopeErrors) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (e *EnvelopeErrors) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return fmt.Errorf("unsupported envelope errors type: %T", value)
+	}
+}
+
+// Date order modes of an import, deciding how numeric dates such as 03/04/2020 are read
+const (
+	DATE_ORDER_AUTO        = "auto" // Inferred per date column from a sample of its values
+	DATE_ORDER_MONTH_FIRST = "mdy"  // US order for every date column
+	DATE_ORDER_DAY_FIRST   = "dmy"  // European order for every date column
+)
+
+// ColumnDateOrder records the day and month order a date column was read with. Inferred orders
+// keep the evidence they were based on.
+type ColumnDateOrder struct {
+	Column     string `json:"column"`
+	Order      string `json:"order,omitempty"` // DATE_ORDER_MONTH_FIRST or DATE_ORDER_DAY_FIRST; empty when undecided
+	MonthFirst int    `json:"monthFirst"`      // Sampled values only valid with the month first
+	DayFirst   int    `json:"dayFirst"`        // Sampled values only valid with the day first
+	Undecided  int    `json:"undecided"`       // Sampled values valid in either order
+	Ambiguous  bool   `json:"ambiguous"`       // The order could not be inferred, so each value was read on its own
+}
+
+// DateOrders is stored as a jsonb column
+type DateOrders []ColumnDateOrder
+
+// Ambiguous returns the columns whose order could not be inferred
+func (d DateOrders) Ambiguous() []string {
+	var columns []string
+	for _, order := range d {
+		if order.Ambiguous {
+			columns = append(columns, order.Column)
+		}
+	}
+	return columns
+}
+
+func (d DateOrders) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (d *DateOrders) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("unsupported date orders type: %T", value)
+	}
+}
+
+// Import file formats
+const (
+	IMPORT_FORMAT_CSV         = "csv"
+	IMPORT_FORMAT_NDJSON      = "ndjson"      // Newline-delimited JSON, also known as JSON Lines
+	IMPORT_FORMAT_FIXED_WIDTH = "fixed-width" // Positional records laid out by a mapping profile's layout
+	IMPORT_FORMAT_X12         = "x12"         // ANSI X12 834 benefit enrollment, one row per member
+	IMPORT_FORMAT_XLSX        = "xlsx"        // Excel workbook, one worksheet per import
+)
+
+// Quote escaping styles of a CSVDialect
+const (
+	DIALECT_ESCAPE_DOUBLE    = "double"    // A quote inside a quoted field is doubled: "a ""b"""
+	DIALECT_ESCAPE_BACKSLASH = "backslash" // A quote inside a quoted field is backslashed: "a \"b\""
+)
+
+// CSVDialect describes how a delimited upload is laid out. It is sniffed from the start of the
+// file, and each part can be overridden on the import request. It is stored as a jsonb column.
+type CSVDialect struct {
+	Delimiter string `json:"delimiter"` // Field separator, e.g. ",", ";", "\t" or "|"
+	Quote     string `json:"quote"`     // Quote character, `"` or "'"
+	Escape    string `json:"escape"`    // One of the DIALECT_ESCAPE styles
+	HeaderRow int    `json:"headerRow"` // Zero-based line of the header row; lines above it are skipped
+}
+
+func (d CSVDialect) Value() (driver.Value, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (d *CSVDialect) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*d = CSVDialect{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("unsupported CSV dialect type: %T", value)
+	}
 }
 
 type CreateLoadTestRequest struct {
-	Rows   int    `json:"rows"   validate:"required,min=1"`
-	Method string `json:"method" validate:"required,oneof=brute_force batched plaid"`
+	Rows     int    `json:"rows"     validate:"required,min=1"`
+	Method   string `json:"method"   validate:"required,oneof=brute_force batched plaid"`
+	Priority int    `json:"priority"` // Higher priority tests leave the queue first
+	Locale   string `json:"locale"`   // Language of generated month and weekday names, e.g. "fr"
 	// Note: Columns and DateColumns are ignored - we use a fixed structure:
 	// - 5 date columns (birth_date, start_date, end_date, created_at, updated_at)
 	// - 20 regular columns (col1-col20)
 	// - Total: 25 columns
 }
 
+// CreateImportRequest holds the form fields sent alongside an uploaded file to /api/imports
+type CreateImportRequest struct {
+	Method           string `form:"method"           validate:"required,oneof=brute_force batched plaid optimized ludicrous"`
+	MappingProfileID string `form:"mappingProfileId"`
+	Format           string `form:"format"`    // One of the IMPORT_FORMAT constants; detected from the file when empty
+	Encoding         string `form:"encoding"`  // e.g. 'utf-8', 'utf-16le', 'windows-1252'; detected from the file when empty
+	Delimiter        string `form:"delimiter"` // Overrides the sniffed CSV dialect; "tab" selects a tab
+	Quote            string `form:"quote"`
+	Escape           string `form:"escape"`
+	HeaderRow        string `form:"headerRow"`       // Also the header row of XLSX worksheets
+	Sheet            string `form:"sheet"`           // XLSX worksheet name or one-based position; the first when empty
+	DateOrder        string `form:"dateOrder"`       // One of the DATE_ORDER modes; each value is read on its own when empty
+	Timezone         string `form:"timezone"`        // IANA zone of times without an offset, e.g. "America/Chicago"; UTC when empty
+	ColumnTimezones  string `form:"columnTimezones"` // Comma-separated field=zone overrides, e.g. "end_date=UTC"
+	RecordOffsets    string `form:"recordOffsets"`   // "true" keeps each date's original offset in date_offsets
+	Locale           string `form:"locale"`          // Language of month and weekday names, e.g. "fr" or "fr-CA"; detected when empty
+	Mode             string `form:"mode"`            // 'insert' (default) or 'upsert'
+	UpsertKey        string `form:"upsertKey"`       // Comma-separated natural key fields, e.g. "member_id,group_number"
+	FileName         string `form:"-"`
+	IdempotencyKey   string `form:"-"` // From the Idempotency-Key header
+}
+
+// LoadTestCheckpoint records how far a streaming insertion has durably progressed so an
+// interrupted run can be resumed. Batches commit out of order, so ByteOffset and BatchNumber
+// mark the end of the last contiguous committed batch and CommittedAhead lists any later
+// batches that were already committed.
+type LoadTestCheckpoint struct {
+	LoadTestID     uuid.UUID `json:"loadTestId"`
+	Method         string    `json:"method"`
+	FilePath       string    `json:"filePath,omitempty"` // Generated CSV on disk; empty for uploads, which must be re-sent
+	Headers        []string  `json:"headers"`
+	BatchSize      int       `json:"batchSize"`
+	ByteOffset     int64     `json:"byteOffset"`  // Offset just past the last contiguous committed batch
+	LineNumber     int       `json:"lineNumber"`  // Last CSV line covered by ByteOffset
+	BatchNumber    int       `json:"batchNumber"` // Last contiguous committed batch, -1 when none
+	RowsCommitted  int       `json:"rowsCommitted"`
+	CommittedAhead []int     `json:"committedAhead,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// LoadTestBatch records a batch committed by a checkpointed run. It is written in the transaction
+// that commits the batch's rows, so it stays accurate when the checkpoint in the cache lags behind.
+type LoadTestBatch struct {
+	LoadTestID  uuid.UUID `gorm:"type:uuid;primaryKey"           json:"loadTestId"`
+	BatchNumber int       `gorm:"primaryKey;autoIncrement:false" json:"batchNumber"`
+	EndOffset   int64     `gorm:"not null"                       json:"endOffset"`
+	EndLine     int       `gorm:"not null"                       json:"endLine"`
+	Rows        int       `gorm:"not null"                       json:"rows"`
+}
+
+// ApplyBatches brings the checkpoint up to date with every batch committed so far, advancing
+// the contiguous watermark and listing the batches committed past it
+func (c *LoadTestCheckpoint) ApplyBatches(batches []*LoadTestBatch) {
+	if len(batches) == 0 {
+		return
+	}
+
+	committed := make(map[int]*LoadTestBatch, len(batches))
+	c.RowsCommitted = 0
+	for _, batch := range batches {
+		committed[batch.BatchNumber] = batch
+		c.RowsCommitted += batch.Rows
+	}
+
+	for {
+		next, ok := committed[c.BatchNumber+1]
+		if !ok {
+			break
+		}
+		c.BatchNumber++
+		c.ByteOffset = next.EndOffset
+		c.LineNumber = next.EndLine
+	}
+
+	c.CommittedAhead = nil
+	for batchNum := range committed {
+		if batchNum > c.BatchNumber {
+			c.CommittedAhead = append(c.CommittedAhead, batchNum)
+		}
+	}
+	slices.Sort(c.CommittedAhead)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Load test job kinds and states
+const (
+	LOAD_TEST_JOB_RUN    = "run"    // Generate the CSV and run the insertion from the start
+	LOAD_TEST_JOB_RESUME = "resume" // Continue a generated run from its checkpointed CSV
+	LOAD_TEST_JOB_UPLOAD = "upload" // Stream an upload, or resume one, on the instance that received it
+
+	LOAD_TEST_JOB_QUEUED  = "queued"
+	LOAD_TEST_JOB_RUNNING = "running"
+)
+
+// LoadTestJob is a queued or running load test. Jobs are dispatched in priority order, oldest
+// first, and removed once the run has finished. Upload jobs are never dispatched to the job
+// stream, as only the instance holding the request can run them; they hold a slot for it instead.
+type LoadTestJob struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuidv7()"             json:"id"`
+	LoadTestID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex"                    json:"loadTestId"`
+	Kind        string     `gorm:"type:varchar(20);not null"                         json:"kind"`                // 'run', 'resume' or 'upload'
+	Priority    int        `gorm:"not null;default:0"                                json:"priority"`            // Higher runs first
+	Status      string     `gorm:"type:varchar(20);not null;index"                   json:"status"`              // 'queued' or 'running'
+	ClaimedBy   *string    `gorm:"type:varchar(64)"                                  json:"claimedBy,omitempty"` // Stream consumer running the job, or instance holding an upload
+	ClaimedAt   *time.Time `gorm:"type:timestamp"                                    json:"claimedAt,omitempty"`
+	HeartbeatAt *time.Time `gorm:"type:timestamp"                                    json:"heartbeatAt,omitempty"`
+	CreatedAt   time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;not null" json:"createdAt"`
+}
+
+// LoadTestJobDelivery is a dispatched job read from the job stream. It stays pending in the
+// consumer group until acknowledged, so a job whose worker dies can be reclaimed.
+type LoadTestJobDelivery struct {
+	EntryID string
+	JobID   uuid.UUID
+}
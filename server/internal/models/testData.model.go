@@ -5,10 +5,15 @@ import "github.com/google/uuid"
 type TestData struct {
 	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuidv7()" json:"id"`
 	LoadTestID uuid.UUID `gorm:"type:uuid;not null;index"              json:"loadTestId"`
-	// Known date columns - these are validated and normalized to RFC3339 format with UTC timezone
-	BirthDate *string `gorm:"type:varchar(255)"                     json:"birth_date"` // Normalized RFC3339: "2006-01-02T15:04:05Z"
-	StartDate *string `gorm:"type:varchar(255)"                     json:"start_date"` // Normalized RFC3339: "2006-01-02T15:04:05Z"
-	EndDate   *string `gorm:"type:varchar(255)"                     json:"end_date"`   // Normalized RFC3339: "2006-01-02T15:04:05Z"
+	// Known date columns - these are validated and normalized to ISO 8601 at the precision they were
+	// written to: "1985", "1985-07", "2006-01-02", or RFC3339 in UTC when they have a time of day
+	BirthDate *string `gorm:"type:varchar(255)"                     json:"birth_date"` // Normalized, e.g. "2006-01-02T15:04:05Z"
+	StartDate *string `gorm:"type:varchar(255)"                     json:"start_date"` // Normalized, e.g. "2006-01-02T15:04:05Z"
+	EndDate   *string `gorm:"type:varchar(255)"                     json:"end_date"`   // Normalized, e.g. "2006-01-02T15:04:05Z"
+	// Precision of each normalized date: "year", "month", "day" or "second"
+	BirthDatePrecision *string `gorm:"type:varchar(10)"                      json:"birth_date_precision"`
+	StartDatePrecision *string `gorm:"type:varchar(10)"                      json:"start_date_precision"`
+	EndDatePrecision   *string `gorm:"type:varchar(10)"                      json:"end_date_precision"`
 	// Meaningful columns (20 total for demographics, employment, and insurance data)
 	FirstName        *string `gorm:"type:varchar(255)"                     json:"first_name"`
 	LastName         *string `gorm:"type:varchar(255)"                     json:"last_name"`
@@ -30,5 +35,169 @@ type TestData struct {
 	PolicyNumber     *string `gorm:"type:varchar(255)"                     json:"policy_number"`
 	GroupNumber      *string `gorm:"type:varchar(255)"                     json:"group_number"`
 	MemberID         *string `gorm:"type:varchar(255)"                     json:"member_id"`
+	// Sidecar of the date columns, for auditing timezone conversions
+	DateOffsets *string `gorm:"type:varchar(255)"                     json:"date_offsets"` // Original UTC offset of each date, e.g. {"start_date":"-06:00"}
+	// Natural key columns the row was upserted on, e.g. "member_id,group_number"; null for inserted rows
+	UpsertKey *string `gorm:"type:varchar(255)"                     json:"upsert_key"`
 }
 
+// Import modes. Upserts merge rows into existing TestData that was upserted on the same natural
+// key and shares its value; rows that were only inserted are never matched.
+const (
+	IMPORT_MODE_INSERT = "insert"
+	IMPORT_MODE_UPSERT = "upsert"
+)
+
+// UpsertCounts tallies how an upsert changed test_data. Unchanged rows matched an existing row
+// with identical values, or repeated the key of a later row in the same batch.
+type UpsertCounts struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+// TestDataDateFields are the canonical date fields that are validated and normalized on import
+var TestDataDateFields = []string{
+	"birth_date",
+	"start_date",
+	"end_date",
+}
+
+// TestDataMeaningfulFields are the canonical demographic, employment and insurance fields
+var TestDataMeaningfulFields = []string{
+	"first_name",
+	"last_name",
+	"email",
+	"phone",
+	"address_line_1",
+	"address_line_2",
+	"city",
+	"state",
+	"zip_code",
+	"country",
+	"social_security_no",
+	"employer",
+	"job_title",
+	"department",
+	"salary",
+	"insurance_plan_id",
+	"insurance_carrier",
+	"policy_number",
+	"group_number",
+	"member_id",
+}
+
+// DATE_OFFSETS_FIELD is the canonical name of the sidecar recording the original UTC offset of
+// each date of an imported row, as a JSON object keyed by date field
+const DATE_OFFSETS_FIELD = "date_offsets"
+
+// TestDataPrecisionField returns the field recording the precision of a date field
+func TestDataPrecisionField(field string) string {
+	return field + "_precision"
+}
+
+// TestDataImportFields returns every canonical field an import can set, in column order. The
+// precision fields come last, as they are set from the dates rather than read from the source.
+func TestDataImportFields() []string {
+	fields := append(append([]string{}, TestDataDateFields...), TestDataMeaningfulFields...)
+	fields = append(fields, DATE_OFFSETS_FIELD)
+	for _, field := range TestDataDateFields {
+		fields = append(fields, TestDataPrecisionField(field))
+	}
+	return fields
+}
+
+// TestDataColumn returns the database column name for a canonical field.
+// The address fields are the only ones whose gorm column name differs.
+func TestDataColumn(field string) string {
+	switch field {
+	case "address_line_1":
+		return "address_line1"
+	case "address_line_2":
+		return "address_line2"
+	default:
+		return field
+	}
+}
+
+// IsTestDataField reports whether field is a canonical TestData field a source column can be
+// mapped to. The sidecars are set from the dates, so they are reachable only through FieldPointer.
+func IsTestDataField(field string) bool {
+	return !IsTestDataSidecar(field) && (&TestData{}).FieldPointer(field) != nil
+}
+
+// IsTestDataSidecar reports whether field records the offsets or precision of the dates
+func IsTestDataSidecar(field string) bool {
+	if field == DATE_OFFSETS_FIELD {
+		return true
+	}
+	for _, date := range TestDataDateFields {
+		if field == TestDataPrecisionField(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldPointer returns a pointer to the TestData field with the given canonical name,
+// or nil if the name is not a known field
+func (t *TestData) FieldPointer(field string) **string {
+	switch field {
+	case "birth_date":
+		return &t.BirthDate
+	case "start_date":
+		return &t.StartDate
+	case "end_date":
+		return &t.EndDate
+	case "first_name":
+		return &t.FirstName
+	case "last_name":
+		return &t.LastName
+	case "email":
+		return &t.Email
+	case "phone":
+		return &t.Phone
+	case "address_line_1":
+		return &t.AddressLine1
+	case "address_line_2":
+		return &t.AddressLine2
+	case "city":
+		return &t.City
+	case "state":
+		return &t.State
+	case "zip_code":
+		return &t.ZipCode
+	case "country":
+		return &t.Country
+	case "social_security_no":
+		return &t.SocialSecurityNo
+	case "employer":
+		return &t.Employer
+	case "job_title":
+		return &t.JobTitle
+	case "department":
+		return &t.Department
+	case "salary":
+		return &t.Salary
+	case "insurance_plan_id":
+		return &t.InsurancePlanID
+	case "insurance_carrier":
+		return &t.InsuranceCarrier
+	case "policy_number":
+		return &t.PolicyNumber
+	case "group_number":
+		return &t.GroupNumber
+	case "member_id":
+		return &t.MemberID
+	case DATE_OFFSETS_FIELD:
+		return &t.DateOffsets
+	case "birth_date_precision":
+		return &t.BirthDatePrecision
+	case "start_date_precision":
+		return &t.StartDatePrecision
+	case "end_date_precision":
+		return &t.EndDatePrecision
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/database"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/services"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ColumnMappingRepository interface {
+	GetByID(ctx context.Context, id string) (*ColumnMappingProfile, error)
+	GetAll(ctx context.Context) ([]*ColumnMappingProfile, error)
+	Create(ctx context.Context, profile *ColumnMappingProfile) error
+	Update(ctx context.Context, profile *ColumnMappingProfile) error
+	Delete(ctx context.Context, id string) error
+}
+
+type columnMappingRepository struct {
+	db  database.DB
+	log logger.Logger
+}
+
+func NewColumnMapping(db database.DB) ColumnMappingRepository {
+	return &columnMappingRepository{
+		db:  db,
+		log: logger.New("columnMappingRepository"),
+	}
+}
+
+func (r *columnMappingRepository) getDB(ctx context.Context) *gorm.DB {
+	if tx, ok := services.GetTransaction(ctx); ok {
+		return tx
+	}
+	return r.db.SQLWithContext(ctx)
+}
+
+func (r *columnMappingRepository) GetByID(
+	ctx context.Context,
+	id string,
+) (*ColumnMappingProfile, error) {
+	log := r.log.Function("GetByID")
+
+	profileID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, log.Err("failed to parse profileID", err, "profileID", id)
+	}
+
+	var profile ColumnMappingProfile
+	if err := r.getDB(ctx).First(&profile, "id = ?", profileID).Error; err != nil {
+		return nil, log.Err("failed to get column mapping profile by id", err, "id", id)
+	}
+
+	return &profile, nil
+}
+
+func (r *columnMappingRepository) GetAll(ctx context.Context) ([]*ColumnMappingProfile, error) {
+	log := r.log.Function("GetAll")
+
+	var profiles []*ColumnMappingProfile
+	if err := r.getDB(ctx).Order("name ASC").Find(&profiles).Error; err != nil {
+		return nil, log.Err("failed to get column mapping profiles", err)
+	}
+
+	return profiles, nil
+}
+
+func (r *columnMappingRepository) Create(
+	ctx context.Context,
+	profile *ColumnMappingProfile,
+) error {
+	log := r.log.Function("Create")
+
+	if err := r.getDB(ctx).Create(profile).Error; err != nil {
+		return log.Err("failed to create column mapping profile", err, "name", profile.Name)
+	}
+
+	return nil
+}
+
+func (r *columnMappingRepository) Update(
+	ctx context.Context,
+	profile *ColumnMappingProfile,
+) error {
+	log := r.log.Function("Update")
+
+	if err := r.getDB(ctx).Save(profile).Error; err != nil {
+		return log.Err("failed to update column mapping profile", err, "id", profile.ID)
+	}
+
+	return nil
+}
+
+func (r *columnMappingRepository) Delete(ctx context.Context, id string) error {
+	log := r.log.Function("Delete")
+
+	profileID, err := uuid.Parse(id)
+	if err != nil {
+		return log.Err("failed to parse profileID", err, "profileID", id)
+	}
+
+	if err := r.getDB(ctx).Delete(&ColumnMappingProfile{}, "id = ?", profileID).Error; err != nil {
+		return log.Err("failed to delete column mapping profile", err, "id", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/database"
+	"server/internal/logger"
+	. "server/internal/models"
+	"time"
+)
+
+const (
+	IDEMPOTENCY_KEY_HASH      = "idempotency:key:%s"
+	IDEMPOTENCY_CHECKSUM_HASH = "idempotency:sha256:%s"
+	DEFAULT_IDEMPOTENCY_TTL   = 24 * time.Hour
+
+	// IDEMPOTENCY_RESERVE_ATTEMPTS bounds how often Reserve retries a key whose record expires
+	// between being found taken and being read
+	IDEMPOTENCY_RESERVE_ATTEMPTS = 3
+)
+
+// IdempotencyRepository stores idempotency records in the General cache. Each record is stored
+// under a hash pattern, IDEMPOTENCY_KEY_HASH for client keys or IDEMPOTENCY_CHECKSUM_HASH for
+// upload checksums.
+type IdempotencyRepository interface {
+	Get(ctx context.Context, hashPattern string, key string) (*IdempotencyRecord, error)
+	Reserve(
+		ctx context.Context,
+		hashPattern string,
+		key string,
+		record *IdempotencyRecord,
+		ttl time.Duration,
+	) (*IdempotencyRecord, error)
+	Save(
+		ctx context.Context,
+		hashPattern string,
+		key string,
+		record *IdempotencyRecord,
+		ttl time.Duration,
+	) error
+	Delete(ctx context.Context, hashPattern string, key string) error
+}
+
+type idempotencyRepository struct {
+	db  database.DB
+	log logger.Logger
+}
+
+func NewIdempotency(db database.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db:  db,
+		log: logger.New("idempotencyRepository"),
+	}
+}
+
+// Get returns the record stored for key, or nil if none exists
+func (r *idempotencyRepository) Get(
+	ctx context.Context,
+	hashPattern string,
+	key string,
+) (*IdempotencyRecord, error) {
+	var record IdempotencyRecord
+	found, err := database.NewCacheBuilder(r.db.Cache.General, key).
+		WithHashPattern(hashPattern).
+		WithContext(ctx).
+		Get(&record)
+	if err != nil {
+		return nil, r.log.Function("Get").
+			Err("failed to get idempotency record", err, "key", key)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+// Reserve stores record for key unless one already exists. It returns the existing record when
+// the key was taken, or nil when record was stored.
+func (r *idempotencyRepository) Reserve(
+	ctx context.Context,
+	hashPattern string,
+	key string,
+	record *IdempotencyRecord,
+	ttl time.Duration,
+) (*IdempotencyRecord, error) {
+	log := r.log.Function("Reserve")
+
+	for attempt := 1; attempt <= IDEMPOTENCY_RESERVE_ATTEMPTS; attempt++ {
+		reserved, err := database.NewCacheBuilder(r.db.Cache.General, key).
+			WithHashPattern(hashPattern).
+			WithStruct(record).
+			WithTTL(ttl).
+			WithContext(ctx).
+			SetNX()
+		if err != nil {
+			return nil, log.Err("failed to reserve idempotency record", err, "key", key)
+		}
+
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := r.Get(ctx, hashPattern, key)
+		if err != nil {
+			return nil, err
+		}
+		// A nil record means the other one expired in between, so try again
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	return nil, log.Error("idempotency record kept expiring while being reserved",
+		"key", key, "attempts", IDEMPOTENCY_RESERVE_ATTEMPTS)
+}
+
+// Save stores record for key, replacing any existing record
+func (r *idempotencyRepository) Save(
+	ctx context.Context,
+	hashPattern string,
+	key string,
+	record *IdempotencyRecord,
+	ttl time.Duration,
+) error {
+	if err := database.NewCacheBuilder(r.db.Cache.General, key).
+		WithHashPattern(hashPattern).
+		WithStruct(record).
+		WithTTL(ttl).
+		WithContext(ctx).
+		Set(); err != nil {
+		return r.log.Function("Save").
+			Err("failed to save idempotency record", err, "key", key)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, hashPattern string, key string) error {
+	if err := database.NewCacheBuilder(r.db.Cache.General, key).
+		WithHashPattern(hashPattern).
+		WithContext(ctx).
+		Delete(); err != nil {
+		return r.log.Function("Delete").
+			Err("failed to delete idempotency record", err, "key", key)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/database"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/services"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportErrorRepository interface {
+	CreateBatch(ctx context.Context, importErrors []*ImportError) error
+	GetByLoadTestIDPaginated(
+		ctx context.Context,
+		loadTestID string,
+		offset, limit int,
+	) ([]*ImportError, error)
+	CountByLoadTestID(ctx context.Context, loadTestID string) (int64, error)
+	CountRowsByLoadTestID(ctx context.Context, loadTestID string) (int64, error)
+	DeleteByLoadTestID(ctx context.Context, loadTestID string) error
+	DeleteFromLine(ctx context.Context, loadTestID string, lineNumber int) error
+}
+
+type importErrorRepository struct {
+	db  database.DB
+	log logger.Logger
+}
+
+func NewImportError(db database.DB) ImportErrorRepository {
+	return &importErrorRepository{
+		db:  db,
+		log: logger.New("importErrorRepository"),
+	}
+}
+
+func (r *importErrorRepository) getDB(ctx context.Context) *gorm.DB {
+	if tx, ok := services.GetTransaction(ctx); ok {
+		return tx
+	}
+	return r.db.SQLWithContext(ctx)
+}
+
+func (r *importErrorRepository) CreateBatch(
+	ctx context.Context,
+	importErrors []*ImportError,
+) error {
+	log := r.log.Function("CreateBatch")
+
+	if len(importErrors) == 0 {
+		return nil
+	}
+
+	if err := r.getDB(ctx).CreateInBatches(importErrors, 500).Error; err != nil {
+		return log.Err("failed to create import errors", err, "count", len(importErrors))
+	}
+
+	return nil
+}
+
+func (r *importErrorRepository) GetByLoadTestIDPaginated(
+	ctx context.Context,
+	loadTestID string,
+	offset, limit int,
+) ([]*ImportError, error) {
+	log := r.log.Function("GetByLoadTestIDPaginated")
+
+	loadTestUUID, err := uuid.Parse(loadTestID)
+	if err != nil {
+		return nil, log.Err("failed to parse loadTestID", err, "loadTestID", loadTestID)
+	}
+
+	var importErrors []*ImportError
+	query := r.getDB(ctx).
+		Where("load_test_id = ?", loadTestUUID).
+		Order("line_number ASC, id ASC")
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&importErrors).Error; err != nil {
+		return nil, log.Err("failed to get import errors by load test ID", err,
+			"loadTestID", loadTestID, "offset", offset, "limit", limit)
+	}
+
+	return importErrors, nil
+}
+
+func (r *importErrorRepository) CountByLoadTestID(
+	ctx context.Context,
+	loadTestID string,
+) (int64, error) {
+	log := r.log.Function("CountByLoadTestID")
+
+	loadTestUUID, err := uuid.Parse(loadTestID)
+	if err != nil {
+		return 0, log.Err("failed to parse loadTestID", err, "loadTestID", loadTestID)
+	}
+
+	var count int64
+	if err := r.getDB(ctx).Model(&ImportError{}).Where("load_test_id = ?", loadTestUUID).Count(&count).Error; err != nil {
+		return 0, log.Err("failed to count import errors", err, "loadTestID", loadTestID)
+	}
+
+	return count, nil
+}
+
+// CountRowsByLoadTestID counts distinct rejected rows, since one row can have several errors
+func (r *importErrorRepository) CountRowsByLoadTestID(
+	ctx context.Context,
+	loadTestID string,
+) (int64, error) {
+	log := r.log.Function("CountRowsByLoadTestID")
+
+	loadTestUUID, err := uuid.Parse(loadTestID)
+	if err != nil {
+		return 0, log.Err("failed to parse loadTestID", err, "loadTestID", loadTestID)
+	}
+
+	var count int64
+	if err := r.getDB(ctx).
+		Model(&ImportError{}).
+		Where("load_test_id = ?", loadTestUUID).
+		Distinct("line_number").
+		Count(&count).Error; err != nil {
+		return 0, log.Err("failed to count rejected rows", err, "loadTestID", loadTestID)
+	}
+
+	return count, nil
+}
+
+func (r *importErrorRepository) DeleteByLoadTestID(ctx context.Context, loadTestID string) error {
+	log := r.log.Function("DeleteByLoadTestID")
+
+	loadTestUUID, err := uuid.Parse(loadTestID)
+	if err != nil {
+		return log.Err("failed to parse loadTestID", err, "loadTestID", loadTestID)
+	}
+
+	if err := r.getDB(ctx).Where("load_test_id = ?", loadTestUUID).Delete(&ImportError{}).Error; err != nil {
+		return log.Err("failed to delete import errors", err, "loadTestID", loadTestID)
+	}
+
+	return nil
+}
+
+// DeleteFromLine removes import errors recorded at or after lineNumber, used when a run is resumed
+// from a checkpoint and the remaining rows will be validated again
+func (r *importErrorRepository) DeleteFromLine(
+	ctx context.Context,
+	loadTestID string,
+	lineNumber int,
+) error {
+	log := r.log.Function("DeleteFromLine")
+
+	loadTestUUID, err := uuid.Parse(loadTestID)
+	if err != nil {
+		return log.Err("failed to parse loadTestID", err, "loadTestID", loadTestID)
+	}
+
+	if err := r.getDB(ctx).
+		Where("load_test_id = ? AND line_number >= ?", loadTestUUID, lineNumber).
+		Delete(&ImportError{}).Error; err != nil {
+		return log.Err("failed to delete import errors", err,
+			"loadTestID", loadTestID, "lineNumber", lineNumber)
+	}
+
+	return nil
+}
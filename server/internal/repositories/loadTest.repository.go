@@ -13,9 +13,17 @@ import (
 )
 
 const (
-	LOAD_TEST_CACHE_EXPIRY = 24 * time.Hour // 24 hours
+	LOAD_TEST_CACHE_EXPIRY      = 24 * time.Hour     // 24 hours
+	LOAD_TEST_CHECKPOINT_EXPIRY = 7 * 24 * time.Hour // 7 days
+	LOAD_TEST_CHECKPOINT_HASH   = "checkpoint:%s"
 )
 
+// INSERT_LOAD_TEST_BATCH records a committed batch in the transaction that commits its rows.
+// Parameters are the load test ID, batch number, end offset, end line and row count.
+const INSERT_LOAD_TEST_BATCH = `INSERT INTO load_test_batches (load_test_id, batch_number, end_offset, end_line, rows)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING`
+
 type LoadTestRepository interface {
 	GetByID(ctx context.Context, id string) (*LoadTest, error)
 	Create(ctx context.Context, loadTest *LoadTest) error
@@ -24,6 +32,9 @@ type LoadTestRepository interface {
 	GetAll(ctx context.Context) ([]*LoadTest, error)
 	GetAllForSummary(ctx context.Context) ([]*LoadTest, error)
 	GetByStatus(ctx context.Context, status string) ([]*LoadTest, error)
+	GetCheckpoint(ctx context.Context, id string) (*LoadTestCheckpoint, error)
+	SaveCheckpoint(ctx context.Context, checkpoint *LoadTestCheckpoint) error
+	DeleteCheckpoint(ctx context.Context, id string) error
 }
 
 type loadTestRepository struct {
@@ -140,6 +151,74 @@ func (r *loadTestRepository) GetByStatus(ctx context.Context, status string) ([]
 	return loadTests, nil
 }
 
+// GetCheckpoint returns the insertion checkpoint for a load test, or nil if none exists. The
+// cached checkpoint is brought up to date with the batches recorded as committed in the database.
+func (r *loadTestRepository) GetCheckpoint(
+	ctx context.Context,
+	id string,
+) (*LoadTestCheckpoint, error) {
+	log := r.log.Function("GetCheckpoint")
+
+	var checkpoint LoadTestCheckpoint
+	found, err := database.NewCacheBuilder(r.db.Cache.LoadTest, id).
+		WithHashPattern(LOAD_TEST_CHECKPOINT_HASH).
+		WithContext(ctx).
+		Get(&checkpoint)
+	if err != nil {
+		return nil, log.Err("failed to get load test checkpoint", err, "loadTestID", id)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	var batches []*LoadTestBatch
+	if err := r.getDB(ctx).Where("load_test_id = ?", checkpoint.LoadTestID).Find(&batches).Error; err != nil {
+		return nil, log.Err("failed to get committed batches", err, "loadTestID", id)
+	}
+	checkpoint.ApplyBatches(batches)
+
+	return &checkpoint, nil
+}
+
+func (r *loadTestRepository) SaveCheckpoint(
+	ctx context.Context,
+	checkpoint *LoadTestCheckpoint,
+) error {
+	if err := database.NewCacheBuilder(r.db.Cache.LoadTest, checkpoint.LoadTestID).
+		WithHashPattern(LOAD_TEST_CHECKPOINT_HASH).
+		WithStruct(checkpoint).
+		WithTTL(LOAD_TEST_CHECKPOINT_EXPIRY).
+		WithContext(ctx).
+		Set(); err != nil {
+		return r.log.Function("SaveCheckpoint").
+			Err("failed to save load test checkpoint", err, "loadTestID", checkpoint.LoadTestID)
+	}
+	return nil
+}
+
+// DeleteCheckpoint removes the checkpoint of a load test along with its committed batches
+func (r *loadTestRepository) DeleteCheckpoint(ctx context.Context, id string) error {
+	log := r.log.Function("DeleteCheckpoint")
+
+	loadTestUUID, err := uuid.Parse(id)
+	if err != nil {
+		return log.Err("failed to parse loadTestID", err, "loadTestID", id)
+	}
+
+	if err := r.getDB(ctx).Where("load_test_id = ?", loadTestUUID).Delete(&LoadTestBatch{}).Error; err != nil {
+		return log.Err("failed to delete committed batches", err, "loadTestID", id)
+	}
+
+	if err := database.NewCacheBuilder(r.db.Cache.LoadTest, id).
+		WithHashPattern(LOAD_TEST_CHECKPOINT_HASH).
+		WithContext(ctx).
+		Delete(); err != nil {
+		return log.Err("failed to delete load test checkpoint", err, "loadTestID", id)
+	}
+	return nil
+}
+
 func (r *loadTestRepository) getCacheByID(ctx context.Context, loadTestID string, loadTest *LoadTest) error {
 	found, err := database.NewCacheBuilder(r.db.Cache.LoadTest, loadTestID).Get(loadTest)
 	if err != nil {
@@ -0,0 +1,502 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"server/internal/database"
+	"server/internal/logger"
+	. "server/internal/models"
+	"server/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valkey-io/valkey-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// LOAD_TEST_JOB_CLAIM_LOCK serializes dispatching across instances so the concurrency limit holds globally
+	LOAD_TEST_JOB_CLAIM_LOCK = "load_test_jobs_claim"
+	LOAD_TEST_JOB_STREAM     = "load-test-jobs"
+	LOAD_TEST_JOB_GROUP      = "load-test-workers"
+)
+
+type LoadTestJobRepository interface {
+	Create(ctx context.Context, job *LoadTestJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*LoadTestJob, error)
+	Dispatch(ctx context.Context, limit int) (*LoadTestJob, error)
+	ClaimUpload(ctx context.Context, job *LoadTestJob, limit int) (bool, error)
+	MarkClaimed(ctx context.Context, job *LoadTestJob, consumer string) error
+	Heartbeat(ctx context.Context, delivery *LoadTestJobDelivery, consumer string) error
+	TouchUpload(ctx context.Context, id uuid.UUID) error
+	RequeueStale(ctx context.Context, staleBefore time.Time) (int64, error)
+	DeleteStaleUploads(ctx context.Context, staleBefore time.Time) ([]*LoadTestJob, error)
+	GetQueued(ctx context.Context) ([]*LoadTestJob, error)
+	IsActive(ctx context.Context, loadTestID uuid.UUID) (bool, error)
+	Position(ctx context.Context, loadTestID uuid.UUID) (int, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteQueued(ctx context.Context, loadTestID uuid.UUID) (bool, error)
+	EnsureStream(ctx context.Context) error
+	ReadDeliveries(ctx context.Context, consumer string, block time.Duration) ([]*LoadTestJobDelivery, error)
+	ReclaimDeliveries(ctx context.Context, consumer string, minIdle time.Duration) ([]*LoadTestJobDelivery, error)
+	Acknowledge(ctx context.Context, delivery *LoadTestJobDelivery) error
+}
+
+type loadTestJobRepository struct {
+	db  database.DB
+	log logger.Logger
+}
+
+func NewLoadTestJob(db database.DB) LoadTestJobRepository {
+	return &loadTestJobRepository{
+		db:  db,
+		log: logger.New("loadTestJobRepository"),
+	}
+}
+
+func (r *loadTestJobRepository) getDB(ctx context.Context) *gorm.DB {
+	if tx, ok := services.GetTransaction(ctx); ok {
+		return tx
+	}
+	return r.db.SQLWithContext(ctx)
+}
+
+func (r *loadTestJobRepository) Create(ctx context.Context, job *LoadTestJob) error {
+	log := r.log.Function("Create")
+
+	if err := r.getDB(ctx).Create(job).Error; err != nil {
+		return log.Err("failed to create load test job", err, "loadTestID", job.LoadTestID)
+	}
+
+	return nil
+}
+
+func (r *loadTestJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*LoadTestJob, error) {
+	log := r.log.Function("GetByID")
+
+	var job LoadTestJob
+	if err := r.getDB(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, log.Err("failed to get load test job", err, "id", id)
+	}
+
+	return &job, nil
+}
+
+// Dispatch marks the next queued job as running and adds it to the job stream for a worker to
+// claim. It returns nil if the queue is empty, limit jobs are already running or the next job is
+// an upload, which the instance holding it claims with ClaimUpload.
+func (r *loadTestJobRepository) Dispatch(ctx context.Context, limit int) (*LoadTestJob, error) {
+	log := r.log.Function("Dispatch")
+
+	var dispatched *LoadTestJob
+	err := r.getDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", LOAD_TEST_JOB_CLAIM_LOCK).Error; err != nil {
+			return err
+		}
+
+		var running int64
+		if err := tx.Model(&LoadTestJob{}).Where("status = ?", LOAD_TEST_JOB_RUNNING).Count(&running).Error; err != nil {
+			return err
+		}
+		if running >= int64(limit) {
+			return nil
+		}
+
+		var job LoadTestJob
+		if err := tx.
+			Where("status = ?", LOAD_TEST_JOB_QUEUED).
+			Order("priority DESC, created_at ASC, id ASC").
+			First(&job).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if job.Kind == LOAD_TEST_JOB_UPLOAD {
+			return nil
+		}
+
+		// The heartbeat starts at dispatch so a job lost before any worker reads it is requeued
+		now := time.Now()
+		job.Status = LOAD_TEST_JOB_RUNNING
+		job.ClaimedBy = nil
+		job.ClaimedAt = nil
+		job.HeartbeatAt = &now
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+
+		// Publishing inside the transaction means a failed publish leaves the job queued
+		client := r.db.Cache.LoadTest
+		if err := client.Do(ctx, client.B().Xadd().
+			Key(LOAD_TEST_JOB_STREAM).
+			Id("*").
+			FieldValue().
+			FieldValue("job_id", job.ID.String()).
+			Build()).Error(); err != nil {
+			return err
+		}
+
+		dispatched = &job
+		return nil
+	})
+	if err != nil {
+		return nil, log.Err("failed to dispatch load test job", err)
+	}
+
+	return dispatched, nil
+}
+
+// ClaimUpload marks an upload job as running if it is next in the queue and fewer than limit jobs
+// are running, reporting whether it was claimed. An upload job that no longer exists, because it
+// was cancelled while waiting, returns gorm.ErrRecordNotFound.
+func (r *loadTestJobRepository) ClaimUpload(ctx context.Context, job *LoadTestJob, limit int) (bool, error) {
+	log := r.log.Function("ClaimUpload")
+
+	claimed := false
+	err := r.getDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", LOAD_TEST_JOB_CLAIM_LOCK).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&LoadTestJob{}, "id = ?", job.ID).Error; err != nil {
+			return err
+		}
+
+		var running int64
+		if err := tx.Model(&LoadTestJob{}).Where("status = ?", LOAD_TEST_JOB_RUNNING).Count(&running).Error; err != nil {
+			return err
+		}
+		if running >= int64(limit) {
+			return nil
+		}
+
+		var next LoadTestJob
+		if err := tx.
+			Where("status = ?", LOAD_TEST_JOB_QUEUED).
+			Order("priority DESC, created_at ASC, id ASC").
+			First(&next).Error; err != nil {
+			return err
+		}
+		if next.ID != job.ID {
+			return nil
+		}
+
+		now := time.Now()
+		job.Status = LOAD_TEST_JOB_RUNNING
+		job.ClaimedAt = &now
+		job.HeartbeatAt = &now
+		if err := tx.Model(job).Updates(map[string]any{
+			"status":       LOAD_TEST_JOB_RUNNING,
+			"claimed_at":   now,
+			"heartbeat_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+		return false, log.Err("failed to claim upload job", err, "id", job.ID)
+	}
+
+	return claimed, nil
+}
+
+// MarkClaimed records the stream consumer that is running the job
+func (r *loadTestJobRepository) MarkClaimed(
+	ctx context.Context,
+	job *LoadTestJob,
+	consumer string,
+) error {
+	log := r.log.Function("MarkClaimed")
+
+	now := time.Now()
+	job.ClaimedBy = &consumer
+	job.ClaimedAt = &now
+	job.HeartbeatAt = &now
+	if err := r.getDB(ctx).Model(job).Updates(map[string]any{
+		"claimed_by":   consumer,
+		"claimed_at":   now,
+		"heartbeat_at": now,
+	}).Error; err != nil {
+		return log.Err("failed to mark load test job as claimed", err, "id", job.ID, "consumer", consumer)
+	}
+
+	return nil
+}
+
+// Heartbeat keeps a running job from being reclaimed. Re-claiming the stream entry for the same
+// consumer resets its idle time, and the job row records when the worker was last seen.
+func (r *loadTestJobRepository) Heartbeat(
+	ctx context.Context,
+	delivery *LoadTestJobDelivery,
+	consumer string,
+) error {
+	log := r.log.Function("Heartbeat")
+
+	client := r.db.Cache.LoadTest
+	if err := client.Do(ctx, client.B().Xclaim().
+		Key(LOAD_TEST_JOB_STREAM).
+		Group(LOAD_TEST_JOB_GROUP).
+		Consumer(consumer).
+		MinIdleTime("0").
+		Id(delivery.EntryID).
+		Justid().
+		Build()).Error(); err != nil {
+		return log.Err("failed to refresh job stream entry", err, "entryID", delivery.EntryID)
+	}
+
+	if err := r.getDB(ctx).
+		Model(&LoadTestJob{}).
+		Where("id = ?", delivery.JobID).
+		Update("heartbeat_at", time.Now()).Error; err != nil {
+		return log.Err("failed to record load test job heartbeat", err, "id", delivery.JobID)
+	}
+
+	return nil
+}
+
+// TouchUpload records that the instance holding an upload job, waiting or running, is still alive
+func (r *loadTestJobRepository) TouchUpload(ctx context.Context, id uuid.UUID) error {
+	log := r.log.Function("TouchUpload")
+
+	if err := r.getDB(ctx).
+		Model(&LoadTestJob{}).
+		Where("id = ?", id).
+		Update("heartbeat_at", time.Now()).Error; err != nil {
+		return log.Err("failed to record upload job heartbeat", err, "id", id)
+	}
+
+	return nil
+}
+
+// RequeueStale returns running jobs whose worker has not been seen since staleBefore to the queue.
+// This covers jobs whose stream entry was lost, which cannot be reclaimed from the stream. Upload
+// jobs cannot run anywhere else, so they are left to DeleteStaleUploads.
+func (r *loadTestJobRepository) RequeueStale(ctx context.Context, staleBefore time.Time) (int64, error) {
+	log := r.log.Function("RequeueStale")
+
+	result := r.getDB(ctx).
+		Model(&LoadTestJob{}).
+		Where("status = ? AND kind <> ? AND heartbeat_at < ?", LOAD_TEST_JOB_RUNNING, LOAD_TEST_JOB_UPLOAD, staleBefore).
+		Updates(map[string]any{
+			"status":     LOAD_TEST_JOB_QUEUED,
+			"claimed_by": nil,
+			"claimed_at": nil,
+		})
+	if result.Error != nil {
+		return 0, log.Err("failed to requeue stale load test jobs", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteStaleUploads removes upload jobs, waiting or running, whose instance has not been seen
+// since staleBefore and returns them, so a dead instance does not hold its place or slot
+func (r *loadTestJobRepository) DeleteStaleUploads(ctx context.Context, staleBefore time.Time) ([]*LoadTestJob, error) {
+	log := r.log.Function("DeleteStaleUploads")
+
+	var jobs []*LoadTestJob
+	if err := r.getDB(ctx).
+		Clauses(clause.Returning{}).
+		Where("kind = ? AND heartbeat_at < ?", LOAD_TEST_JOB_UPLOAD, staleBefore).
+		Delete(&jobs).Error; err != nil {
+		return nil, log.Err("failed to delete stale upload jobs", err)
+	}
+
+	return jobs, nil
+}
+
+// GetQueued returns the waiting jobs in the order they will be claimed
+func (r *loadTestJobRepository) GetQueued(ctx context.Context) ([]*LoadTestJob, error) {
+	log := r.log.Function("GetQueued")
+
+	var jobs []*LoadTestJob
+	if err := r.getDB(ctx).
+		Where("status = ?", LOAD_TEST_JOB_QUEUED).
+		Order("priority DESC, created_at ASC, id ASC").
+		Find(&jobs).Error; err != nil {
+		return nil, log.Err("failed to get queued load test jobs", err)
+	}
+
+	return jobs, nil
+}
+
+// IsActive reports whether a load test has a job that is queued or running on any instance
+func (r *loadTestJobRepository) IsActive(ctx context.Context, loadTestID uuid.UUID) (bool, error) {
+	log := r.log.Function("IsActive")
+
+	var count int64
+	if err := r.getDB(ctx).Model(&LoadTestJob{}).Where("load_test_id = ?", loadTestID).Count(&count).Error; err != nil {
+		return false, log.Err("failed to count load test jobs", err, "loadTestID", loadTestID)
+	}
+
+	return count > 0, nil
+}
+
+// Position returns the 1-based queue position of a load test, or 0 if it is not queued
+func (r *loadTestJobRepository) Position(ctx context.Context, loadTestID uuid.UUID) (int, error) {
+	log := r.log.Function("Position")
+
+	var job LoadTestJob
+	if err := r.getDB(ctx).
+		Where("load_test_id = ? AND status = ?", loadTestID, LOAD_TEST_JOB_QUEUED).
+		First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, log.Err("failed to get load test job", err, "loadTestID", loadTestID)
+	}
+
+	var ahead int64
+	if err := r.getDB(ctx).
+		Model(&LoadTestJob{}).
+		Where("status = ?", LOAD_TEST_JOB_QUEUED).
+		Where("priority > ? OR (priority = ? AND (created_at < ? OR (created_at = ? AND id < ?)))",
+			job.Priority, job.Priority, job.CreatedAt, job.CreatedAt, job.ID).
+		Count(&ahead).Error; err != nil {
+		return 0, log.Err("failed to count queued load test jobs", err, "loadTestID", loadTestID)
+	}
+
+	return int(ahead) + 1, nil
+}
+
+func (r *loadTestJobRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	log := r.log.Function("Delete")
+
+	if err := r.getDB(ctx).Delete(&LoadTestJob{}, "id = ?", id).Error; err != nil {
+		return log.Err("failed to delete load test job", err, "id", id)
+	}
+
+	return nil
+}
+
+// DeleteQueued removes a job that has not been claimed yet, reporting whether one was removed
+func (r *loadTestJobRepository) DeleteQueued(ctx context.Context, loadTestID uuid.UUID) (bool, error) {
+	log := r.log.Function("DeleteQueued")
+
+	result := r.getDB(ctx).
+		Where("load_test_id = ? AND status = ?", loadTestID, LOAD_TEST_JOB_QUEUED).
+		Delete(&LoadTestJob{})
+	if result.Error != nil {
+		return false, log.Err("failed to delete queued load test job", result.Error, "loadTestID", loadTestID)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// EnsureStream creates the job stream and its consumer group if they do not exist yet
+func (r *loadTestJobRepository) EnsureStream(ctx context.Context) error {
+	log := r.log.Function("EnsureStream")
+
+	client := r.db.Cache.LoadTest
+	err := client.Do(ctx, client.B().XgroupCreate().
+		Key(LOAD_TEST_JOB_STREAM).
+		Group(LOAD_TEST_JOB_GROUP).
+		Id("0").
+		Mkstream().
+		Build()).Error()
+	if err != nil && !valkey.IsValkeyBusyGroup(err) {
+		return log.Err("failed to create job stream consumer group", err)
+	}
+
+	return nil
+}
+
+// ReadDeliveries waits up to block for new jobs and assigns them to consumer
+func (r *loadTestJobRepository) ReadDeliveries(
+	ctx context.Context,
+	consumer string,
+	block time.Duration,
+) ([]*LoadTestJobDelivery, error) {
+	log := r.log.Function("ReadDeliveries")
+
+	client := r.db.Cache.LoadTest
+	streams, err := client.Do(ctx, client.B().Xreadgroup().
+		Group(LOAD_TEST_JOB_GROUP, consumer).
+		Count(1).
+		Block(block.Milliseconds()).
+		Streams().
+		Key(LOAD_TEST_JOB_STREAM).
+		Id(">").
+		Build()).AsXRead()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, nil
+		}
+		return nil, log.Err("failed to read job stream", err, "consumer", consumer)
+	}
+
+	return toJobDeliveries(streams[LOAD_TEST_JOB_STREAM]), nil
+}
+
+// ReclaimDeliveries takes over jobs that have been pending longer than minIdle, which means the
+// worker holding them stopped sending heartbeats
+func (r *loadTestJobRepository) ReclaimDeliveries(
+	ctx context.Context,
+	consumer string,
+	minIdle time.Duration,
+) ([]*LoadTestJobDelivery, error) {
+	log := r.log.Function("ReclaimDeliveries")
+
+	client := r.db.Cache.LoadTest
+	result, err := client.Do(ctx, client.B().Xautoclaim().
+		Key(LOAD_TEST_JOB_STREAM).
+		Group(LOAD_TEST_JOB_GROUP).
+		Consumer(consumer).
+		MinIdleTime(strconv.FormatInt(minIdle.Milliseconds(), 10)).
+		Start("0-0").
+		Count(10).
+		Build()).ToArray()
+	if err != nil {
+		return nil, log.Err("failed to reclaim job stream entries", err, "consumer", consumer)
+	}
+	if len(result) < 2 {
+		return nil, nil
+	}
+
+	entries, err := result[1].AsXRange()
+	if err != nil {
+		return nil, log.Err("failed to parse reclaimed job stream entries", err, "consumer", consumer)
+	}
+
+	return toJobDeliveries(entries), nil
+}
+
+// Acknowledge removes a finished job from the stream and its pending list
+func (r *loadTestJobRepository) Acknowledge(ctx context.Context, delivery *LoadTestJobDelivery) error {
+	log := r.log.Function("Acknowledge")
+
+	client := r.db.Cache.LoadTest
+	for _, err := range client.DoMulti(ctx,
+		client.B().Xack().Key(LOAD_TEST_JOB_STREAM).Group(LOAD_TEST_JOB_GROUP).Id(delivery.EntryID).Build(),
+		client.B().Xdel().Key(LOAD_TEST_JOB_STREAM).Id(delivery.EntryID).Build(),
+	) {
+		if err := err.Error(); err != nil {
+			return log.Err("failed to acknowledge job stream entry", err, "entryID", delivery.EntryID)
+		}
+	}
+
+	return nil
+}
+
+func toJobDeliveries(entries []valkey.XRangeEntry) []*LoadTestJobDelivery {
+	deliveries := make([]*LoadTestJobDelivery, 0, len(entries))
+	for _, entry := range entries {
+		// Entries deleted while pending come back without fields
+		jobID, err := uuid.Parse(entry.FieldValues["job_id"])
+		if err != nil {
+			deliveries = append(deliveries, &LoadTestJobDelivery{EntryID: entry.ID})
+			continue
+		}
+		deliveries = append(deliveries, &LoadTestJobDelivery{EntryID: entry.ID, JobID: jobID})
+	}
+	return deliveries
+}
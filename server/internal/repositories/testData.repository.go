@@ -2,26 +2,50 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"server/internal/database"
 	"server/internal/logger"
 	. "server/internal/models"
 	"server/internal/services"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// TEST_DATA_UPSERT_STAGING is the per-session temporary table COPY upserts are staged in. Its
+// staging_seq column records the order rows were copied in.
+const (
+	TEST_DATA_UPSERT_STAGING        = "test_data_upsert_staging"
+	CREATE_TEST_DATA_UPSERT_STAGING = "CREATE TEMP TABLE IF NOT EXISTS " + TEST_DATA_UPSERT_STAGING +
+		" (LIKE test_data INCLUDING DEFAULTS, staging_seq bigserial) ON COMMIT DELETE ROWS"
+)
+
 type TestDataRepository interface {
 	GetByID(ctx context.Context, id string) (*TestData, error)
 	Create(ctx context.Context, testData *TestData) error
 	CreateBatch(ctx context.Context, testDataBatch []*TestData, batchSize int) error
+	EnsureNaturalKey(ctx context.Context, keyColumns []string) error
+	UpsertBatch(
+		ctx context.Context,
+		testDataBatch []*TestData,
+		fields []string,
+		keyColumns []string,
+	) (UpsertCounts, error)
 	GetByLoadTestID(ctx context.Context, loadTestID string) ([]*TestData, error)
 	GetByLoadTestIDPaginated(
 		ctx context.Context,
 		loadTestID string,
 		offset, limit int,
 	) ([]*TestData, error)
+	GetByLoadTestIDAfter(
+		ctx context.Context,
+		loadTestID string,
+		afterID uuid.UUID,
+		limit int,
+	) ([]*TestData, error)
 	CountByLoadTestID(ctx context.Context, loadTestID string) (int64, error)
 	Delete(ctx context.Context, id string) error
 	DeleteByLoadTestID(ctx context.Context, loadTestID string) error
@@ -98,6 +122,258 @@ func (r *testDataRepository) CreateBatch(
 	return nil
 }
 
+// EnsureNaturalKey creates the unique index that upserts on keyColumns conflict against. The index
+// is partial, covering only rows upserted on the same key, so rows that were only inserted may
+// repeat a key and never block it. It is built concurrently, outside any transaction in ctx, so
+// imports already writing test_data are not blocked while it builds.
+func (r *testDataRepository) EnsureNaturalKey(ctx context.Context, keyColumns []string) error {
+	log := r.log.Function("EnsureNaturalKey")
+
+	db := r.db.SQLWithContext(ctx)
+	index := naturalKeyIndex(keyColumns)
+
+	var valid []bool
+	if err := db.Raw(
+		"SELECT i.indisvalid FROM pg_class c JOIN pg_index i ON i.indexrelid = c.oid WHERE c.relname = ?",
+		index,
+	).Scan(&valid).Error; err != nil {
+		return log.Err("failed to look up natural key index", err, "keyColumns", keyColumns)
+	}
+	if len(valid) > 0 && valid[0] {
+		return nil
+	}
+
+	// A concurrent build that failed, on duplicate keys for instance, leaves an invalid index behind
+	if len(valid) > 0 {
+		if err := db.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + index).Error; err != nil {
+			return log.Err("failed to drop invalid natural key index", err, "keyColumns", keyColumns)
+		}
+	}
+
+	statement := fmt.Sprintf(
+		"CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS %s ON test_data (%s) WHERE %s",
+		index,
+		strings.Join(keyColumns, ", "),
+		naturalKeyPredicate(keyColumns),
+	)
+	if err := db.Exec(statement).Error; err != nil {
+		return log.Err("failed to create natural key index", err, "keyColumns", keyColumns)
+	}
+
+	return nil
+}
+
+// UpsertBatch inserts the given fields of each record, updating the existing row instead when one
+// was already upserted with the same natural key. Rows whose key has a null column are always inserted.
+func (r *testDataRepository) UpsertBatch(
+	ctx context.Context,
+	testDataBatch []*TestData,
+	fields []string,
+	keyColumns []string,
+) (UpsertCounts, error) {
+	log := r.log.Function("UpsertBatch")
+
+	statement, args := BuildTestDataUpsert(testDataBatch, fields, keyColumns)
+	rows, err := r.getDB(ctx).ConnPool.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return UpsertCounts{}, log.Err("failed to upsert test data batch", err, "totalRecords", len(testDataBatch))
+	}
+
+	counts, err := CountUpserted(rows, len(testDataBatch))
+	if err != nil {
+		return UpsertCounts{}, log.Err("failed to read upserted rows", err, "totalRecords", len(testDataBatch))
+	}
+
+	return counts, nil
+}
+
+// BuildTestDataUpsert builds a multi-row INSERT of load_test_id and the given fields that upserts
+// on keyColumns, marking each row with upsert_key, along with its arguments. Records repeating a
+// key within the batch are dropped in favour of the last one, since Postgres cannot update the
+// same row twice in one statement.
+func BuildTestDataUpsert(records []*TestData, fields []string, keyColumns []string) (string, []any) {
+	records = lastPerNaturalKey(records, fields, keyColumns)
+	columns := append(testDataUpsertColumns(fields), "upsert_key")
+	upsertKey := strings.Join(keyColumns, ",")
+
+	valueClauses := make([]string, 0, len(records))
+	args := make([]any, 0, len(records)*len(columns))
+	for _, record := range records {
+		placeholders := make([]string, len(columns))
+		for i := range columns {
+			placeholders[i] = fmt.Sprintf("$%d", len(args)+i+1)
+		}
+		valueClauses = append(valueClauses, "("+strings.Join(placeholders, ", ")+")")
+
+		args = append(args, record.LoadTestID)
+		for _, field := range fields {
+			if value := *record.FieldPointer(field); value != nil {
+				args = append(args, *value)
+			} else {
+				args = append(args, nil)
+			}
+		}
+		args = append(args, upsertKey)
+	}
+
+	return fmt.Sprintf("INSERT INTO test_data (%s) VALUES %s%s",
+		strings.Join(columns, ", "),
+		strings.Join(valueClauses, ", "),
+		testDataUpsertClause(testDataUpsertColumns(fields), keyColumns),
+	), args
+}
+
+// BuildTestDataUpsertFromStaging builds the statement that upserts the rows COPYed into
+// TEST_DATA_UPSERT_STAGING. As with BuildTestDataUpsert, the last row copied for a key wins;
+// rows with a null key column are kept apart because they never conflict.
+func BuildTestDataUpsertFromStaging(fields []string, keyColumns []string) string {
+	columns := strings.Join(testDataUpsertColumns(fields), ", ")
+
+	nullKey := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		nullKey[i] = column + " IS NULL"
+	}
+	distinct := fmt.Sprintf("%s, CASE WHEN %s THEN staging_seq END",
+		strings.Join(keyColumns, ", "), strings.Join(nullKey, " OR "))
+
+	return fmt.Sprintf(
+		"INSERT INTO test_data (%s, upsert_key) SELECT DISTINCT ON (%s) %s, %s FROM %s ORDER BY %s, staging_seq DESC%s",
+		columns,
+		distinct,
+		columns,
+		quoteLiteral(strings.Join(keyColumns, ",")),
+		TEST_DATA_UPSERT_STAGING,
+		distinct,
+		testDataUpsertClause(testDataUpsertColumns(fields), keyColumns),
+	)
+}
+
+// CountUpserted reads the RETURNING rows of an upsert built by this package and closes rows.
+// Each returned row was either inserted or updated; the rest of the batch was unchanged.
+func CountUpserted(rows *sql.Rows, total int) (UpsertCounts, error) {
+	defer rows.Close()
+
+	var counts UpsertCounts
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return UpsertCounts{}, err
+		}
+		if inserted {
+			counts.Inserted++
+		} else {
+			counts.Updated++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return UpsertCounts{}, err
+	}
+
+	counts.Unchanged = total - counts.Inserted - counts.Updated
+	return counts, nil
+}
+
+// testDataUpsertClause is the ON CONFLICT clause shared by the upsert statements. Rows are only
+// rewritten when a value differs, which is what separates updated rows from unchanged ones, and
+// an updated row moves to the load test that last changed it. xmax is zero for freshly inserted rows.
+func testDataUpsertClause(columns []string, keyColumns []string) string {
+	isKey := make(map[string]bool, len(keyColumns))
+	for _, column := range keyColumns {
+		isKey[column] = true
+	}
+
+	var assignments, compared []string
+	for _, column := range columns {
+		if isKey[column] {
+			continue
+		}
+		assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		if column != "load_test_id" {
+			compared = append(compared, column)
+		}
+	}
+
+	clause := fmt.Sprintf(" ON CONFLICT (%s) WHERE %s DO UPDATE SET %s",
+		strings.Join(keyColumns, ", "), naturalKeyPredicate(keyColumns), strings.Join(assignments, ", "))
+	if len(compared) > 0 {
+		current := make([]string, len(compared))
+		excluded := make([]string, len(compared))
+		for i, column := range compared {
+			current[i] = "test_data." + column
+			excluded[i] = "EXCLUDED." + column
+		}
+		clause += fmt.Sprintf(" WHERE (%s) IS DISTINCT FROM (%s)",
+			strings.Join(current, ", "), strings.Join(excluded, ", "))
+	}
+
+	return clause + " RETURNING (xmax = 0) AS inserted"
+}
+
+// testDataUpsertColumns returns load_test_id followed by the database columns of fields
+func testDataUpsertColumns(fields []string) []string {
+	columns := make([]string, 0, len(fields)+1)
+	columns = append(columns, "load_test_id")
+	for _, field := range fields {
+		columns = append(columns, TestDataColumn(field))
+	}
+	return columns
+}
+
+// lastPerNaturalKey keeps the last record for each complete natural key, in batch order
+func lastPerNaturalKey(records []*TestData, fields []string, keyColumns []string) []*TestData {
+	keyFields := make([]string, 0, len(keyColumns))
+	for _, field := range fields {
+		for _, column := range keyColumns {
+			if TestDataColumn(field) == column {
+				keyFields = append(keyFields, field)
+			}
+		}
+	}
+
+	last := make(map[string]int, len(records))
+	keys := make([]*string, len(records))
+	for i, record := range records {
+		parts := make([]string, len(keyFields))
+		for j, field := range keyFields {
+			value := *record.FieldPointer(field)
+			if value == nil {
+				parts = nil
+				break
+			}
+			parts[j] = *value
+		}
+		if parts != nil {
+			key := strings.Join(parts, "\x00")
+			keys[i] = &key
+			last[key] = i
+		}
+	}
+
+	kept := make([]*TestData, 0, len(records))
+	for i, record := range records {
+		if keys[i] == nil || last[*keys[i]] == i {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// naturalKeyIndex names the unique index for a natural key
+func naturalKeyIndex(keyColumns []string) string {
+	return "idx_test_data_upsert_key_" + strings.Join(keyColumns, "_")
+}
+
+// naturalKeyPredicate limits the natural key index, and the conflicts inferred from it, to rows
+// upserted on keyColumns
+func naturalKeyPredicate(keyColumns []string) string {
+	return "upsert_key = " + quoteLiteral(strings.Join(keyColumns, ","))
+}
+
+// quoteLiteral quotes a value as a SQL string literal, for statements that cannot take arguments
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
 func (r *testDataRepository) GetByLoadTestID(
 	ctx context.Context,
 	loadTestID string,
@@ -135,7 +411,8 @@ func (r *testDataRepository) GetByLoadTestIDPaginated(
 	}
 
 	var testData []*TestData
-	query := r.getDB(ctx).Where("load_test_id = ?", loadTestUUID)
+	// IDs are time-ordered, so pages follow insertion order and do not overlap
+	query := r.getDB(ctx).Where("load_test_id = ?", loadTestUUID).Order("id ASC")
 
 	if offset > 0 {
 		query = query.Offset(offset)
@@ -152,6 +429,35 @@ func (r *testDataRepository) GetByLoadTestIDPaginated(
 	return testData, nil
 }
 
+// GetByLoadTestIDAfter returns up to limit rows of a load test whose ID follows afterID, in ID
+// order. Passing the last ID of one page reads the next without the cost of skipping an offset;
+// uuid.Nil reads the first page.
+func (r *testDataRepository) GetByLoadTestIDAfter(
+	ctx context.Context,
+	loadTestID string,
+	afterID uuid.UUID,
+	limit int,
+) ([]*TestData, error) {
+	log := r.log.Function("GetByLoadTestIDAfter")
+
+	loadTestUUID, err := uuid.Parse(loadTestID)
+	if err != nil {
+		return nil, log.Err("failed to parse loadTestID", err, "loadTestID", loadTestID)
+	}
+
+	var testData []*TestData
+	if err := r.getDB(ctx).
+		Where("load_test_id = ? AND id > ?", loadTestUUID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&testData).Error; err != nil {
+		return nil, log.Err("failed to get test data page by load test ID", err,
+			"loadTestID", loadTestID, "afterID", afterID, "limit", limit)
+	}
+
+	return testData, nil
+}
+
 func (r *testDataRepository) CountByLoadTestID(
 	ctx context.Context,
 	loadTestID string,
@@ -233,11 +539,16 @@ func (r *testDataRepository) getDBByID(
 	return nil
 }
 
-// BatchInsertWithCustomColumns allows dynamic column mapping for performance testing scenarios
+// BatchInsertWithCustomColumns allows dynamic column mapping for performance testing scenarios.
+// Record keys are resolved onto TestData fields through the mapping profile's aliases (nil for
+// canonical names only). Map keys have no position, so profiles with positional mappings are
+// rejected, and keys are resolved in sorted order so that the first of two keys naming the same
+// field wins on every row.
 func (r *testDataRepository) BatchInsertWithCustomColumns(
 	ctx context.Context,
 	records []map[string]interface{},
 	loadTestID uuid.UUID,
+	mapping *ColumnMappingProfile,
 	batchSize int,
 ) error {
 	log := r.log.Function("BatchInsertWithCustomColumns")
@@ -245,47 +556,41 @@ func (r *testDataRepository) BatchInsertWithCustomColumns(
 	if len(records) == 0 {
 		return log.Error("empty records provided")
 	}
+	if mapping != nil {
+		for _, columnMapping := range mapping.Mappings {
+			if columnMapping.Index != nil {
+				return log.Error("positional mappings do not apply to keyed records", "field", columnMapping.Field)
+			}
+		}
+	}
 
 	if batchSize <= 0 {
 		batchSize = 1000
 	}
 
 	// Convert map records to TestData structs
+	resolve := mapping.KeyResolver()
 	var testDataBatch []*TestData
 	for _, record := range records {
 		testData := &TestData{
 			LoadTestID: loadTestID,
 		}
 
-		// Map meaningful columns for fixed 25-column structure
-		meaningfulColumns := map[string]**string{
-			"first_name":        &testData.FirstName,
-			"last_name":         &testData.LastName,
-			"email":             &testData.Email,
-			"phone":             &testData.Phone,
-			"address_line_1":    &testData.AddressLine1,
-			"address_line_2":    &testData.AddressLine2,
-			"city":              &testData.City,
-			"state":             &testData.State,
-			"zip_code":          &testData.ZipCode,
-			"country":           &testData.Country,
-			"social_security_no": &testData.SocialSecurityNo,
-			"employer":          &testData.Employer,
-			"job_title":         &testData.JobTitle,
-			"department":        &testData.Department,
-			"salary":            &testData.Salary,
-			"insurance_plan_id": &testData.InsurancePlanID,
-			"insurance_carrier": &testData.InsuranceCarrier,
-			"policy_number":     &testData.PolicyNumber,
-			"group_number":      &testData.GroupNumber,
-			"member_id":         &testData.MemberID,
+		columns := make([]string, 0, len(record))
+		for colName := range record {
+			columns = append(columns, colName)
 		}
-		
-		for colName, fieldPtr := range meaningfulColumns {
-			if val, ok := record[colName]; ok {
-				if str, ok := val.(string); ok {
-					*fieldPtr = &str
-				}
+		slices.Sort(columns)
+
+		assigned := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			field := resolve(column)
+			if field == "" || assigned[field] {
+				continue
+			}
+			if str, ok := record[column].(string); ok {
+				*testData.FieldPointer(field) = &str
+				assigned[field] = true
 			}
 		}
 
@@ -297,50 +602,10 @@ func (r *testDataRepository) BatchInsertWithCustomColumns(
 
 // GetColumnValue retrieves a specific column value from TestData by field name
 func (r *testDataRepository) GetColumnValue(testData *TestData, columnName string) *string {
-	switch columnName {
-	case "first_name":
-		return testData.FirstName
-	case "last_name":
-		return testData.LastName
-	case "email":
-		return testData.Email
-	case "phone":
-		return testData.Phone
-	case "address_line_1":
-		return testData.AddressLine1
-	case "address_line_2":
-		return testData.AddressLine2
-	case "city":
-		return testData.City
-	case "state":
-		return testData.State
-	case "zip_code":
-		return testData.ZipCode
-	case "country":
-		return testData.Country
-	case "social_security_no":
-		return testData.SocialSecurityNo
-	case "employer":
-		return testData.Employer
-	case "job_title":
-		return testData.JobTitle
-	case "department":
-		return testData.Department
-	case "salary":
-		return testData.Salary
-	case "insurance_plan_id":
-		return testData.InsurancePlanID
-	case "insurance_carrier":
-		return testData.InsuranceCarrier
-	case "policy_number":
-		return testData.PolicyNumber
-	case "group_number":
-		return testData.GroupNumber
-	case "member_id":
-		return testData.MemberID
-	default:
-		return nil
+	if field := testData.FieldPointer(columnName); field != nil {
+		return *field
 	}
+	return nil
 }
 
 // SetColumnValue sets a specific column value in TestData by field name
@@ -349,50 +614,10 @@ func (r *testDataRepository) SetColumnValue(
 	columnName string,
 	value *string,
 ) error {
-	switch columnName {
-	case "first_name":
-		testData.FirstName = value
-	case "last_name":
-		testData.LastName = value
-	case "email":
-		testData.Email = value
-	case "phone":
-		testData.Phone = value
-	case "address_line_1":
-		testData.AddressLine1 = value
-	case "address_line_2":
-		testData.AddressLine2 = value
-	case "city":
-		testData.City = value
-	case "state":
-		testData.State = value
-	case "zip_code":
-		testData.ZipCode = value
-	case "country":
-		testData.Country = value
-	case "social_security_no":
-		testData.SocialSecurityNo = value
-	case "employer":
-		testData.Employer = value
-	case "job_title":
-		testData.JobTitle = value
-	case "department":
-		testData.Department = value
-	case "salary":
-		testData.Salary = value
-	case "insurance_plan_id":
-		testData.InsurancePlanID = value
-	case "insurance_carrier":
-		testData.InsuranceCarrier = value
-	case "policy_number":
-		testData.PolicyNumber = value
-	case "group_number":
-		testData.GroupNumber = value
-	case "member_id":
-		testData.MemberID = value
-	default:
+	field := testData.FieldPointer(columnName)
+	if field == nil {
 		return fmt.Errorf("unknown column name: %s", columnName)
 	}
+	*field = value
 	return nil
 }
-
@@ -5,6 +5,7 @@ import (
 	"server/internal/app"
 	"server/internal/handlers"
 	"server/internal/logger"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,6 +13,14 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 	fiberLogs "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	DEFAULT_BODY_LIMIT_MB       = 10
+	DEFAULT_READ_TIMEOUT        = 30 * time.Second
+	DEFAULT_IMPORT_READ_TIMEOUT = 30 * time.Minute
+	IMPORT_PATH                 = "/api/imports"
 )
 
 type AppServer struct {
@@ -23,23 +32,37 @@ func New(app *app.App) (*AppServer, error) {
 	log := logger.New("server").Function("New")
 	log.Info("Initializing server")
 
+	// With request body streaming enabled, BodyLimit only caps how much of a request is
+	// buffered in memory; larger uploads are streamed to the handler.
+	bodyLimit := DEFAULT_BODY_LIMIT_MB * 1024 * 1024
+	if app.Config.ServerBodyLimitMB > 0 {
+		bodyLimit = app.Config.ServerBodyLimitMB * 1024 * 1024
+	}
+
+	// Large imports are read from the connection for the lifetime of the request
+	importReadTimeout := DEFAULT_IMPORT_READ_TIMEOUT
+	if app.Config.ServerImportReadTimeout > 0 {
+		importReadTimeout = time.Duration(app.Config.ServerImportReadTimeout) * time.Second
+	}
+
 	config := fiber.Config{
 		ServerHeader: fmt.Sprintf(
 			"APIServer/%s",
 			app.Config.GeneralVersion,
 		),
-		AppName:                  "vim_server",
-		BodyLimit:                10 * 1024 * 1024,
-		ReadBufferSize:           16384,
-		WriteBufferSize:          16384,
-		StreamRequestBody:        false,
-		EnableSplittingOnParsers: true,
-		EnableTrustedProxyCheck:  true,
-		ReadTimeout:              30 * time.Second,
-		WriteTimeout:             30 * time.Second,
-		IdleTimeout:              120 * time.Second,
-		DisableStartupMessage:    true,
-		EnablePrintRoutes:        false,
+		AppName:                      "vim_server",
+		BodyLimit:                    bodyLimit,
+		ReadBufferSize:               16384,
+		WriteBufferSize:              16384,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		EnableSplittingOnParsers:     true,
+		EnableTrustedProxyCheck:      true,
+		ReadTimeout:                  DEFAULT_READ_TIMEOUT,
+		WriteTimeout:                 30 * time.Second,
+		IdleTimeout:                  120 * time.Second,
+		DisableStartupMessage:        true,
+		EnablePrintRoutes:            false,
 	}
 
 	if app.Config.Environment == "development" {
@@ -49,6 +72,7 @@ func New(app *app.App) (*AppServer, error) {
 	}
 
 	server := fiber.New(config)
+	server.Server().HeaderReceived = importReadDeadline(importReadTimeout)
 
 	server.Use(cors.New(cors.Config{
 		AllowOrigins:     app.Config.CorsAllowOrigins,
@@ -75,6 +99,21 @@ func New(app *app.App) (*AppServer, error) {
 	return fiberApp, nil
 }
 
+// importReadDeadline extends the read deadline of the connection to timeout for import uploads,
+// leaving every other request to the server's ReadTimeout
+func importReadDeadline(timeout time.Duration) func(*fasthttp.RequestHeader) fasthttp.RequestConfig {
+	return func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		path := strings.TrimSuffix(string(header.RequestURI()), "/")
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = strings.TrimSuffix(path[:i], "/")
+		}
+		if header.IsPost() && path == IMPORT_PATH {
+			return fasthttp.RequestConfig{ReadTimeout: timeout}
+		}
+		return fasthttp.RequestConfig{}
+	}
+}
+
 func (s *AppServer) Listen(port int) error {
 	log := s.log.Function("Listen")
 
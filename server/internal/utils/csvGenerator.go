@@ -24,6 +24,7 @@ type CSVGenerationConfig struct {
 	DateColumns      int
 	TempDir          string
 	FilePrefix       string
+	Locale           DateLocale // Language of generated month and weekday names; the usual formats when empty
 	Context          context.Context
 	ProgressCallback CSVProgressCallback // Optional progress callback
 }
@@ -150,7 +151,7 @@ func GeneratePerformanceCSVWithDuplication(config CSVGenerationConfig) (CSVGener
 	if config.ProgressCallback != nil {
 		config.ProgressCallback("csv_generation", 10, fmt.Sprintf("Generating base dataset (%d rows)...", baseRows))
 	}
-	_, err := generateOptimizedBaseCSVWithProgress(config.Context, baseCsvPath, baseRows, config.ProgressCallback)
+	_, err := generateOptimizedBaseCSVWithProgress(config.Context, baseCsvPath, baseRows, config.Locale, config.ProgressCallback)
 	if err != nil {
 		return CSVGenerationResult{}, fmt.Errorf("failed to generate base CSV: %w", err)
 	}
@@ -285,7 +286,7 @@ func generateDirectCSV(config CSVGenerationConfig) (CSVGenerationResult, error)
 			config.ProgressCallback("csv_generation", progress, message)
 		}
 
-		row := generatePerformanceDataRow(allColumns, selectedDateColumnMap, allDateColumnMap, rng)
+		row := generatePerformanceDataRow(allColumns, selectedDateColumnMap, allDateColumnMap, config.Locale, rng)
 		if err := writer.Write(row); err != nil {
 			return CSVGenerationResult{}, fmt.Errorf("failed to write row %d: %w", i, err)
 		}
@@ -331,6 +332,7 @@ func selectRandomDateColumns(dateColumns []string, count int) []string {
 func generatePerformanceDataRow(
 	headers []string,
 	selectedDateColumnMap, allDateColumnMap map[string]bool,
+	locale DateLocale,
 	rng *rand.Rand,
 ) []string {
 	row := make([]string, len(headers))
@@ -338,7 +340,7 @@ func generatePerformanceDataRow(
 	for i, header := range headers {
 		if allDateColumnMap[header] {
 			if selectedDateColumnMap[header] {
-				row[i] = generatePerformanceDateValue(rng)
+				row[i] = generatePerformanceDateValue(locale, rng)
 			} else {
 				row[i] = ""
 			}
@@ -413,7 +415,7 @@ func generateOptimizedBaseCSV(ctx context.Context, csvPath string, rows int) (in
 			}
 		}
 
-		row := generatePerformanceDataRow(headers, allDateColumnMap, allDateColumnMap, rng)
+		row := generatePerformanceDataRow(headers, allDateColumnMap, allDateColumnMap, "", rng)
 		if err := writer.Write(row); err != nil {
 			return 0, fmt.Errorf("failed to write row %d: %w", i, err)
 		}
@@ -423,7 +425,7 @@ func generateOptimizedBaseCSV(ctx context.Context, csvPath string, rows int) (in
 }
 
 // generateOptimizedBaseCSVWithProgress creates the initial base CSV file with progress tracking
-func generateOptimizedBaseCSVWithProgress(ctx context.Context, csvPath string, rows int, progressCallback CSVProgressCallback) (int, error) {
+func generateOptimizedBaseCSVWithProgress(ctx context.Context, csvPath string, rows int, locale DateLocale, progressCallback CSVProgressCallback) (int, error) {
 	startTime := time.Now()
 	
 	file, err := os.Create(csvPath)
@@ -481,7 +483,7 @@ func generateOptimizedBaseCSVWithProgress(ctx context.Context, csvPath string, r
 			progressCallback("csv_generation", totalProgress, message)
 		}
 
-		row := generatePerformanceDataRow(headers, allDateColumnMap, allDateColumnMap, rng)
+		row := generatePerformanceDataRow(headers, allDateColumnMap, allDateColumnMap, locale, rng)
 		if err := writer.Write(row); err != nil {
 			return 0, fmt.Errorf("failed to write row %d: %w", i, err)
 		}
@@ -768,8 +770,9 @@ func addVariationToNumericField(value string, rng *rand.Rand) string {
 	return value
 }
 
-// generatePerformanceDateValue creates a date value optimized for maximum performance
-func generatePerformanceDateValue(rng *rand.Rand) string {
+// generatePerformanceDateValue creates a date value optimized for maximum performance, written in
+// locale's formats when it has its own
+func generatePerformanceDateValue(locale DateLocale, rng *rand.Rand) string {
 	// Fewer formats for better performance
 	formats := []string{
 		"2006-01-02", "01/02/2006", "01-02-2006", "2006/01/02",
@@ -780,6 +783,9 @@ func generatePerformanceDateValue(rng *rand.Rand) string {
 	day := rng.Intn(28) + 1
 
 	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if localized, ok := FormatRandomInLocale(date, locale, rng); ok {
+		return localized
+	}
 	format := formats[rng.Intn(len(formats))]
 
 	return date.Format(format)
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultDateCacheSize is how many distinct values a DateCache keeps by default. Imports repeat a
+// small set of dates, such as report or admission days, far more often than they hold new ones.
+const DefaultDateCacheSize = 4096
+
+// DateCache memoizes a DateValidator's results for repeated values, keeping the most recently
+// used ones up to its capacity. It is safe for concurrent use, so the parser workers of a run
+// can share one.
+type DateCache struct {
+	validator *DateValidator
+	capacity  int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	recent  *list.List // Front is the most recently used
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// dateCacheEntry is a cached result, kept in the recency list
+type dateCacheEntry struct {
+	input  string
+	result ValidationResult
+}
+
+// DateCacheStats counts the lookups a DateCache answered from memory and those it validated
+type DateCacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// HitRate returns the fraction of lookups answered from memory, 0 before any lookup
+func (s DateCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewDateCache creates a DateCache in front of validator holding up to capacity values, or
+// DefaultDateCacheSize if capacity is not positive. The validator must not be reconfigured while
+// the cache is in use, as cached results would no longer match it.
+func NewDateCache(validator *DateValidator, capacity int) *DateCache {
+	if capacity <= 0 {
+		capacity = DefaultDateCacheSize
+	}
+	return &DateCache{
+		validator: validator,
+		capacity:  capacity,
+		entries:   make(map[string]*list.Element, capacity),
+		recent:    list.New(),
+	}
+}
+
+// ValidateAndConvert returns the validator's result for input, validating it only the first time
+// it is seen since it was last evicted
+func (c *DateCache) ValidateAndConvert(input string) ValidationResult {
+	c.mu.Lock()
+	if element, ok := c.entries[input]; ok {
+		c.recent.MoveToFront(element)
+		result := element.Value.(*dateCacheEntry).result
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return result
+	}
+	c.mu.Unlock()
+
+	// Validate outside the lock so workers missing on different values don't wait on each other
+	c.misses.Add(1)
+	result := c.validator.ValidateAndConvert(input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[input]; ok {
+		// Another worker cached it meanwhile
+		c.recent.MoveToFront(element)
+		return result
+	}
+	c.entries[input] = c.recent.PushFront(&dateCacheEntry{input: input, result: result})
+	if c.recent.Len() > c.capacity {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dateCacheEntry).input)
+	}
+	return result
+}
+
+// Len returns how many values are cached
+func (c *DateCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recent.Len()
+}
+
+// Stats returns the hits and misses so far
+func (c *DateCache) Stats() DateCacheStats {
+	return DateCacheStats{
+		Hits:   int(c.hits.Load()),
+		Misses: int(c.misses.Load()),
+	}
+}
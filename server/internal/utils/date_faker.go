@@ -2,7 +2,6 @@ package utils
 
 import (
 	"math/rand"
-	"strconv"
 	"time"
 )
 
@@ -17,6 +16,7 @@ type FakeDataOptions struct {
 	IncludeTime  bool
 	FormatMix    bool // If true, generates variety of formats; if false, uses specific format
 	TargetFormat DateFormat
+	Locale       DateLocale // If set, dates are written in the locale's formats and names
 }
 
 func NewDateFaker() *DateFaker {
@@ -58,7 +58,7 @@ func (df *DateFaker) GenerateFakeDates(options FakeDataOptions) []string {
 			selectedFormat = formats[df.random.Intn(len(formats))]
 		}
 
-		dates[i] = df.formatTimeWithFormat(randomTime, selectedFormat)
+		dates[i] = FormatTimeInLocale(randomTime, selectedFormat, options.Locale)
 	}
 
 	return dates
@@ -68,6 +68,9 @@ func (df *DateFaker) getFormatsToUse(options FakeDataOptions) []DateFormat {
 	if !options.FormatMix && options.TargetFormat != "" {
 		return []DateFormat{options.TargetFormat}
 	}
+	if localeFormats := LocaleFormats(options.Locale); localeFormats != nil {
+		return localeFormats
+	}
 
 	baseFormats := []DateFormat{
 		FormatISO8601Date,
@@ -123,12 +126,7 @@ func (df *DateFaker) getDaysInMonth(year int, month time.Month) int {
 }
 
 func (df *DateFaker) formatTimeWithFormat(t time.Time, format DateFormat) string {
-	switch format {
-	case FormatUnixTime:
-		return strconv.FormatInt(t.Unix(), 10)
-	default:
-		return t.Format(string(format))
-	}
+	return FormatTime(t, format)
 }
 
 // GenerateSpecificFormat generates dates in a specific format
@@ -144,6 +142,18 @@ func (df *DateFaker) GenerateSpecificFormat(format DateFormat, count int) []stri
 	return df.GenerateFakeDates(options)
 }
 
+// GenerateLocalizedDates generates dates in the formats of a locale, with its month and weekday names
+func (df *DateFaker) GenerateLocalizedDates(locale DateLocale, count int) []string {
+	options := FakeDataOptions{
+		Count:     count,
+		StartYear: 1990,
+		EndYear:   2030,
+		FormatMix: true,
+		Locale:    locale,
+	}
+	return df.GenerateFakeDates(options)
+}
+
 // GenerateMixedFormats generates dates in various formats for comprehensive testing
 func (df *DateFaker) GenerateMixedFormats(count int) []string {
 	options := FakeDataOptions{
@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DateLocale is a language whose month and weekday names DateValidator reads
+type DateLocale string
+
+const (
+	LocaleEnglish    DateLocale = "en"
+	LocaleFrench     DateLocale = "fr"
+	LocaleGerman     DateLocale = "de"
+	LocaleSpanish    DateLocale = "es"
+	LocalePortuguese DateLocale = "pt"
+)
+
+// localePack holds how dates are written in one language. Layouts use Go's English names in
+// place of the localized ones, which are translated to English before parsing.
+type localePack struct {
+	months        [12]string
+	shortMonths   [12]string // Abbreviations keep their dot, e.g. "janv."
+	weekdays      [7]string  // From Sunday, as time.Weekday
+	shortWeekdays [7]string
+	fillers       []string     // Words dropped before parsing, e.g. "de" in "2 de enero de 2006"
+	layouts       []DateFormat // Written forms, also generated by DateFaker
+
+	names        map[string]localeName // Keyed by folded localized name
+	parseLayouts []localeLayout
+}
+
+// localeName is the English name a localized name translates to, empty for filler words
+type localeName struct {
+	english string
+	month   bool
+	short   bool // An abbreviation that differs from the full name
+}
+
+// localeLayout pairs a written layout with the form it takes once translated
+type localeLayout struct {
+	written     DateFormat
+	parse       string
+	abbreviated bool
+}
+
+// localeOrder is the order locales are tried in when auto-detecting them. A name shared by two
+// languages, such as "mai", is read by the first whose layouts fit.
+var localeOrder = []DateLocale{LocaleFrench, LocaleGerman, LocaleSpanish, LocalePortuguese}
+
+var localePacks = map[DateLocale]*localePack{
+	LocaleFrench: {
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin",
+			"juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin",
+			"juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:      [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortWeekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		fillers:       []string{"le", "er"}, // "le 1er janvier 2006"
+		layouts: []DateFormat{
+			"2 January 2006",
+			"2 Jan 2006",
+			"Monday 2 January 2006",
+			"Mon 2 Jan 2006",
+		},
+	},
+	LocaleGerman: {
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni",
+			"Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni",
+			"Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortWeekdays: [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		layouts: []DateFormat{
+			"2. January 2006",
+			"2. Jan 2006",
+			"Monday, 2. January 2006",
+			"Mon, 2. Jan 2006",
+		},
+	},
+	LocaleSpanish: {
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio",
+			"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun",
+			"jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:      [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortWeekdays: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		fillers:       []string{"de", "del"},
+		layouts: []DateFormat{
+			"2 de January de 2006",
+			"2 Jan 2006",
+			"Monday, 2 de January de 2006",
+		},
+	},
+	LocalePortuguese: {
+		months: [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+			"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun",
+			"jul", "ago", "set", "out", "nov", "dez"},
+		weekdays: [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira",
+			"quinta-feira", "sexta-feira", "sábado"},
+		shortWeekdays: [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+		fillers:       []string{"de"},
+		layouts: []DateFormat{
+			"2 de January de 2006",
+			"2 Jan 2006",
+			"Monday, 2 de January de 2006",
+		},
+	},
+}
+
+// fullNames expands the abbreviated names of a layout, as translated values always use full names
+var fullNames = strings.NewReplacer("January", "January", "Jan", "January", "Monday", "Monday", "Mon", "Monday")
+
+func init() {
+	for _, pack := range localePacks {
+		pack.names = make(map[string]localeName)
+		for i := range pack.weekdays {
+			weekday := time.Weekday(i).String()
+			pack.names[foldName(pack.shortWeekdays[i])] = localeName{english: weekday, short: true}
+			pack.names[foldName(pack.weekdays[i])] = localeName{english: weekday}
+		}
+		// Months win over weekdays sharing an abbreviation, such as Spanish "mar"
+		for i := range pack.months {
+			month := time.Month(i + 1).String()
+			pack.names[foldName(pack.shortMonths[i])] = localeName{english: month, month: true, short: true}
+			pack.names[foldName(pack.months[i])] = localeName{english: month, month: true}
+		}
+		for _, filler := range pack.fillers {
+			pack.names[filler] = localeName{}
+		}
+
+		// Times of day follow the date
+		for _, suffix := range []string{"", " 15:04", " 15:04:05"} {
+			for _, layout := range pack.layouts {
+				written := layout + DateFormat(suffix)
+				parse, _, _ := pack.translate(string(written))
+				pack.parseLayouts = append(pack.parseLayouts, localeLayout{
+					written:     written,
+					parse:       fullNames.Replace(parse),
+					abbreviated: fullNames.Replace(string(written)) != string(written),
+				})
+			}
+		}
+	}
+}
+
+// NormalizeLocale returns the supported locale of a language tag such as "fr", "fr-CA" or
+// "pt_BR", or "" if the language is not supported
+func NormalizeLocale(tag string) DateLocale {
+	language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	language, _, _ = strings.Cut(language, "_")
+	locale := DateLocale(language)
+	if locale == LocaleEnglish || localePacks[locale] != nil {
+		return locale
+	}
+	return ""
+}
+
+// SupportedLocales returns the locales whose names DateValidator reads besides English
+func SupportedLocales() []DateLocale {
+	return append([]DateLocale(nil), localeOrder...)
+}
+
+// LocaleFormats returns the formats dates are written in for locale, nil for English
+func LocaleFormats(locale DateLocale) []DateFormat {
+	pack := localePacks[locale]
+	if pack == nil {
+		return nil
+	}
+	return append([]DateFormat(nil), pack.layouts...)
+}
+
+// SetLocale reads month and weekday names in locale only, besides English. The empty locale,
+// the default, detects the language of each value; LocaleEnglish reads English names only.
+func (dv *DateValidator) SetLocale(locale DateLocale) {
+	dv.locale = locale
+}
+
+// parseLocalized reads a date with month names in one of the locales, returning the locale it
+// was read in
+func (dv *DateValidator) parseLocalized(input string) (time.Time, DateFormat, DateLocale, bool) {
+	if strings.IndexFunc(input, unicode.IsLetter) < 0 {
+		return time.Time{}, "", "", false
+	}
+	locales := localeOrder
+	if dv.locale != "" {
+		locales = []DateLocale{dv.locale}
+	}
+
+	for _, locale := range locales {
+		pack := localePacks[locale]
+		if pack == nil {
+			continue
+		}
+		translated, hasMonth, abbreviated := pack.translate(input)
+		if !hasMonth {
+			continue
+		}
+		// Written forms differing only in abbreviations translate alike, so the one with the
+		// input's abbreviations is reported
+		for _, matchAbbreviations := range []bool{true, false} {
+			for _, layout := range pack.parseLayouts {
+				if matchAbbreviations && layout.abbreviated != abbreviated {
+					continue
+				}
+				if parsedTime, err := time.Parse(layout.parse, translated); err == nil {
+					return parsedTime, layout.written, locale, true
+				}
+			}
+		}
+	}
+	return time.Time{}, "", "", false
+}
+
+// translate rewrites the localized names in s as full English names and drops the filler words,
+// the commas and the dots ending abbreviations, so values and layouts compare on their content.
+// It also reports whether s names a month and whether it abbreviates a name.
+func (p *localePack) translate(s string) (string, bool, bool) {
+	var out strings.Builder
+	hasMonth, abbreviated := false, false
+	chars := []rune(s)
+	for i := 0; i < len(chars); i++ {
+		if chars[i] == ',' {
+			out.WriteRune(' ')
+			continue
+		}
+		if !unicode.IsLetter(chars[i]) {
+			out.WriteRune(chars[i])
+			continue
+		}
+
+		// A word runs over letters and the hyphens joining them, as in "segunda-feira"
+		start := i
+		for i+1 < len(chars) && (unicode.IsLetter(chars[i+1]) ||
+			chars[i+1] == '-' && i+2 < len(chars) && unicode.IsLetter(chars[i+2])) {
+			i++
+		}
+		word := string(chars[start : i+1])
+		if i+1 < len(chars) && chars[i+1] == '.' {
+			i++
+		}
+
+		if name, ok := p.names[foldName(word)]; ok {
+			// Fillers, such as the "er" of "1er", translate to nothing
+			out.WriteString(name.english)
+			hasMonth = hasMonth || name.month
+			abbreviated = abbreviated || name.short
+		} else {
+			out.WriteString(word)
+		}
+		out.WriteRune(' ')
+	}
+	return strings.Join(strings.Fields(out.String()), " "), hasMonth, abbreviated
+}
+
+// format writes t in layout with the locale's names
+func (p *localePack) format(t time.Time, layout DateFormat) string {
+	var out strings.Builder
+	rest := string(layout)
+	for rest != "" {
+		// Longer names first, as "Jan" is also the start of "January"
+		var name, token string
+		switch {
+		case strings.HasPrefix(rest, "January"):
+			name, token = p.months[t.Month()-1], "January"
+		case strings.HasPrefix(rest, "Jan"):
+			name, token = p.shortMonths[t.Month()-1], "Jan"
+		case strings.HasPrefix(rest, "Monday"):
+			name, token = p.weekdays[t.Weekday()], "Monday"
+		case strings.HasPrefix(rest, "Mon"):
+			name, token = p.shortWeekdays[t.Weekday()], "Mon"
+		}
+		if token != "" {
+			out.WriteString(name)
+			rest = rest[len(token):]
+			continue
+		}
+
+		next := len(rest)
+		for _, token := range []string{"Jan", "Mon"} {
+			if index := strings.Index(rest, token); index > 0 && index < next {
+				next = index
+			}
+		}
+		out.WriteString(t.Format(rest[:next]))
+		rest = rest[next:]
+	}
+	return out.String()
+}
+
+// FormatTimeInLocale formats t in format, writing month and weekday names in locale
+func FormatTimeInLocale(t time.Time, format DateFormat, locale DateLocale) string {
+	pack := localePacks[locale]
+	if pack == nil {
+		return FormatTime(t, format)
+	}
+	return pack.format(t, format)
+}
+
+// FormatRandomInLocale formats t in one of locale's formats picked by rng, with its month and
+// weekday names. It returns false for English and unknown locales, which have no formats of their
+// own.
+func FormatRandomInLocale(t time.Time, locale DateLocale, rng *rand.Rand) (string, bool) {
+	pack := localePacks[locale]
+	if pack == nil {
+		return "", false
+	}
+	return pack.format(t, pack.layouts[rng.Intn(len(pack.layouts))]), true
+}
+
+// foldName lowercases a name and strips its accents, so "Fevrier" and "février" match
+func foldName(name string) string {
+	folded, _, err := transform.String(
+		transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC),
+		strings.TrimSuffix(name, "."),
+	)
+	if err != nil {
+		folded = name
+	}
+	return strings.ToLower(folded)
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateOrder is the order of the day and month in numeric dates such as 03/04/2020
+type DateOrder string
+
+const (
+	DateOrderMonthFirst DateOrder = "mdy" // US order, 03/04/2020 is March 4
+	DateOrderDayFirst   DateOrder = "dmy" // European order, 03/04/2020 is April 3
+)
+
+// A column's order is only inferred from at least dateOrderMinEvidence unambiguous values, of
+// which at least dateOrderMinShare must agree
+const (
+	dateOrderMinEvidence = 3
+	dateOrderMinShare    = 0.9
+)
+
+// numericDayMonthPattern matches dates of two one- or two-digit numbers and a year, with an
+// optional time of day, capturing the numbers, separators, year and time
+var numericDayMonthPattern = regexp.MustCompile(
+	`^(\d{1,2})([/.-])(\d{1,2})([/.-])(\d{4}|\d{2})(?: (\d{1,2}:\d{2}(?::\d{2})?))?$`,
+)
+
+// DateOrderProfile is the evidence for the date order of a column
+type DateOrderProfile struct {
+	Order      DateOrder `json:"order,omitempty"` // Empty when undecided
+	MonthFirst int       `json:"monthFirst"`      // Values only valid with the month first, e.g. 03/14/2020
+	DayFirst   int       `json:"dayFirst"`        // Values only valid with the day first, e.g. 14/03/2020
+	Undecided  int       `json:"undecided"`       // Values valid in either order, e.g. 03/04/2020
+	Ambiguous  bool      `json:"ambiguous"`       // Numeric dates were found but their order could not be inferred
+}
+
+// ProfileDateOrder infers the date order of a column from a sample of its values. Only values
+// with a day above 12 show the order; the column takes the order nearly all of them agree on.
+// The profile is ambiguous when the column holds numeric dates but too few show their order,
+// or they disagree. Columns without numeric dates have no order and are not ambiguous.
+func ProfileDateOrder(values []string) DateOrderProfile {
+	var profile DateOrderProfile
+	for _, value := range values {
+		if !HasDateOrder(value) {
+			continue
+		}
+		matches := numericDayMonthPattern.FindStringSubmatch(strings.TrimSpace(value))
+
+		first, _ := strconv.Atoi(matches[1])
+		second, _ := strconv.Atoi(matches[3])
+		switch {
+		case first < 1 || second < 1 || (first > 12 && second > 12):
+		case first > 12:
+			profile.DayFirst++
+		case second > 12:
+			profile.MonthFirst++
+		default:
+			profile.Undecided++
+		}
+	}
+
+	evidence := profile.MonthFirst + profile.DayFirst
+	if evidence >= dateOrderMinEvidence {
+		switch {
+		case float64(profile.MonthFirst) >= dateOrderMinShare*float64(evidence):
+			profile.Order = DateOrderMonthFirst
+		case float64(profile.DayFirst) >= dateOrderMinShare*float64(evidence):
+			profile.Order = DateOrderDayFirst
+		}
+	}
+	profile.Ambiguous = profile.Order == "" && evidence+profile.Undecided > 0
+	return profile
+}
+
+// HasDateOrder reports whether input is a numeric date whose meaning depends on the date order
+func HasDateOrder(input string) bool {
+	matches := numericDayMonthPattern.FindStringSubmatch(strings.TrimSpace(input))
+	return matches != nil && matches[2] == matches[4]
+}
+
+// ValidateWithOrder validates input like ValidateAndConvert, except that numeric dates such as
+// 03/04/2020 are read in the given order rather than whichever order parses first. Values that
+// only parse in the other order are invalid.
+func (dv *DateValidator) ValidateWithOrder(input string, order DateOrder) ValidationResult {
+	if order == "" || !HasDateOrder(input) {
+		return dv.ValidateAndConvert(input)
+	}
+	matches := numericDayMonthPattern.FindStringSubmatch(strings.TrimSpace(input))
+
+	result := ValidationResult{
+		IsValid:       false,
+		OriginalValue: input,
+	}
+
+	// Build the layout the value is written in, so the canonical formats keep their names
+	first, second := "1", "2"
+	if order == DateOrderDayFirst {
+		first, second = "2", "1"
+	}
+	if len(matches[1]) == 2 {
+		first = "0" + first
+	}
+	if len(matches[3]) == 2 {
+		second = "0" + second
+	}
+	year := "2006"
+	if len(matches[5]) == 2 {
+		year = "06"
+	}
+	layout := first + matches[2] + second + matches[4] + year
+	if clock := matches[6]; clock != "" {
+		if strings.Count(clock, ":") == 2 {
+			layout += " 15:04:05"
+		} else {
+			layout += " 15:04"
+		}
+	}
+
+	parsedTime, err := time.Parse(layout, strings.TrimSpace(input))
+	if err != nil {
+		return result
+	}
+
+	result.IsValid = true
+	result.DetectedFormat = DateFormat(layout)
+	result.ParsedTime = parsedTime
+	result.Precision = precisionOf(result.DetectedFormat, input)
+	result.StandardFormat = parsedTime.Format(string(dv.standardFormat))
+	return result
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+// DatePrecision is the finest unit a date was written to
+type DatePrecision string
+
+const (
+	PrecisionYear   DatePrecision = "year"   // e.g. 1985
+	PrecisionMonth  DatePrecision = "month"  // e.g. 1985-07
+	PrecisionDay    DatePrecision = "day"    // e.g. 1985-07-04
+	PrecisionSecond DatePrecision = "second" // A time of day, e.g. 1985-07-04T09:30:00Z
+)
+
+// precisionOf returns the precision of a value read in format. Serial dates only have a time of
+// day when they have a fraction.
+func precisionOf(format DateFormat, input string) DatePrecision {
+	switch format {
+	case FormatYear:
+		return PrecisionYear
+	case FormatYearMonth, FormatMonthYear:
+		return PrecisionMonth
+	case FormatUnixTime:
+		return PrecisionSecond
+	case FormatExcelSerial, FormatExcelSerial1904:
+		if strings.Contains(input, ".") {
+			return PrecisionSecond
+		}
+		return PrecisionDay
+	}
+	if strings.Contains(string(format), ":04") {
+		return PrecisionSecond
+	}
+	return PrecisionDay
+}
+
+// FormatWithPrecision writes t as ISO 8601 to the given precision, so no day or time of day is
+// made up for a partial date: "1985", "1985-07", "1985-07-04", or RFC 3339 in UTC for times
+func FormatWithPrecision(t time.Time, precision DatePrecision) string {
+	switch precision {
+	case PrecisionYear:
+		return t.Format("2006")
+	case PrecisionMonth:
+		return t.Format("2006-01")
+	case PrecisionDay:
+		return t.Format(time.DateOnly)
+	default:
+		return t.UTC().Format(time.RFC3339)
+	}
+}
+
+// Normalized returns the parsed value as ISO 8601 to its precision, for storage
+func (r ValidationResult) Normalized() string {
+	return FormatWithPrecision(r.ParsedTime, r.Precision)
+}
+
+// IsPartial reports whether the result is missing its day, such as a birth year
+func (r ValidationResult) IsPartial() bool {
+	return r.Precision == PrecisionYear || r.Precision == PrecisionMonth
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+// DSTTransition describes how a wall clock time that a daylight saving transition skips or
+// repeats was resolved
+type DSTTransition string
+
+const (
+	DSTGap     DSTTransition = "gap"     // Skipped by the clocks going forward; moved forward by the gap
+	DSTOverlap DSTTransition = "overlap" // Repeated by the clocks going back; the earlier instant is used
+)
+
+// ValidateInLocation validates input like ValidateWithOrder, reading times without an offset as
+// wall clock time in loc, or UTC when loc is nil. Values with an explicit offset, including Unix
+// timestamps, keep it; zone abbreviations such as CST are resolved in loc. Dates without a time
+// of day are calendar dates rather than instants and stay at midnight UTC.
+func (dv *DateValidator) ValidateInLocation(input string, order DateOrder, loc *time.Location) ValidationResult {
+	result := dv.ValidateWithOrder(input, order)
+	if !result.IsValid {
+		return result
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	result.HasOffset = formatHasOffset(result.DetectedFormat)
+	switch {
+	case result.HasOffset:
+		// Parse only knows the offsets of the abbreviations used in its location
+		if layout := string(result.DetectedFormat); strings.Contains(layout, "MST") {
+			if parsedTime, err := time.ParseInLocation(layout, strings.TrimSpace(input), loc); err == nil {
+				result.ParsedTime = parsedTime
+			}
+		}
+	case result.IsCalendarDate():
+		return result
+	default:
+		result.ParsedTime, result.DST = resolveWallClock(result.ParsedTime, loc)
+	}
+
+	result.StandardFormat = result.ParsedTime.Format(string(dv.standardFormat))
+	return result
+}
+
+// IsCalendarDate reports whether the result is a date without a time of day or an offset
+func (r ValidationResult) IsCalendarDate() bool {
+	if r.HasOffset || strings.Contains(string(r.DetectedFormat), ":04") {
+		return false
+	}
+	return r.ParsedTime.Equal(r.ParsedTime.Truncate(24 * time.Hour))
+}
+
+// formatHasOffset reports whether values in format carry their own offset
+func formatHasOffset(format DateFormat) bool {
+	switch format {
+	case FormatUnixTime, FormatRFC3339:
+		return true
+	}
+	layout := string(format)
+	return strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST")
+}
+
+// resolveWallClock returns the instant the wall clock time of wall shows in loc. A time skipped
+// by a DST gap is moved forward by the length of the gap, and a time repeated by an overlap
+// resolves to its earlier instant, the one still on daylight time.
+func resolveWallClock(wall time.Time, loc *time.Location) (time.Time, DSTTransition) {
+	naive := time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+
+	// A transition changes the offset at most once within a day either side
+	_, offsetBefore := naive.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := naive.Add(24 * time.Hour).In(loc).Zone()
+	before := naive.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+	after := naive.Add(-time.Duration(offsetAfter) * time.Second).In(loc)
+
+	beforeMatches, afterMatches := sameWallClock(before, naive), sameWallClock(after, naive)
+	switch {
+	case beforeMatches && afterMatches && !before.Equal(after):
+		if after.Before(before) {
+			return after, DSTOverlap
+		}
+		return before, DSTOverlap
+	case beforeMatches:
+		return before, ""
+	case afterMatches:
+		return after, ""
+	default:
+		// The offset from before the gap carries the time past it
+		return before, DSTGap
+	}
+}
+
+func sameWallClock(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd &&
+		a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Second() == b.Second()
+}
@@ -56,11 +56,7 @@ func (du *DateUtils) ConvertToAllFormats(input string) ConversionResult {
 	// Convert to all supported formats
 	formats := du.validator.GetSupportedFormats()
 	for _, format := range formats {
-		if format == FormatUnixTime {
-			result.ConvertedValues[format] = fmt.Sprintf("%d", validationResult.ParsedTime.Unix())
-		} else {
-			result.ConvertedValues[format] = validationResult.ParsedTime.Format(string(format))
-		}
+		result.ConvertedValues[format] = FormatTime(validationResult.ParsedTime, format)
 	}
 
 	result.Success = true
@@ -85,11 +81,7 @@ func (du *DateUtils) ConvertToSpecificFormats(input string, targetFormats []Date
 
 	// Convert to specified formats
 	for _, format := range targetFormats {
-		if format == FormatUnixTime {
-			result.ConvertedValues[format] = fmt.Sprintf("%d", validationResult.ParsedTime.Unix())
-		} else {
-			result.ConvertedValues[format] = validationResult.ParsedTime.Format(string(format))
-		}
+		result.ConvertedValues[format] = FormatTime(validationResult.ParsedTime, format)
 	}
 
 	result.Success = true
@@ -105,6 +97,16 @@ func (du *DateUtils) NormalizeDate(input string) (string, error) {
 	return validationResult.StandardFormat, nil
 }
 
+// NormalizeDateInLocation converts a date to the standard format, reading times without an
+// offset as wall clock time in loc. The offset of the input, or of loc, is kept.
+func (du *DateUtils) NormalizeDateInLocation(input string, loc *time.Location) (string, error) {
+	validationResult := du.validator.ValidateInLocation(input, "", loc)
+	if !validationResult.IsValid {
+		return "", fmt.Errorf("invalid date format: %s", input)
+	}
+	return validationResult.StandardFormat, nil
+}
+
 // NormalizeDates normalizes a batch of date strings
 func (du *DateUtils) NormalizeDates(inputs []string) []string {
 	results := make([]string, len(inputs))
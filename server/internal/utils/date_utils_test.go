@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -46,6 +47,121 @@ func TestDateValidator_ValidateAndConvert(t *testing.T) {
 	}
 }
 
+func TestDateValidator_NumericDates(t *testing.T) {
+	validator := NewDateValidator()
+	validator.SetSerialDates(FormatExcelSerial)
+
+	testCases := []struct {
+		input          string
+		shouldBeValid  bool
+		expectedFormat DateFormat
+		expectedTime   time.Time
+	}{
+		{"44927", true, FormatExcelSerial, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"44927.5", true, FormatExcelSerial, time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"59", true, FormatExcelSerial, time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"60", false, "", time.Time{}}, // 1900-02-29 does not exist
+		{"61", true, FormatExcelSerial, time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"20230115", true, FormatCompactDate, time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"19800215", true, FormatCompactDate, time.Date(1980, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{"20230230", false, "", time.Time{}},
+		{"2023015", true, FormatJulianDate, time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024366", true, FormatJulianDate, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"2023366", false, "", time.Time{}},
+		{"2023000", false, "", time.Time{}},
+		{"1673827200", true, FormatUnixTime, time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"125000", false, "", time.Time{}},   // Six digits fit no numeric format
+		{"99999", false, "", time.Time{}},    // Serial date past 2100
+		{"12345678", false, "", time.Time{}}, // Not a valid YYYYMMDD
+		{"-44927", false, "", time.Time{}},
+		{"44927.", false, "", time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := validator.ValidateAndConvert(tc.input)
+
+			if result.IsValid != tc.shouldBeValid {
+				t.Errorf("Expected IsValid=%v for input '%s', got %v",
+					tc.shouldBeValid, tc.input, result.IsValid)
+			}
+
+			if tc.shouldBeValid && result.DetectedFormat != tc.expectedFormat {
+				t.Errorf("Expected format %s for input '%s', got %s",
+					tc.expectedFormat, tc.input, result.DetectedFormat)
+			}
+
+			if tc.shouldBeValid && !result.ParsedTime.Equal(tc.expectedTime) {
+				t.Errorf("Expected time %v for input '%s', got %v",
+					tc.expectedTime, tc.input, result.ParsedTime)
+			}
+		})
+	}
+}
+
+func TestDateValidator_NumericDateAmbiguity(t *testing.T) {
+	// Without serial dates enabled, salary-like numbers must not be read as dates
+	validator := NewDateValidator()
+
+	testCases := []string{"44927", "55000", "55000.50", "125000", "2023", "0"}
+
+	for _, input := range testCases {
+		t.Run(input, func(t *testing.T) {
+			if result := validator.ValidateAndConvert(input); result.IsValid {
+				t.Errorf("Expected '%s' not to be a date, got format %s", input, result.DetectedFormat)
+			}
+		})
+	}
+}
+
+func TestDateValidator_SerialDates1904(t *testing.T) {
+	validator := NewDateValidator()
+	validator.SetSerialDates(FormatExcelSerial1904)
+
+	testCases := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"0", time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"43465", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := validator.ValidateAndConvert(tc.input)
+			if !result.IsValid || result.DetectedFormat != FormatExcelSerial1904 {
+				t.Fatalf("Expected a 1904 serial date for input '%s', got %+v", tc.input, result)
+			}
+			if !result.ParsedTime.Equal(tc.expected) {
+				t.Errorf("Expected time %v for input '%s', got %v", tc.expected, tc.input, result.ParsedTime)
+			}
+		})
+	}
+}
+
+func TestFormatTime_NumericFormats(t *testing.T) {
+	date := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		format   DateFormat
+		expected string
+	}{
+		{FormatExcelSerial, "44927.5"},
+		{FormatExcelSerial1904, "43465.5"},
+		{FormatCompactDate, "20230101"},
+		{FormatJulianDate, "2023001"},
+		{FormatUnixTime, "1672574400"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			if got := FormatTime(date, tc.format); got != tc.expected {
+				t.Errorf("Expected %s for format %s, got %s", tc.expected, tc.format, got)
+			}
+		})
+	}
+}
+
 func TestDateFaker_GenerateFakeDates(t *testing.T) {
 	faker := NewDateFaker()
 	faker.SetSeed(42) // Set seed for reproducible tests
@@ -225,4 +341,344 @@ func TestValidationStats_GetSuccessRate(t *testing.T) {
 	if rate := emptyStats.GetSuccessRate(); rate != 0.0 {
 		t.Errorf("Expected 0.0 for empty stats, got %f", rate)
 	}
-}
\ No newline at end of file
+}
+func TestProfileDateOrder(t *testing.T) {
+	testCases := []struct {
+		name              string
+		values            []string
+		expectedOrder     DateOrder
+		expectedAmbiguous bool
+	}{
+		{"month first", []string{"03/04/2020", "03/14/2020", "12/25/2021", "01/31/2019"}, DateOrderMonthFirst, false},
+		{"day first", []string{"03/04/2020", "14/03/2020", "25.12.2021", "31-01-2019"}, DateOrderDayFirst, false},
+		{"too little evidence", []string{"03/04/2020", "05/06/2020", "14/03/2020"}, "", true},
+		{"conflicting evidence", []string{"14/03/2020", "25/12/2021", "03/14/2020", "12/25/2021"}, "", true},
+		{"no numeric dates", []string{"2020-03-04", "January 2, 2006", ""}, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := ProfileDateOrder(tc.values)
+
+			if profile.Order != tc.expectedOrder {
+				t.Errorf("Expected order %q, got %q", tc.expectedOrder, profile.Order)
+			}
+
+			if profile.Ambiguous != tc.expectedAmbiguous {
+				t.Errorf("Expected Ambiguous=%v, got %v", tc.expectedAmbiguous, profile.Ambiguous)
+			}
+		})
+	}
+}
+
+func TestDateValidator_ValidateWithOrder(t *testing.T) {
+	validator := NewDateValidator()
+
+	testCases := []struct {
+		input          string
+		order          DateOrder
+		shouldBeValid  bool
+		expectedFormat DateFormat
+		expectedTime   time.Time
+	}{
+		{"03/04/2020", DateOrderMonthFirst, true, FormatUSDate, time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"03/04/2020", DateOrderDayFirst, true, FormatEuropeanDate, time.Date(2020, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{"03.04.2020", DateOrderDayFirst, true, FormatDotDate, time.Date(2020, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{"3/4/20", DateOrderDayFirst, true, "2/1/06", time.Date(2020, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{"03/04/2020 10:30:00", DateOrderMonthFirst, true, FormatUSDateTime, time.Date(2020, 3, 4, 10, 30, 0, 0, time.UTC)},
+		{"14/03/2020", DateOrderMonthFirst, false, "", time.Time{}},
+		{"2020-03-04", DateOrderDayFirst, true, FormatISO8601Date, time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input+"/"+string(tc.order), func(t *testing.T) {
+			result := validator.ValidateWithOrder(tc.input, tc.order)
+
+			if result.IsValid != tc.shouldBeValid {
+				t.Errorf("Expected IsValid=%v for input '%s', got %v",
+					tc.shouldBeValid, tc.input, result.IsValid)
+			}
+
+			if tc.shouldBeValid && result.DetectedFormat != tc.expectedFormat {
+				t.Errorf("Expected format %s for input '%s', got %s",
+					tc.expectedFormat, tc.input, result.DetectedFormat)
+			}
+
+			if tc.shouldBeValid && !result.ParsedTime.Equal(tc.expectedTime) {
+				t.Errorf("Expected time %v for input '%s', got %v",
+					tc.expectedTime, tc.input, result.ParsedTime)
+			}
+		})
+	}
+}
+
+func TestDateValidator_ValidateInLocation(t *testing.T) {
+	validator := NewDateValidator()
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		input       string
+		location    *time.Location
+		expectedUTC time.Time
+		expectedDST DSTTransition
+	}{
+		{"naive time in UTC", "01/02/2006 15:04:05", nil, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC), ""},
+		{"naive time in Chicago", "01/02/2006 15:04:05", chicago, time.Date(2006, 1, 2, 21, 4, 5, 0, time.UTC), ""},
+		{"daylight time in Chicago", "2021-07-01 12:00:00", chicago, time.Date(2021, 7, 1, 17, 0, 0, 0, time.UTC), ""},
+		{"explicit offset wins", "2021-07-01T12:00:00+02:00", chicago, time.Date(2021, 7, 1, 10, 0, 0, 0, time.UTC), ""},
+		{"unix time is absolute", "1673827200", chicago, time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC), ""},
+		{"calendar date is not shifted", "2021-07-01", chicago, time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"gap moves forward", "2021-03-14 02:30:00", chicago, time.Date(2021, 3, 14, 8, 30, 0, 0, time.UTC), DSTGap},
+		{"overlap takes the earlier instant", "2021-11-07 01:30:00", chicago, time.Date(2021, 11, 7, 6, 30, 0, 0, time.UTC), DSTOverlap},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateInLocation(tc.input, "", tc.location)
+
+			if !result.IsValid {
+				t.Fatalf("Expected input '%s' to be valid", tc.input)
+			}
+
+			if !result.ParsedTime.Equal(tc.expectedUTC) {
+				t.Errorf("Expected %v for input '%s', got %v",
+					tc.expectedUTC, tc.input, result.ParsedTime.UTC())
+			}
+
+			if result.DST != tc.expectedDST {
+				t.Errorf("Expected DST transition %q for input '%s', got %q",
+					tc.expectedDST, tc.input, result.DST)
+			}
+		})
+	}
+}
+
+func TestDateUtils_NormalizeDateInLocation(t *testing.T) {
+	utils := NewDateUtils()
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"01/02/2006 15:04:05", "2006-01-02T15:04:05-06:00"},
+		{"2021-07-01T12:00:00+02:00", "2021-07-01T12:00:00+02:00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			normalized, err := utils.NormalizeDateInLocation(tc.input, chicago)
+			if err != nil {
+				t.Fatalf("Unexpected error for input '%s': %v", tc.input, err)
+			}
+			if normalized != tc.expected {
+				t.Errorf("Expected %s for input '%s', got %s", tc.expected, tc.input, normalized)
+			}
+		})
+	}
+}
+
+func TestDateValidator_Locales(t *testing.T) {
+	validator := NewDateValidator()
+
+	testCases := []struct {
+		input          string
+		expectedLocale DateLocale
+		expectedDate   string
+	}{
+		{"2 janvier 2006", LocaleFrench, "2006-01-02"},
+		{"le 1er février 2021", LocaleFrench, "2021-02-01"},
+		{"lundi 14 fevrier 2022", LocaleFrench, "2022-02-14"},
+		{"15 déc. 2020 14:30", LocaleFrench, "2020-12-15"},
+		{"02. Jan. 2006", LocaleGerman, "2006-01-02"},
+		{"Montag, 3. März 2025", LocaleGerman, "2025-03-03"},
+		{"2 de enero de 2006", LocaleSpanish, "2006-01-02"},
+		{"martes, 5 de mayo de 2020", LocaleSpanish, "2020-05-05"},
+		{"25 de dezembro de 2019", LocalePortuguese, "2019-12-25"},
+		{"segunda-feira, 7 de março de 2022", LocalePortuguese, "2022-03-07"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := validator.ValidateAndConvert(tc.input)
+
+			if !result.IsValid {
+				t.Fatalf("Expected %q to be valid", tc.input)
+			}
+
+			if result.Locale != tc.expectedLocale {
+				t.Errorf("Expected locale %q, got %q", tc.expectedLocale, result.Locale)
+			}
+
+			if date := result.ParsedTime.Format("2006-01-02"); date != tc.expectedDate {
+				t.Errorf("Expected %s, got %s", tc.expectedDate, date)
+			}
+		})
+	}
+
+	// English names are not localized
+	if result := validator.ValidateAndConvert("January 2, 2006"); !result.IsValid || result.Locale != "" {
+		t.Errorf("Expected English date without a locale, got %+v", result)
+	}
+
+	// A selected locale reads only its own names
+	validator.SetLocale(LocaleGerman)
+	if result := validator.ValidateAndConvert("2 janvier 2006"); result.IsValid {
+		t.Errorf("Expected French date to be invalid in the German locale")
+	}
+	if result := validator.ValidateAndConvert("2. Mai 2006"); !result.IsValid || result.Locale != LocaleGerman {
+		t.Errorf("Expected German date to be valid in the German locale, got %+v", result)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	testCases := map[string]DateLocale{
+		"fr":    LocaleFrench,
+		"fr-CA": LocaleFrench,
+		"pt_BR": LocalePortuguese,
+		"DE":    LocaleGerman,
+		"en-US": LocaleEnglish,
+		"it":    "",
+		"":      "",
+	}
+
+	for tag, expected := range testCases {
+		if locale := NormalizeLocale(tag); locale != expected {
+			t.Errorf("NormalizeLocale(%q) = %q, expected %q", tag, locale, expected)
+		}
+	}
+}
+
+func TestDateFaker_GenerateLocalizedDates(t *testing.T) {
+	faker := NewDateFaker()
+	faker.SetSeed(42)
+
+	for _, locale := range SupportedLocales() {
+		validator := NewDateValidator()
+		validator.SetLocale(locale)
+
+		for _, date := range faker.GenerateLocalizedDates(locale, 50) {
+			result := validator.ValidateAndConvert(date)
+			if !result.IsValid || result.Locale != locale {
+				t.Errorf("Expected generated %s date %q to be read back in its locale, got %+v", locale, date, result)
+			}
+		}
+	}
+
+	if date := FormatTimeInLocale(time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC), "Mon, 2. Jan 2006", LocaleGerman); date != "Mo., 2. Jan. 2006" {
+		t.Errorf("Expected Mo., 2. Jan. 2006, got %s", date)
+	}
+}
+
+func TestGeneratePerformanceDateValue_Locale(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	for _, locale := range append(SupportedLocales(), LocaleEnglish, "") {
+		validator := NewDateValidator()
+		validator.SetLocale(locale)
+
+		for range 50 {
+			date := generatePerformanceDateValue(locale, rng)
+			result := validator.ValidateAndConvert(date)
+			if !result.IsValid {
+				t.Errorf("Expected generated %q date %q to be valid, got %+v", locale, date, result)
+			}
+			if _, localized := localePacks[locale]; localized && result.Locale != locale {
+				t.Errorf("Expected generated %s date %q to be read back in its locale, got %+v", locale, date, result)
+			}
+		}
+	}
+}
+
+func TestDateValidator_Precision(t *testing.T) {
+	validator := NewDateValidator()
+	validator.SetYearDates(true)
+	validator.SetSerialDates(FormatExcelSerial)
+
+	testCases := []struct {
+		input              string
+		expectedPrecision  DatePrecision
+		expectedNormalized string
+	}{
+		{"1985", PrecisionYear, "1985"},
+		{"1985-07", PrecisionMonth, "1985-07"},
+		{"07/1985", PrecisionMonth, "1985-07"},
+		{"07/04/1985", PrecisionDay, "1985-07-04"},
+		{"19850704", PrecisionDay, "1985-07-04"},
+		{"2 janvier 2006", PrecisionDay, "2006-01-02"},
+		{"44927", PrecisionDay, "2023-01-01"},
+		{"44927.5", PrecisionSecond, "2023-01-01T12:00:00Z"},
+		{"2006-01-02T15:04:05+02:00", PrecisionSecond, "2006-01-02T13:04:05Z"},
+		{"1136214245", PrecisionSecond, "2006-01-02T15:04:05Z"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := validator.ValidateAndConvert(tc.input)
+
+			if !result.IsValid {
+				t.Fatalf("Expected %q to be valid", tc.input)
+			}
+
+			if result.Precision != tc.expectedPrecision {
+				t.Errorf("Expected precision %s, got %s", tc.expectedPrecision, result.Precision)
+			}
+
+			if normalized := result.Normalized(); normalized != tc.expectedNormalized {
+				t.Errorf("Expected %s, got %s", tc.expectedNormalized, normalized)
+			}
+		})
+	}
+
+	// Partial dates are not given a made-up day in the standard format either
+	if result := validator.ValidateAndConvert("1985-07"); result.StandardFormat != "1985-07" {
+		t.Errorf("Expected standard format 1985-07, got %s", result.StandardFormat)
+	}
+
+	// Bare years stay off by default
+	if result := NewDateValidator().ValidateAndConvert("1985"); result.IsValid {
+		t.Errorf("Expected 1985 not to be a date without year dates enabled")
+	}
+}
+
+func TestDateCache(t *testing.T) {
+	cache := NewDateCache(NewDateValidator(), 2)
+
+	testCases := []struct {
+		input          string
+		expectedHits   int
+		expectedMisses int
+	}{
+		{"2023-01-01", 0, 1},
+		{"2023-01-01", 1, 1},
+		{"01/02/2023", 1, 2},
+		{"2023-01-01", 2, 2},
+		{"invalid", 2, 3}, // Evicts 01/02/2023, the least recently used
+		{"01/02/2023", 2, 4},
+		{"invalid", 3, 4}, // Invalid results are cached too
+	}
+
+	for _, tc := range testCases {
+		result := cache.ValidateAndConvert(tc.input)
+		if expected := NewDateValidator().ValidateAndConvert(tc.input); result != expected {
+			t.Errorf("Expected cached result for %s to match the validator's", tc.input)
+		}
+
+		stats := cache.Stats()
+		if stats.Hits != tc.expectedHits || stats.Misses != tc.expectedMisses {
+			t.Errorf("After %s expected %d hits and %d misses, got %d and %d",
+				tc.input, tc.expectedHits, tc.expectedMisses, stats.Hits, stats.Misses)
+		}
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("Expected the cache to hold 2 values, got %d", cache.Len())
+	}
+}
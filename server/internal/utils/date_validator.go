@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -11,27 +11,45 @@ import (
 type DateFormat string
 
 const (
-	FormatISO8601      DateFormat = "2006-01-02T15:04:05Z07:00"
-	FormatISO8601Date  DateFormat = "2006-01-02"
-	FormatUSDate       DateFormat = "01/02/2006"
-	FormatUSDateTime   DateFormat = "01/02/2006 15:04:05"
-	FormatEuropeanDate DateFormat = "02/01/2006"
-	FormatDashDate     DateFormat = "02-01-2006"
-	FormatDotDate      DateFormat = "02.01.2006"
-	FormatUnixTime     DateFormat = "unix"
-	FormatRFC3339      DateFormat = "2006-01-02T15:04:05Z"
-	FormatRFC822       DateFormat = "02 Jan 06 15:04 MST"
-	FormatRFC850       DateFormat = "Monday, 02-Jan-06 15:04:05 MST"
-	FormatMonthDay     DateFormat = "January 2, 2006"
-	FormatShortMonth   DateFormat = "Jan 2, 2006"
-	FormatYearMonth    DateFormat = "2006-01"
-	FormatTime24       DateFormat = "15:04:05"
-	FormatTime12       DateFormat = "3:04:05 PM"
+	FormatISO8601         DateFormat = "2006-01-02T15:04:05Z07:00"
+	FormatISO8601Date     DateFormat = "2006-01-02"
+	FormatUSDate          DateFormat = "01/02/2006"
+	FormatUSDateTime      DateFormat = "01/02/2006 15:04:05"
+	FormatEuropeanDate    DateFormat = "02/01/2006"
+	FormatDashDate        DateFormat = "02-01-2006"
+	FormatDotDate         DateFormat = "02.01.2006"
+	FormatUnixTime        DateFormat = "unix"
+	FormatExcelSerial     DateFormat = "excel_serial"
+	FormatExcelSerial1904 DateFormat = "excel_serial_1904"
+	FormatCompactDate     DateFormat = "20060102"
+	FormatJulianDate      DateFormat = "2006002"
+	FormatRFC3339         DateFormat = "2006-01-02T15:04:05Z"
+	FormatRFC822          DateFormat = "02 Jan 06 15:04 MST"
+	FormatRFC850          DateFormat = "Monday, 02-Jan-06 15:04:05 MST"
+	FormatMonthDay        DateFormat = "January 2, 2006"
+	FormatShortMonth      DateFormat = "Jan 2, 2006"
+	FormatYearMonth       DateFormat = "2006-01"
+	FormatMonthYear       DateFormat = "01/2006"
+	FormatYear            DateFormat = "2006"
+	FormatTime24          DateFormat = "15:04:05"
+	FormatTime12          DateFormat = "3:04:05 PM"
+)
+
+// excelMaxSerial is the serial number of 9999-12-31, the last date Excel can represent
+const excelMaxSerial = 2958465
+
+// Bare numbers are only read as dates within this range of years
+const (
+	numericDateMinYear = 1900
+	numericDateMaxYear = 2100
 )
 
 type DateValidator struct {
 	supportedFormats []DateFormat
 	standardFormat   DateFormat
+	serialFormat     DateFormat
+	yearDates        bool
+	locale           DateLocale // Language of month and weekday names, detected when empty
 }
 
 type ValidationResult struct {
@@ -40,6 +58,10 @@ type ValidationResult struct {
 	ParsedTime     time.Time
 	StandardFormat string
 	OriginalValue  string
+	HasOffset      bool          // The input fixed its own offset; set by ValidateInLocation
+	DST            DSTTransition // How a wall clock time skipped or repeated by DST was resolved
+	Locale         DateLocale    // Language of the month and weekday names; empty when not localized
+	Precision      DatePrecision // Finest unit the input was written to
 }
 
 func NewDateValidator() *DateValidator {
@@ -53,12 +75,18 @@ func NewDateValidator() *DateValidator {
 			FormatDashDate,
 			FormatDotDate,
 			FormatUnixTime,
+			FormatExcelSerial,
+			FormatExcelSerial1904,
+			FormatCompactDate,
+			FormatJulianDate,
 			FormatRFC3339,
 			FormatRFC822,
 			FormatRFC850,
 			FormatMonthDay,
 			FormatShortMonth,
 			FormatYearMonth,
+			FormatMonthYear,
+			FormatYear,
 			FormatTime24,
 			FormatTime12,
 		},
@@ -70,6 +98,21 @@ func (dv *DateValidator) SetStandardFormat(format DateFormat) {
 	dv.standardFormat = format
 }
 
+// SetSerialDates reads bare day counts as Excel serial dates in the date system of format,
+// FormatExcelSerial or FormatExcelSerial1904, or stops reading them when format is empty.
+// Serial dates are off by default because any small number, such as a salary, is also a
+// plausible serial date, so they should only be enabled for values known to be dates.
+func (dv *DateValidator) SetSerialDates(format DateFormat) {
+	dv.serialFormat = format
+}
+
+// SetYearDates reads bare four-digit numbers from 1900 to 2100 as years, such as a birth year
+// of 1985, ahead of reading them as serial dates. Like serial dates they are off by default, as
+// any such number is also a plausible amount.
+func (dv *DateValidator) SetYearDates(enabled bool) {
+	dv.yearDates = enabled
+}
+
 func (dv *DateValidator) ValidateAndConvert(input string) ValidationResult {
 	result := ValidationResult{
 		IsValid:       false,
@@ -81,21 +124,21 @@ func (dv *DateValidator) ValidateAndConvert(input string) ValidationResult {
 		return result
 	}
 
-	// Try Unix timestamp first (integer)
-	if unixTime, err := strconv.ParseInt(input, 10, 64); err == nil {
-		if unixTime > 0 && unixTime < 4102444800 { // Valid range: 1970-2100
-			parsedTime := time.Unix(unixTime, 0).UTC()
+	// Bare numbers are either a numeric date or not a date at all
+	if isNumericInput(input) {
+		if parsedTime, format, ok := dv.parseNumericDate(input); ok {
 			result.IsValid = true
-			result.DetectedFormat = FormatUnixTime
+			result.DetectedFormat = format
 			result.ParsedTime = parsedTime
-			result.StandardFormat = parsedTime.Format(string(dv.standardFormat))
-			return result
+			result.Precision = precisionOf(format, input)
+			result.StandardFormat = dv.formatStandard(parsedTime, result.Precision)
 		}
+		return result
 	}
 
 	// Try each supported format
 	for _, format := range dv.supportedFormats {
-		if format == FormatUnixTime {
+		if isNumericFormat(format) {
 			continue // Already handled above
 		}
 
@@ -105,24 +148,188 @@ func (dv *DateValidator) ValidateAndConvert(input string) ValidationResult {
 				result.IsValid = true
 				result.DetectedFormat = format
 				result.ParsedTime = parsedTime
-				result.StandardFormat = parsedTime.Format(string(dv.standardFormat))
+				result.Precision = precisionOf(format, input)
+				result.StandardFormat = dv.formatStandard(parsedTime, result.Precision)
 				return result
 			}
 		}
 	}
 
+	// Try month and weekday names in other languages
+	if parsedTime, format, locale, ok := dv.parseLocalized(input); ok {
+		result.IsValid = true
+		result.DetectedFormat = format
+		result.ParsedTime = parsedTime
+		result.Precision = precisionOf(format, input)
+		result.StandardFormat = dv.formatStandard(parsedTime, result.Precision)
+		result.Locale = locale
+		return result
+	}
+
 	// Try some flexible parsing patterns
 	if parsedTime, format := dv.tryFlexibleParsing(input); !parsedTime.IsZero() {
 		result.IsValid = true
 		result.DetectedFormat = format
 		result.ParsedTime = parsedTime
-		result.StandardFormat = parsedTime.Format(string(dv.standardFormat))
+		result.Precision = precisionOf(format, input)
+		result.StandardFormat = dv.formatStandard(parsedTime, result.Precision)
 		return result
 	}
 
 	return result
 }
 
+// formatStandard writes t in the standard format, except that partial dates are written to
+// their precision rather than given a made-up day
+func (dv *DateValidator) formatStandard(t time.Time, precision DatePrecision) string {
+	if precision == PrecisionYear || precision == PrecisionMonth {
+		return FormatWithPrecision(t, precision)
+	}
+	return t.Format(string(dv.standardFormat))
+}
+
+// parseNumericDate reads a bare number as a date. The number of digits decides the format, so a
+// value is never read two ways: eight digits are YYYYMMDD, seven are Julian YYYYDDD, nine or ten
+// are Unix seconds, four are a year when enabled, and up to five, with an optional time of day
+// as a fraction, are Excel serial dates when enabled. Numbers of any other length, or that give
+// a year outside 1900-2100, are not dates.
+func (dv *DateValidator) parseNumericDate(input string) (time.Time, DateFormat, bool) {
+	digits, _, fractional := strings.Cut(input, ".")
+	if fractional && dv.serialFormat == "" {
+		return time.Time{}, "", false
+	}
+
+	var parsedTime time.Time
+	var format DateFormat
+	switch {
+	case !fractional && len(digits) == 4 && dv.yearDates:
+		// Four-digit serial dates fall in 1902-1927, so a year is the likelier reading
+		year, _ := strconv.Atoi(digits)
+		parsedTime = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		format = FormatYear
+	case fractional || len(digits) <= 5:
+		if dv.serialFormat == "" {
+			return time.Time{}, "", false
+		}
+		serial, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return time.Time{}, "", false
+		}
+		var ok bool
+		if parsedTime, ok = ExcelSerialToTime(serial, dv.serialFormat == FormatExcelSerial1904); !ok {
+			return time.Time{}, "", false
+		}
+		format = dv.serialFormat
+	case len(digits) == 7:
+		year, _ := strconv.Atoi(digits[:4])
+		day, _ := strconv.Atoi(digits[4:])
+		parsedTime = time.Date(year, 1, day, 0, 0, 0, 0, time.UTC)
+		if day < 1 || parsedTime.Year() != year {
+			return time.Time{}, "", false
+		}
+		format = FormatJulianDate
+	case len(digits) == 8:
+		var err error
+		if parsedTime, err = time.Parse(string(FormatCompactDate), digits); err != nil {
+			return time.Time{}, "", false
+		}
+		format = FormatCompactDate
+	case len(digits) == 9 || len(digits) == 10:
+		unixTime, _ := strconv.ParseInt(digits, 10, 64)
+		parsedTime = time.Unix(unixTime, 0).UTC()
+		format = FormatUnixTime
+	default:
+		return time.Time{}, "", false
+	}
+
+	if parsedTime.Year() < numericDateMinYear || parsedTime.Year() > numericDateMaxYear {
+		return time.Time{}, "", false
+	}
+	return parsedTime, format, true
+}
+
+// isNumericInput reports whether input is an unsigned number, optionally with a fraction
+func isNumericInput(input string) bool {
+	digits, fraction, _ := strings.Cut(input, ".")
+	return digits != "" && isDigits(digits) && (fraction == "" || isDigits(fraction)) &&
+		!strings.HasSuffix(input, ".")
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumericFormat reports whether format is read by parseNumericDate rather than time.Parse
+func isNumericFormat(format DateFormat) bool {
+	switch format {
+	case FormatUnixTime, FormatExcelSerial, FormatExcelSerial1904, FormatCompactDate, FormatJulianDate,
+		FormatYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExcelSerialToTime converts an Excel serial date, the days since the workbook's epoch with the
+// time of day as a fraction, to a time. The 1900 date system counts the nonexistent 1900-02-29
+// as day 60, which is rejected, and the 1904 system starts at day 0 on 1904-01-01.
+func ExcelSerialToTime(serial float64, date1904 bool) (time.Time, bool) {
+	if math.IsNaN(serial) || serial < 0 || serial > excelMaxSerial {
+		return time.Time{}, false
+	}
+
+	days := math.Floor(serial)
+	var epoch time.Time
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	case days < 1 || days == 60:
+		return time.Time{}, false
+	case days < 60:
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	default:
+		// Skips the nonexistent 1900-02-29
+		epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	}
+
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second), true
+}
+
+// timeToExcelSerial is the inverse of ExcelSerialToTime
+func timeToExcelSerial(t time.Time, date1904 bool) float64 {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	case midnight.Before(time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)):
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	days := math.Round(midnight.Sub(epoch).Hours() / 24)
+	return days + t.Sub(midnight).Seconds()/(24*60*60)
+}
+
+// FormatTime formats t in format, including the numeric formats that are not time layouts
+func FormatTime(t time.Time, format DateFormat) string {
+	switch format {
+	case FormatUnixTime:
+		return strconv.FormatInt(t.Unix(), 10)
+	case FormatExcelSerial, FormatExcelSerial1904:
+		return strconv.FormatFloat(timeToExcelSerial(t, format == FormatExcelSerial1904), 'f', -1, 64)
+	default:
+		return t.Format(string(format))
+	}
+}
+
 func (dv *DateValidator) isValidForFormat(input string, format DateFormat) bool {
 	switch format {
 	case FormatUSDate, FormatUSDateTime:
@@ -297,12 +504,5 @@ func (dv *DateValidator) ValidateBatch(inputs []string) []ValidationResult {
 
 // GetFormatExample returns an example of the given format
 func GetFormatExample(format DateFormat) string {
-	now := time.Now()
-	
-	switch format {
-	case FormatUnixTime:
-		return fmt.Sprintf("%d", now.Unix())
-	default:
-		return now.Format(string(format))
-	}
+	return FormatTime(time.Now(), format)
 }
\ No newline at end of file
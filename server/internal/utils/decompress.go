@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats recognised on import
+const (
+	COMPRESSION_NONE = "none"
+	COMPRESSION_GZIP = "gzip"
+	COMPRESSION_ZSTD = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// DetectCompression returns the compression format whose magic bytes start header
+func DetectCompression(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return COMPRESSION_GZIP
+	case bytes.HasPrefix(header, zstdMagic):
+		return COMPRESSION_ZSTD
+	default:
+		return COMPRESSION_NONE
+	}
+}
+
+// newDecompressor peeks at the start of source and returns a reader of its decompressed content
+func newDecompressor(source *bufio.Reader) (io.ReadCloser, string, error) {
+	header, err := source.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("failed to read input header: %w", err)
+	}
+
+	compression := DetectCompression(header)
+	switch compression {
+	case COMPRESSION_GZIP:
+		reader, err := gzip.NewReader(source)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		return reader, compression, nil
+	case COMPRESSION_ZSTD:
+		decoder, err := zstd.NewReader(source, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open zstd stream: %w", err)
+		}
+		return decoder.IOReadCloser(), compression, nil
+	default:
+		return io.NopCloser(source), compression, nil
+	}
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Character encodings recognised on import. Everything is transcoded to UTF-8 before parsing.
+const (
+	ENCODING_UTF8         = "utf-8"
+	ENCODING_UTF16LE      = "utf-16le"
+	ENCODING_UTF16BE      = "utf-16be"
+	ENCODING_WINDOWS_1252 = "windows-1252"
+)
+
+// encodingSampleSize is how much of a source DetectEncoding is given
+const encodingSampleSize = 4096
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// NormalizeEncoding returns the canonical name of a supported encoding, accepting common aliases
+// such as "UTF8", "cp1252" and "latin1", or "" if the encoding is not supported
+func NormalizeEncoding(name string) string {
+	switch strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(name)) {
+	case "utf8":
+		return ENCODING_UTF8
+	case "utf16", "utf16le":
+		return ENCODING_UTF16LE
+	case "utf16be":
+		return ENCODING_UTF16BE
+	case "windows1252", "cp1252", "latin1", "iso88591":
+		// ISO-8859-1 is read as its Windows-1252 superset, as browsers do
+		return ENCODING_WINDOWS_1252
+	default:
+		return ""
+	}
+}
+
+// DetectEncoding guesses the encoding of a source from a sample of its first bytes. A byte order
+// mark is trusted first. Without one, text with NUL bytes concentrated in alternate positions is
+// taken as UTF-16, valid UTF-8 as UTF-8, and anything else as Windows-1252.
+func DetectEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, utf8BOM):
+		return ENCODING_UTF8
+	case bytes.HasPrefix(sample, utf16LEBOM):
+		return ENCODING_UTF16LE
+	case bytes.HasPrefix(sample, utf16BEBOM):
+		return ENCODING_UTF16BE
+	}
+
+	if encoding := detectUTF16(sample); encoding != "" {
+		return encoding
+	}
+
+	// A full sample may end part way through a multi-byte character. A shorter one is the whole
+	// source, so a character left incomplete at its end is not UTF-8.
+	for i := len(sample) - 1; len(sample) >= encodingSampleSize && i >= len(sample)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(sample[i]) {
+			if !utf8.FullRune(sample[i:]) {
+				sample = sample[:i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(sample) {
+		return ENCODING_UTF8
+	}
+	return ENCODING_WINDOWS_1252
+}
+
+// detectUTF16 recognises BOM-less UTF-16 by its NUL bytes. Mostly-ASCII text in UTF-16LE has a
+// NUL in every odd position and UTF-16BE in every even one, while NULs never appear in text
+// encoded as UTF-8 or Windows-1252.
+func detectUTF16(sample []byte) string {
+	if len(sample) < 2 {
+		return ""
+	}
+
+	var evenNULs, oddNULs int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNULs++
+		} else {
+			oddNULs++
+		}
+	}
+
+	pairs := len(sample) / 2
+	switch {
+	case oddNULs*10 >= pairs*4 && evenNULs*10 < pairs:
+		return ENCODING_UTF16LE
+	case evenNULs*10 >= pairs*4 && oddNULs*10 < pairs:
+		return ENCODING_UTF16BE
+	default:
+		return ""
+	}
+}
+
+// NewUTF8Reader returns a reader of input transcoded from the given encoding to UTF-8, dropping
+// any byte order mark. Bytes that cannot be decoded become U+FFFD replacement characters.
+func NewUTF8Reader(input io.Reader, name string) (io.Reader, error) {
+	var decoder *encoding.Decoder
+	switch name {
+	case ENCODING_UTF8:
+		decoder = unicode.UTF8BOM.NewDecoder()
+	case ENCODING_UTF16LE:
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case ENCODING_UTF16BE:
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case ENCODING_WINDOWS_1252:
+		decoder = charmap.Windows1252.NewDecoder()
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+
+	return transform.NewReader(input, decoder), nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encodeUTF16(t *testing.T, text string, endianness unicode.Endianness) string {
+	t.Helper()
+	encoded, err := unicode.UTF16(endianness, unicode.IgnoreBOM).NewEncoder().String(text)
+	if err != nil {
+		t.Fatalf("Failed to encode %q as UTF-16: %v", text, err)
+	}
+	return encoded
+}
+
+func TestDetectEncoding(t *testing.T) {
+	csv := "first_name,last_name,birth_date\nAnn,Lee,1985-07-01\n"
+	// fullSample pads text at the front to a full sample, so it ends where the sample is cut
+	fullSample := func(text string) string {
+		return strings.Repeat("a", encodingSampleSize-len(text)) + text
+	}
+
+	testCases := []struct {
+		name     string
+		sample   string
+		expected string
+	}{
+		{"empty", "", ENCODING_UTF8},
+		{"ascii", csv, ENCODING_UTF8},
+		{"utf-8", "first_name\nRenée\nJürgen\n", ENCODING_UTF8},
+		{"utf-8 bom", "\xef\xbb\xbf" + csv, ENCODING_UTF8},
+		{"utf-16le bom", "\xff\xfe" + encodeUTF16(t, csv, unicode.LittleEndian), ENCODING_UTF16LE},
+		{"utf-16be bom", "\xfe\xff" + encodeUTF16(t, csv, unicode.BigEndian), ENCODING_UTF16BE},
+		{"utf-16le without bom", encodeUTF16(t, csv, unicode.LittleEndian), ENCODING_UTF16LE},
+		{"utf-16be without bom", encodeUTF16(t, csv, unicode.BigEndian), ENCODING_UTF16BE},
+		{"utf-16le accented without bom", encodeUTF16(t, "Renée,Jürgen,Zoë\n", unicode.LittleEndian), ENCODING_UTF16LE},
+		{"utf-16le cut mid character", encodeUTF16(t, csv, unicode.LittleEndian)[:25], ENCODING_UTF16LE},
+		{"windows-1252 accented", "first_name\nRen\xe9e\nJ\xfcrgen\n", ENCODING_WINDOWS_1252},
+		{"windows-1252 smart quotes", "note\n\x93quoted\x94 \x96 dash\n", ENCODING_WINDOWS_1252},
+		{"windows-1252 final byte", "first_name\nRen\xe9", ENCODING_WINDOWS_1252},
+		{"utf-8 cut mid character", fullSample("\nRen\xc3"), ENCODING_UTF8},
+		{"utf-8 cut mid emoji", fullSample("\nok \xf0\x9f\x98"), ENCODING_UTF8},
+		{"windows-1252 cut after accent", fullSample("\nRen\xe9e\nJ\xfc"), ENCODING_WINDOWS_1252},
+		{"single nul", "a\x00b,c\n", ENCODING_UTF8},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := DetectEncoding([]byte(tc.sample))
+			if result != tc.expected {
+				t.Errorf("Expected encoding %s for %q, got %s", tc.expected, tc.sample, result)
+			}
+		})
+	}
+}
+
+func TestNormalizeEncoding(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"UTF-8", ENCODING_UTF8},
+		{"utf8", ENCODING_UTF8},
+		{"UTF-16", ENCODING_UTF16LE},
+		{"utf_16le", ENCODING_UTF16LE},
+		{"UTF-16BE", ENCODING_UTF16BE},
+		{"Windows-1252", ENCODING_WINDOWS_1252},
+		{"cp1252", ENCODING_WINDOWS_1252},
+		{"Latin1", ENCODING_WINDOWS_1252},
+		{"ISO-8859-1", ENCODING_WINDOWS_1252},
+		{"shift_jis", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := NormalizeEncoding(tc.input)
+			if result != tc.expected {
+				t.Errorf("Expected %q for input '%s', got %q", tc.expected, tc.input, result)
+			}
+		})
+	}
+}
+
+func TestNewUTF8Reader(t *testing.T) {
+	text := "first_name\nRenée\n"
+
+	testCases := []struct {
+		name     string
+		input    string
+		encoding string
+	}{
+		{"utf-8", text, ENCODING_UTF8},
+		{"utf-8 bom", "\xef\xbb\xbf" + text, ENCODING_UTF8},
+		{"utf-16le bom", "\xff\xfe" + encodeUTF16(t, text, unicode.LittleEndian), ENCODING_UTF16LE},
+		{"utf-16le without bom", encodeUTF16(t, text, unicode.LittleEndian), ENCODING_UTF16LE},
+		{"utf-16be without bom", encodeUTF16(t, text, unicode.BigEndian), ENCODING_UTF16BE},
+		{"windows-1252", "first_name\nRen\xe9e\n", ENCODING_WINDOWS_1252},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := NewUTF8Reader(strings.NewReader(tc.input), tc.encoding)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			result, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result) != text {
+				t.Errorf("Expected %q, got %q", text, result)
+			}
+		})
+	}
+
+	if _, err := NewUTF8Reader(strings.NewReader(text), "shift_jis"); err == nil {
+		t.Error("Expected an error for an unsupported encoding")
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+)
+
+// FixedWidthReader reads records laid out in fixed character positions, one per line, as rows
+// over a fixed set of columns. The source has no header line; the column names are returned as
+// the header row instead. Blank lines are skipped.
+type FixedWidthReader struct {
+	reader     *bufio.Reader
+	columns    []string
+	split      func(line string) []string
+	headerSent bool
+	line       int
+	offset     int64
+}
+
+// NewFixedWidthReader creates a FixedWidthReader whose header row is columns. split cuts a line
+// into one value per column.
+func NewFixedWidthReader(
+	input io.Reader,
+	columns []string,
+	split func(line string) []string,
+) *FixedWidthReader {
+	return &FixedWidthReader{
+		reader:  bufio.NewReaderSize(input, 64*1024),
+		columns: slices.Clone(columns),
+		split:   split,
+	}
+}
+
+// SkipHeader stops the reader returning the header row, for sources resumed part way through
+func (r *FixedWidthReader) SkipHeader() {
+	r.headerSent = true
+}
+
+// Read returns the header row first, then one row per line
+func (r *FixedWidthReader) Read() ([]string, error) {
+	if !r.headerSent {
+		r.headerSent = true
+		return slices.Clone(r.columns), nil
+	}
+
+	for {
+		data, err := r.reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(data) == 0 && err != nil {
+			return nil, io.EOF
+		}
+
+		r.offset += int64(len(data))
+		r.line++
+
+		// Padding is significant, so only the line ending is removed
+		data = strings.TrimRight(data, "\r\n")
+		if r.line == 1 {
+			data = strings.TrimPrefix(data, "\ufeff")
+		}
+		if strings.TrimSpace(data) == "" {
+			continue
+		}
+
+		return r.split(data), nil
+	}
+}
+
+// FieldPos returns the line of the last record. Column positions come from the layout.
+func (r *FixedWidthReader) FieldPos(field int) (line, column int) {
+	return r.line, 1
+}
+
+// InputOffset returns the byte offset just past the last line read
+func (r *FixedWidthReader) InputOffset() int64 {
+	return r.offset
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"server/internal/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFixedWidthReader_Read(t *testing.T) {
+	layout := models.FixedWidthLayout{
+		{Name: "member_id", Start: 1, Length: 6},
+		{Name: "last_name", Start: 7, Length: 8},
+		{Name: "salary", Start: 15, Length: 7, Trim: models.FIXED_WIDTH_TRIM_LEFT, Pad: "0"},
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected [][]string
+	}{
+		{
+			"header row first",
+			"M00001Lee     0052000\n",
+			[][]string{{"member_id", "last_name", "salary"}, {"M00001", "Lee", "52000"}},
+		},
+		{
+			"empty input has only the header",
+			"",
+			[][]string{{"member_id", "last_name", "salary"}},
+		},
+		{
+			"byte order mark",
+			"\ufeffM00001Lee     0052000\n",
+			[][]string{{"member_id", "last_name", "salary"}, {"M00001", "Lee", "52000"}},
+		},
+		{
+			"blank lines are skipped",
+			"\nM00001Lee     0052000\n   \r\n\nM00002Ray     0048000",
+			[][]string{{"member_id", "last_name", "salary"}, {"M00001", "Lee", "52000"}, {"M00002", "Ray", "48000"}},
+		},
+		{
+			"crlf line endings",
+			"M00001Lee     0052000\r\nM00002Ray     0048000\r\n",
+			[][]string{{"member_id", "last_name", "salary"}, {"M00001", "Lee", "52000"}, {"M00002", "Ray", "48000"}},
+		},
+		{
+			"trailing spaces stripped in transfer",
+			"M00001Lee\n",
+			[][]string{{"member_id", "last_name", "salary"}, {"M00001", "Lee", ""}},
+		},
+		{
+			"multi-byte characters count once",
+			"M00001Müller  0052000\n",
+			[][]string{{"member_id", "last_name", "salary"}, {"M00001", "Müller", "52000"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewFixedWidthReader(strings.NewReader(tc.input), layout.Names(), layout.Split)
+
+			var records [][]string
+			for {
+				record, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				records = append(records, record)
+			}
+			if !slices.EqualFunc(records, tc.expected, slices.Equal) {
+				t.Errorf("Expected records %q, got %q", tc.expected, records)
+			}
+		})
+	}
+}
+
+func TestFixedWidthReader_SkipHeader(t *testing.T) {
+	layout := models.FixedWidthLayout{{Name: "member_id", Start: 1, Length: 6}}
+	reader := NewFixedWidthReader(strings.NewReader("M00001\nM00002\n"), layout.Names(), layout.Split)
+	reader.SkipHeader()
+
+	record, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slices.Equal(record, []string{"M00001"}) {
+		t.Errorf("Expected the first record after SkipHeader, got %q", record)
+	}
+}
+
+func TestFixedWidthReader_Position(t *testing.T) {
+	layout := models.FixedWidthLayout{{Name: "member_id", Start: 1, Length: 6}}
+	first := "M00001\r\n"
+	blank := "\n"
+	second := "M00002"
+	reader := NewFixedWidthReader(strings.NewReader(first+blank+second), layout.Names(), layout.Split)
+	reader.SkipHeader()
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if line, _ := reader.FieldPos(0); line != 1 {
+		t.Errorf("Expected first record on line 1, got %d", line)
+	}
+	if offset := reader.InputOffset(); offset != int64(len(first)) {
+		t.Errorf("Expected offset %d after the first record, got %d", len(first), offset)
+	}
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if line, _ := reader.FieldPos(0); line != 3 {
+		t.Errorf("Expected second record on line 3, got %d", line)
+	}
+	if offset := reader.InputOffset(); offset != int64(len(first+blank+second)) {
+		t.Errorf("Expected offset %d after the second record, got %d", len(first+blank+second), offset)
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// ProgressReader wraps an io.Reader and tracks how many bytes have been consumed.
+// It is used to report progress for streamed imports whose row count is not known up front.
+// After Decompress and Transcode, Read returns the decoded stream while progress still counts
+// the bytes consumed from the source.
+type ProgressReader struct {
+	reader      io.Reader
+	bytesRead   atomic.Int64
+	total       int64
+	decoded     io.Reader // Set by Decompress and Transcode
+	closer      io.Closer // Releases the decompressor
+	compression string
+	encoding    string
+}
+
+// NewProgressReader creates a ProgressReader. total is the expected size in bytes, or 0 if unknown.
+func NewProgressReader(reader io.Reader, total int64) *ProgressReader {
+	return &ProgressReader{
+		reader: reader,
+		total:  total,
+	}
+}
+
+// Read implements io.Reader
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	if r.decoded != nil {
+		return r.decoded.Read(p)
+	}
+	return r.readSource(p)
+}
+
+func (r *ProgressReader) readSource(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bytesRead.Add(int64(n))
+	return n, err
+}
+
+// Decompress detects a gzip or zstd source by its magic bytes and decompresses it in-stream from
+// then on. Uncompressed sources are read unchanged. It must be called before the first Read.
+func (r *ProgressReader) Decompress() error {
+	source := bufio.NewReaderSize(sourceReader{r}, 64*1024)
+	decoded, compression, err := newDecompressor(source)
+	if err != nil {
+		return err
+	}
+
+	r.decoded = decoded
+	r.closer = decoded
+	r.compression = compression
+	return nil
+}
+
+// Transcode converts the stream to UTF-8 from the given encoding, or from the encoding detected
+// from its byte order mark or content when encoding is empty. It returns the encoding used. Call
+// it after Decompress and before the first Read.
+func (r *ProgressReader) Transcode(encoding string) (string, error) {
+	var source io.Reader = sourceReader{r}
+	if r.decoded != nil {
+		source = r.decoded
+	}
+
+	buffered := bufio.NewReaderSize(source, 64*1024)
+	if encoding == "" {
+		sample, err := buffered.Peek(encodingSampleSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read input sample: %w", err)
+		}
+		encoding = DetectEncoding(sample)
+	}
+
+	decoded, err := NewUTF8Reader(buffered, encoding)
+	if err != nil {
+		return "", err
+	}
+
+	r.decoded = decoded
+	r.encoding = encoding
+	return encoding, nil
+}
+
+// Encoding returns the encoding Transcode converted from, or "" before Transcode is called
+func (r *ProgressReader) Encoding() string {
+	return r.encoding
+}
+
+// Compression returns the compression Decompress detected, COMPRESSION_NONE for plain sources
+// and "" before Decompress is called
+func (r *ProgressReader) Compression() string {
+	return r.compression
+}
+
+// Close releases the decompressor, if any. The underlying reader is not closed.
+func (r *ProgressReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// sourceReader reads the underlying source of a ProgressReader, counting the bytes read
+type sourceReader struct {
+	progress *ProgressReader
+}
+
+func (s sourceReader) Read(p []byte) (int, error) {
+	return s.progress.readSource(p)
+}
+
+// BytesRead returns the number of bytes consumed so far
+func (r *ProgressReader) BytesRead() int64 {
+	return r.bytesRead.Load()
+}
+
+// Total returns the expected size in bytes, or 0 if unknown
+func (r *ProgressReader) Total() int64 {
+	return r.total
+}
+
+// Fraction returns the completed fraction in the range [0, 1], or 0 if the total is unknown
+func (r *ProgressReader) Fraction() float64 {
+	if r.total <= 0 {
+		return 0
+	}
+
+	fraction := float64(r.bytesRead.Load()) / float64(r.total)
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+)
+
+// RecordReader reads an import source as rows of text fields. The first row holds the headers.
+// *csv.Reader satisfies it, so CSV sources need no wrapper.
+type RecordReader interface {
+	// Read returns the next row, a *csv.ParseError for a row that cannot be parsed, or io.EOF
+	Read() ([]string, error)
+	// FieldPos returns the line and column the given field of the last row started on
+	FieldPos(field int) (line, column int)
+	// InputOffset returns the byte offset just past the last row read
+	InputOffset() int64
+}
+
+// NDJSONReader reads newline-delimited JSON objects as rows over a fixed set of columns. Each
+// object is flattened into named values, and every name is resolved onto one of the columns;
+// values whose name resolves to no column are dropped. Blank lines are skipped.
+type NDJSONReader struct {
+	reader     *bufio.Reader
+	columns    []string
+	columnPos  map[string]int
+	flatten    func(object map[string]any, emit func(key, value string))
+	resolve    func(key string) string
+	resolved   map[string]int
+	headerSent bool
+	line       int
+	offset     int64
+}
+
+// NewNDJSONReader creates an NDJSONReader whose header row is columns. flatten emits the named
+// values of an object and resolve maps a name onto a column, returning "" to drop it.
+func NewNDJSONReader(
+	input io.Reader,
+	columns []string,
+	flatten func(object map[string]any, emit func(key, value string)),
+	resolve func(key string) string,
+) *NDJSONReader {
+	columnPos := make(map[string]int, len(columns))
+	for i, column := range columns {
+		columnPos[column] = i
+	}
+
+	return &NDJSONReader{
+		reader:    bufio.NewReaderSize(input, 64*1024),
+		columns:   slices.Clone(columns),
+		columnPos: columnPos,
+		flatten:   flatten,
+		resolve:   resolve,
+		resolved:  make(map[string]int),
+	}
+}
+
+// SkipHeader stops the reader returning the header row, for sources resumed part way through
+func (r *NDJSONReader) SkipHeader() {
+	r.headerSent = true
+}
+
+// Read returns the header row first, then one row per JSON object
+func (r *NDJSONReader) Read() ([]string, error) {
+	if !r.headerSent {
+		r.headerSent = true
+		return slices.Clone(r.columns), nil
+	}
+
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(data) == 0 && err != nil {
+			return nil, io.EOF
+		}
+
+		r.offset += int64(len(data))
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if r.line == 1 {
+			data = bytes.TrimPrefix(data, []byte("\ufeff"))
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		return r.parse(data)
+	}
+}
+
+func (r *NDJSONReader) parse(data []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil, r.parseError(err)
+	}
+	if object == nil {
+		return nil, r.parseError(errors.New("expected a JSON object"))
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, r.parseError(errors.New("unexpected data after JSON object"))
+	}
+
+	record := make([]string, len(r.columns))
+	r.flatten(object, func(key, value string) {
+		// The first value resolved onto a column wins
+		if pos := r.column(key); pos >= 0 && record[pos] == "" {
+			record[pos] = value
+		}
+	})
+	return record, nil
+}
+
+// column returns the position of the column key resolves to, or -1
+func (r *NDJSONReader) column(key string) int {
+	if pos, ok := r.resolved[key]; ok {
+		return pos
+	}
+
+	pos, ok := r.columnPos[r.resolve(key)]
+	if !ok {
+		pos = -1
+	}
+	r.resolved[key] = pos
+	return pos
+}
+
+func (r *NDJSONReader) parseError(err error) error {
+	return &csv.ParseError{StartLine: r.line, Line: r.line, Column: 1, Err: err}
+}
+
+// FieldPos returns the line of the last object. Every field of an object starts on its line.
+func (r *NDJSONReader) FieldPos(field int) (line, column int) {
+	return r.line, 1
+}
+
+// InputOffset returns the byte offset just past the last line read
+func (r *NDJSONReader) InputOffset() int64 {
+	return r.offset
+}
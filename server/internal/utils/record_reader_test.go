@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"server/internal/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// flattenSorted emits the top-level values of an object in key order, so precedence between keys
+// that resolve onto the same column is deterministic
+func flattenSorted(object map[string]any, emit func(key, value string)) {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		emit(key, fmt.Sprint(object[key]))
+	}
+}
+
+// resolveAliases maps camelCase aliases onto their columns and drops unknown keys
+func resolveAliases(key string) string {
+	switch key {
+	case "first_name", "firstName":
+		return "first_name"
+	case "member_id", "memberId":
+		return "member_id"
+	}
+	return ""
+}
+
+func readAllRecords(reader *NDJSONReader) ([][]string, error) {
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+func TestNDJSONReader_Read(t *testing.T) {
+	columns := []string{"first_name", "member_id"}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected [][]string
+	}{
+		{
+			"header row first",
+			`{"first_name":"Ann","member_id":"M1"}` + "\n",
+			[][]string{{"first_name", "member_id"}, {"Ann", "M1"}},
+		},
+		{
+			"empty input has only the header",
+			"",
+			[][]string{{"first_name", "member_id"}},
+		},
+		{
+			"byte order mark",
+			"\ufeff" + `{"first_name":"Ann"}` + "\n",
+			[][]string{{"first_name", "member_id"}, {"Ann", ""}},
+		},
+		{
+			"blank lines are skipped",
+			"\n" + `{"first_name":"Ann"}` + "\n\n  \r\n" + `{"first_name":"Bob"}`,
+			[][]string{{"first_name", "member_id"}, {"Ann", ""}, {"Bob", ""}},
+		},
+		{
+			"crlf line endings",
+			`{"first_name":"Ann"}` + "\r\n" + `{"first_name":"Bob"}` + "\r\n",
+			[][]string{{"first_name", "member_id"}, {"Ann", ""}, {"Bob", ""}},
+		},
+		{
+			"numbers keep their text",
+			`{"member_id":12345678901234567890}`,
+			[][]string{{"first_name", "member_id"}, {"", "12345678901234567890"}},
+		},
+		{
+			"unknown keys are dropped",
+			`{"first_name":"Ann","nickname":"A"}`,
+			[][]string{{"first_name", "member_id"}, {"Ann", ""}},
+		},
+		{
+			"first resolved value wins",
+			`{"firstName":"Alias","first_name":"Canonical"}`,
+			[][]string{{"first_name", "member_id"}, {"Alias", ""}},
+		},
+		{
+			"empty value does not claim its column",
+			`{"firstName":"","first_name":"Canonical"}`,
+			[][]string{{"first_name", "member_id"}, {"Canonical", ""}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewNDJSONReader(strings.NewReader(tc.input), columns, flattenSorted, resolveAliases)
+
+			records, err := readAllRecords(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !slices.EqualFunc(records, tc.expected, slices.Equal) {
+				t.Errorf("Expected records %q, got %q", tc.expected, records)
+			}
+		})
+	}
+}
+
+func TestNDJSONReader_MappingProfile(t *testing.T) {
+	profile := &models.ColumnMappingProfile{
+		Mappings: models.ColumnMappings{
+			{Field: "first_name", Aliases: []string{"given"}},
+			{Field: "member_id", Aliases: []string{"subscriber.id"}},
+		},
+		Flatten: models.FlattenRules{Arrays: models.FLATTEN_ARRAYS_FIRST},
+	}
+	columns := []string{"first_name", "member_id"}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"alias", `{"given":"Ann"}`, []string{"Ann", ""}},
+		{"nested alias", `{"subscriber":{"id":"S1"}}`, []string{"", "S1"}},
+		{"field name sorts before alias", `{"given":"Alias","first_name":"Canonical"}`, []string{"Canonical", ""}},
+		{"field name sorts before nested alias", `{"subscriber":{"id":"S1"},"member_id":"M1"}`, []string{"", "M1"}},
+		{"nested key sorts before field name", `{"member":{"id":"N1"},"member_id":"M1"}`, []string{"", "N1"}},
+		{"null does not claim its column", `{"first_name":null,"given":"Ann"}`, []string{"Ann", ""}},
+		{"first array element", `{"given":["Ann","Bob"]}`, []string{"Ann", ""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewNDJSONReader(strings.NewReader(tc.input), columns, profile.Flatten.Flatten, profile.KeyResolver())
+			reader.SkipHeader()
+
+			record, err := reader.Read()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !slices.Equal(record, tc.expected) {
+				t.Errorf("Expected record %q, got %q", tc.expected, record)
+			}
+		})
+	}
+}
+
+func TestNDJSONReader_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        string
+		expectedLine int
+		expectedErr  string
+	}{
+		{"trailing object", `{"first_name":"Ann"} {"first_name":"Bob"}`, 1, "unexpected data after JSON object"},
+		{"trailing text", `{"first_name":"Ann"} x`, 1, "unexpected data after JSON object"},
+		{"null", "null", 1, "expected a JSON object"},
+		{"array", `["Ann"]`, 1, "cannot unmarshal array"},
+		{"truncated object", `{"first_name":"Ann"`, 1, "unexpected EOF"},
+		{"after blank lines", "\n\n" + `{"first_name":`, 3, "unexpected EOF"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewNDJSONReader(strings.NewReader(tc.input), []string{"first_name"}, flattenSorted, resolveAliases)
+			reader.SkipHeader()
+
+			_, err := reader.Read()
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *csv.ParseError, got %v", err)
+			}
+			if parseErr.Line != tc.expectedLine {
+				t.Errorf("Expected line %d, got %d", tc.expectedLine, parseErr.Line)
+			}
+			if !strings.Contains(parseErr.Err.Error(), tc.expectedErr) {
+				t.Errorf("Expected error containing %q, got %q", tc.expectedErr, parseErr.Err)
+			}
+		})
+	}
+}
+
+func TestNDJSONReader_SkipHeader(t *testing.T) {
+	input := `{"first_name":"Ann"}` + "\n" + `{"first_name":"Bob"}` + "\n"
+	reader := NewNDJSONReader(strings.NewReader(input), []string{"first_name"}, flattenSorted, resolveAliases)
+	reader.SkipHeader()
+
+	records, err := readAllRecords(reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := [][]string{{"Ann"}, {"Bob"}}
+	if !slices.EqualFunc(records, expected, slices.Equal) {
+		t.Errorf("Expected records %q, got %q", expected, records)
+	}
+}
+
+func TestNDJSONReader_Position(t *testing.T) {
+	first := `{"first_name":"Ann"}` + "\n"
+	blank := "\n"
+	second := `{"first_name":"Bob"}`
+	reader := NewNDJSONReader(strings.NewReader(first+blank+second), []string{"first_name"}, flattenSorted, resolveAliases)
+	reader.SkipHeader()
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if line, _ := reader.FieldPos(0); line != 1 {
+		t.Errorf("Expected first object on line 1, got %d", line)
+	}
+	if offset := reader.InputOffset(); offset != int64(len(first)) {
+		t.Errorf("Expected offset %d after the first object, got %d", len(first), offset)
+	}
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if line, _ := reader.FieldPos(0); line != 3 {
+		t.Errorf("Expected second object on line 3, got %d", line)
+	}
+	if offset := reader.InputOffset(); offset != int64(len(first+blank+second)) {
+		t.Errorf("Expected offset %d after the second object, got %d", len(first+blank+second), offset)
+	}
+}
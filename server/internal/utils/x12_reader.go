@@ -0,0 +1,530 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// x12HeaderSize is the fixed length of an ISA segment including its terminator. The separators
+// of the interchange are read from fixed positions within it.
+const x12HeaderSize = 106
+
+// X12EnvelopeError describes a problem with the interchange (ISA/IEA), functional group (GS/GE)
+// or transaction set (ST/SE) envelopes of an X12 source
+type X12EnvelopeError struct {
+	Segment       string // Segment ID, e.g. "SE"
+	Position      int    // One-based position of the segment in the source
+	ControlNumber string // Control number of the envelope, when known
+	Message       string
+}
+
+// x12Envelope tracks an open ISA, GS or ST envelope
+type x12Envelope struct {
+	controlNumber string
+	position      int
+	count         int // Groups in an interchange, sets in a group, or segments in a set
+}
+
+// X12Reader reads ANSI X12 834 benefit enrollment transactions as one row per member over a
+// fixed set of columns, named as canonical TestData fields. Envelope counts and control numbers
+// are checked as the source is read and problems are passed to the envelope error callback.
+//
+// Members are mapped from their loops as follows:
+//   - INS starts a member; REF*0F is member_id and REF*1L is group_number
+//   - NM1*IL gives first_name, last_name and, with an SSN qualifier, social_security_no,
+//     with its N3, N4, PER and DMG segments giving the address, phone, email and birth_date
+//   - the first HD coverage gives insurance_plan_id, with REF*1L as policy_number
+//   - DTP*348/349 of that coverage, or DTP*356/357 of the member, give start_date and end_date
+//   - N1*P5 of the transaction set is the employer and N1*IN the insurance_carrier
+//
+// Dates are converted from their X12 format qualifier to ISO 8601, leaving validation to the
+// date normalizer. FieldPos reports the position of the member's INS segment, not a line.
+type X12Reader struct {
+	reader          *bufio.Reader
+	columns         []string
+	columnPos       map[string]int
+	onEnvelopeError func(X12EnvelopeError)
+	elementSep      string
+	terminator      byte
+	started         bool
+	headerSent      bool
+	position        int
+	offset          int64
+
+	interchange *x12Envelope
+	group       *x12Envelope
+	set         *x12Envelope
+	skipSet     bool   // The open transaction set is not an 834
+	stray       int    // Segments read outside a transaction set since the last envelope segment
+	strayID     string // Segment ID of the first of them
+	strayPos    int    // Position of the first of them
+	sponsor     string
+	carrier     string
+
+	member      []string
+	memberPos   int
+	returnedPos int
+	nameLoop    string // Entity code of the current NM1 loop of the member
+	coverages   int    // HD loops seen for the member
+}
+
+// NewX12Reader creates an X12Reader whose header row is columns. onEnvelopeError is called with
+// every envelope problem found and may be nil.
+func NewX12Reader(
+	input io.Reader,
+	columns []string,
+	onEnvelopeError func(X12EnvelopeError),
+) *X12Reader {
+	columnPos := make(map[string]int, len(columns))
+	for i, column := range columns {
+		columnPos[column] = i
+	}
+
+	return &X12Reader{
+		reader:          bufio.NewReaderSize(input, 64*1024),
+		columns:         slices.Clone(columns),
+		columnPos:       columnPos,
+		onEnvelopeError: onEnvelopeError,
+	}
+}
+
+// Read returns the header row first, then one row per member
+func (r *X12Reader) Read() ([]string, error) {
+	if !r.headerSent {
+		r.headerSent = true
+		return slices.Clone(r.columns), nil
+	}
+
+	if !r.started {
+		if err := r.readInterchangeHeader(); err != nil {
+			return nil, err
+		}
+		r.started = true
+	}
+
+	for {
+		segment, err := r.readSegment()
+		if errors.Is(err, io.EOF) {
+			r.closeAll()
+			if record := r.finishMember(); record != nil {
+				return record, nil
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record := r.apply(segment); record != nil {
+			return record, nil
+		}
+	}
+}
+
+// readInterchangeHeader reads the separators from the leading ISA segment and applies it
+func (r *X12Reader) readInterchangeHeader() error {
+	for {
+		b, err := r.reader.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("not an X12 interchange: source is empty")
+			}
+			return err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		_, _ = r.reader.ReadByte()
+		r.offset++
+	}
+
+	header, err := r.reader.Peek(x12HeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if len(header) < x12HeaderSize || string(header[:3]) != "ISA" {
+		return errors.New("not an X12 interchange: expected an ISA segment")
+	}
+
+	r.elementSep = string(header[3])
+	r.terminator = header[x12HeaderSize-1]
+	return nil
+}
+
+// readSegment returns the elements of the next non-empty segment
+func (r *X12Reader) readSegment() ([]string, error) {
+	for {
+		data, err := r.reader.ReadString(r.terminator)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(data) == 0 && err != nil {
+			return nil, io.EOF
+		}
+		r.offset += int64(len(data))
+
+		// Segments are commonly followed by a line break for readability
+		data = strings.TrimSpace(strings.TrimSuffix(data, string(r.terminator)))
+		if data == "" {
+			continue
+		}
+
+		r.position++
+		if r.set != nil {
+			r.set.count++
+		}
+		return strings.Split(data, r.elementSep), nil
+	}
+}
+
+// apply updates the reader state with a segment and returns the previous member when the
+// segment completes it
+func (r *X12Reader) apply(segment []string) []string {
+	id := segment[0]
+	element := func(i int) string {
+		if i < len(segment) {
+			return strings.TrimSpace(segment[i])
+		}
+		return ""
+	}
+
+	switch id {
+	case "ISA", "IEA", "GS", "GE", "ST", "SE":
+		r.reportStray()
+	}
+
+	switch id {
+	case "ISA":
+		if r.interchange != nil {
+			r.envelopeError(id, r.interchange.controlNumber, "interchange has no IEA trailer")
+		}
+		r.interchange = &x12Envelope{controlNumber: element(13), position: r.position}
+		return nil
+	case "IEA":
+		record := r.closeGroup("IEA")
+		r.closeInterchange(element(1), element(2))
+		return record
+	case "GS":
+		if r.interchange == nil {
+			r.envelopeError(id, element(6), "functional group outside an interchange")
+		} else {
+			r.interchange.count++
+		}
+		record := r.closeGroup(id)
+		r.group = &x12Envelope{controlNumber: element(6), position: r.position}
+		return record
+	case "GE":
+		record := r.closeSet(id)
+		r.closeGroupTrailer(element(1), element(2))
+		return record
+	case "ST":
+		record := r.closeSet(id)
+		if r.group == nil {
+			r.envelopeError(id, element(2), "transaction set outside a functional group")
+		} else {
+			r.group.count++
+		}
+		r.set = &x12Envelope{controlNumber: element(2), position: r.position, count: 1}
+		r.skipSet = element(1) != "834"
+		if r.skipSet {
+			r.envelopeError(id, element(2), fmt.Sprintf("transaction set %s is not an 834, skipped", element(1)))
+		}
+		r.sponsor, r.carrier = "", ""
+		return record
+	case "SE":
+		record := r.finishMember()
+		r.closeSetTrailer(element(1), element(2))
+		return record
+	}
+
+	if r.set == nil {
+		if r.stray == 0 {
+			r.strayID, r.strayPos = id, r.position
+		}
+		r.stray++
+		return nil
+	}
+	if r.skipSet {
+		return nil
+	}
+
+	if id == "INS" {
+		record := r.finishMember()
+		r.member = make([]string, len(r.columns))
+		r.memberPos = r.position
+		r.nameLoop = ""
+		r.coverages = 0
+		r.setField("employer", r.sponsor)
+		r.setField("insurance_carrier", r.carrier)
+		return record
+	}
+
+	if r.member == nil {
+		// Header loops of the transaction set, before the first member
+		if id == "N1" {
+			switch element(1) {
+			case "P5":
+				r.sponsor = element(2)
+			case "IN":
+				r.carrier = element(2)
+			}
+		}
+		return nil
+	}
+
+	r.applyMember(id, element)
+	return nil
+}
+
+// applyMember maps a segment within a member's loops onto its row
+func (r *X12Reader) applyMember(id string, element func(i int) string) {
+	inCoverage := r.coverages > 0
+	inName := r.nameLoop == "IL" && !inCoverage
+
+	switch id {
+	case "REF":
+		switch {
+		case element(1) == "0F" && !inCoverage:
+			r.setField("member_id", element(2))
+		case element(1) == "1L" && !inCoverage:
+			r.setField("group_number", element(2))
+		case element(1) == "1L" && r.coverages == 1:
+			r.setField("policy_number", element(2))
+		}
+	case "DTP":
+		from, to := r.x12DateRange(element(2), element(3))
+		switch {
+		case element(1) == "356" && !inCoverage:
+			r.setFieldIfEmpty("start_date", from)
+		case element(1) == "357" && !inCoverage:
+			r.setFieldIfEmpty("end_date", from)
+		case element(1) == "348" && r.coverages == 1:
+			r.setField("start_date", from)
+			if to != "" {
+				r.setField("end_date", to)
+			}
+		case element(1) == "349" && r.coverages == 1:
+			r.setField("end_date", from)
+		}
+	case "NM1":
+		r.nameLoop = element(1)
+		if r.nameLoop == "IL" && !inCoverage {
+			r.setField("last_name", element(3))
+			r.setField("first_name", element(4))
+			if element(8) == "34" {
+				r.setField("social_security_no", element(9))
+			}
+		}
+	case "PER":
+		if inName {
+			for i := 3; i+1 <= 8; i += 2 {
+				switch element(i) {
+				case "TE", "HP", "WP", "CP":
+					r.setFieldIfEmpty("phone", element(i+1))
+				case "EM":
+					r.setFieldIfEmpty("email", element(i+1))
+				}
+			}
+		}
+	case "N3":
+		if inName {
+			r.setField("address_line_1", element(1))
+			r.setField("address_line_2", element(2))
+		}
+	case "N4":
+		if inName {
+			r.setField("city", element(1))
+			r.setField("state", element(2))
+			r.setField("zip_code", element(3))
+			r.setField("country", element(4))
+		}
+	case "DMG":
+		if inName {
+			from, _ := r.x12DateRange(element(1), element(2))
+			r.setField("birth_date", from)
+		}
+	case "HD":
+		r.coverages++
+		r.nameLoop = ""
+		if r.coverages == 1 {
+			plan := element(4)
+			if plan == "" {
+				plan = element(3)
+			}
+			r.setField("insurance_plan_id", plan)
+		}
+	}
+}
+
+// x12DateRange converts a date or date range from its X12 format qualifier to ISO 8601. Values
+// in formats other than D8 and RD8 are returned unchanged for the date normalizer to judge.
+func (r *X12Reader) x12DateRange(format, value string) (string, string) {
+	switch format {
+	case "D8":
+		return x12Date(value), ""
+	case "RD8":
+		from, to, ok := strings.Cut(value, "-")
+		if !ok {
+			return value, ""
+		}
+		return x12Date(from), x12Date(to)
+	default:
+		return value, ""
+	}
+}
+
+// x12Date rewrites a CCYYMMDD date as YYYY-MM-DD
+func x12Date(value string) string {
+	if len(value) != 8 {
+		return value
+	}
+	if _, err := strconv.Atoi(value); err != nil {
+		return value
+	}
+	return value[:4] + "-" + value[4:6] + "-" + value[6:]
+}
+
+func (r *X12Reader) setField(field, value string) {
+	if pos, ok := r.columnPos[field]; ok && r.member != nil {
+		r.member[pos] = value
+	}
+}
+
+func (r *X12Reader) setFieldIfEmpty(field, value string) {
+	if pos, ok := r.columnPos[field]; ok && r.member != nil && r.member[pos] == "" {
+		r.member[pos] = value
+	}
+}
+
+// finishMember returns the member being read, if any, and clears it
+func (r *X12Reader) finishMember() []string {
+	record := r.member
+	if record != nil {
+		r.returnedPos = r.memberPos
+	}
+	r.member = nil
+	return record
+}
+
+// closeSet reports a transaction set left open when segment starts another envelope
+func (r *X12Reader) closeSet(segment string) []string {
+	record := r.finishMember()
+	if r.set != nil {
+		r.envelopeError(segment, r.set.controlNumber, "transaction set has no SE trailer")
+		r.set = nil
+	}
+	return record
+}
+
+// closeGroup reports a functional group left open when segment starts another envelope
+func (r *X12Reader) closeGroup(segment string) []string {
+	record := r.closeSet(segment)
+	if r.group != nil {
+		r.envelopeError(segment, r.group.controlNumber, "functional group has no GE trailer")
+		r.group = nil
+	}
+	return record
+}
+
+func (r *X12Reader) closeSetTrailer(count, controlNumber string) {
+	if r.set == nil {
+		r.envelopeError("SE", controlNumber, "SE trailer without a transaction set")
+		return
+	}
+	r.checkTrailer("SE", r.set, count, controlNumber, "segments")
+	r.set = nil
+	r.skipSet = false
+}
+
+func (r *X12Reader) closeGroupTrailer(count, controlNumber string) {
+	if r.group == nil {
+		r.envelopeError("GE", controlNumber, "GE trailer without a functional group")
+		return
+	}
+	r.checkTrailer("GE", r.group, count, controlNumber, "transaction sets")
+	r.group = nil
+}
+
+func (r *X12Reader) closeInterchange(count, controlNumber string) {
+	if r.interchange == nil {
+		r.envelopeError("IEA", controlNumber, "IEA trailer without an interchange")
+		return
+	}
+	r.checkTrailer("IEA", r.interchange, count, controlNumber, "functional groups")
+	r.interchange = nil
+}
+
+// checkTrailer compares the count and control number of a trailer against its envelope
+func (r *X12Reader) checkTrailer(segment string, envelope *x12Envelope, count, controlNumber, counted string) {
+	if controlNumber != envelope.controlNumber {
+		r.envelopeError(segment, envelope.controlNumber, fmt.Sprintf(
+			"control number %s does not match %s from the header", controlNumber, envelope.controlNumber))
+	}
+	if expected, err := strconv.Atoi(count); err != nil || expected != envelope.count {
+		r.envelopeError(segment, envelope.controlNumber, fmt.Sprintf(
+			"trailer counts %s %s, found %d", count, counted, envelope.count))
+	}
+}
+
+// reportStray reports a run of segments found outside a transaction set as one problem, at the
+// first of them, as a file framed wrongly would otherwise report every one of its segments
+func (r *X12Reader) reportStray() {
+	if r.stray == 0 {
+		return
+	}
+
+	message := "segment outside a transaction set"
+	if r.stray > 1 {
+		message = fmt.Sprintf("%d segments outside a transaction set", r.stray)
+	}
+	r.envelopeErrorAt(r.strayID, r.strayPos, "", message)
+	r.stray = 0
+}
+
+// closeAll reports every envelope still open at the end of the source
+func (r *X12Reader) closeAll() {
+	r.reportStray()
+	if r.set != nil {
+		r.envelopeError("SE", r.set.controlNumber, "transaction set has no SE trailer")
+		r.set = nil
+	}
+	if r.group != nil {
+		r.envelopeError("GE", r.group.controlNumber, "functional group has no GE trailer")
+		r.group = nil
+	}
+	if r.interchange != nil {
+		r.envelopeError("IEA", r.interchange.controlNumber, "interchange has no IEA trailer")
+		r.interchange = nil
+	}
+}
+
+func (r *X12Reader) envelopeError(segment, controlNumber, message string) {
+	r.envelopeErrorAt(segment, r.position, controlNumber, message)
+}
+
+func (r *X12Reader) envelopeErrorAt(segment string, position int, controlNumber, message string) {
+	if r.onEnvelopeError == nil {
+		return
+	}
+	r.onEnvelopeError(X12EnvelopeError{
+		Segment:       segment,
+		Position:      position,
+		ControlNumber: controlNumber,
+		Message:       message,
+	})
+}
+
+// FieldPos returns the position of the INS segment that started the last member
+func (r *X12Reader) FieldPos(field int) (line, column int) {
+	return r.returnedPos, 1
+}
+
+// InputOffset returns the byte offset just past the last segment read
+func (r *X12Reader) InputOffset() int64 {
+	return r.offset
+}
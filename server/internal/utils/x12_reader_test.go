@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var x12Columns = []string{
+	"birth_date", "start_date", "end_date",
+	"first_name", "last_name", "email", "phone",
+	"address_line_1", "address_line_2", "city", "state", "zip_code", "country",
+	"social_security_no", "employer", "insurance_plan_id", "insurance_carrier",
+	"policy_number", "group_number", "member_id",
+}
+
+// x12Interchange frames segments in an interchange with the given ISA and IEA control numbers,
+// one segment per line
+func x12Interchange(control, trailerControl string, groups int, segments ...string) string {
+	isa := "ISA*00*          *00*          *ZZ*SENDER         *ZZ*RECEIVER       *240101*1200*^*00501*" +
+		control + "*0*P*:~"
+	segments = append(segments, fmt.Sprintf("IEA*%d*%s", groups, trailerControl))
+	return isa + "\n" + strings.Join(segments, "~\n") + "~\n"
+}
+
+// x12Set frames segments in an 834 transaction set whose SE counts them correctly
+func x12Set(control string, segments ...string) []string {
+	set := append([]string{"ST*834*" + control + "*005010X220A1"}, segments...)
+	return append(set, fmt.Sprintf("SE*%d*%s", len(segments)+2, control))
+}
+
+// x12Group frames transaction sets in a functional group whose GE counts them correctly
+func x12Group(control string, sets ...[]string) []string {
+	group := []string{"GS*BE*SENDER*RECEIVER*20240101*1200*" + control + "*X*005010X220A1"}
+	for _, set := range sets {
+		group = append(group, set...)
+	}
+	return append(group, fmt.Sprintf("GE*%d*%s", len(sets), control))
+}
+
+func readX12(t *testing.T, input string) ([][]string, []X12EnvelopeError) {
+	t.Helper()
+
+	var envelopeErrors []X12EnvelopeError
+	reader := NewX12Reader(strings.NewReader(input), x12Columns, func(err X12EnvelopeError) {
+		envelopeErrors = append(envelopeErrors, err)
+	})
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 || !slices.Equal(records[0], x12Columns) {
+		t.Fatalf("Expected the header row first, got %q", records)
+	}
+	return records[1:], envelopeErrors
+}
+
+// x12Member returns the non-empty fields of a row by column
+func x12Member(record []string) map[string]string {
+	member := make(map[string]string)
+	for i, value := range record {
+		if value != "" {
+			member[x12Columns[i]] = value
+		}
+	}
+	return member
+}
+
+func TestX12Reader_Envelopes(t *testing.T) {
+	member := []string{"INS*Y*18*021*28*A", "REF*0F*M001"}
+
+	testCases := []struct {
+		name            string
+		input           string
+		expectedMembers int
+		expectedErrors  []X12EnvelopeError
+	}{
+		{
+			"valid",
+			x12Interchange("000000001", "000000001", 1, x12Group("1", x12Set("0001", member...))...),
+			1,
+			nil,
+		},
+		{
+			"SE segment count",
+			x12Interchange("000000001", "000000001", 1,
+				x12Group("1", []string{"ST*834*0001", "INS*Y*18", "SE*5*0001"})...),
+			1,
+			[]X12EnvelopeError{{Segment: "SE", Position: 5, ControlNumber: "0001", Message: "trailer counts 5 segments, found 3"}},
+		},
+		{
+			"SE control number",
+			x12Interchange("000000001", "000000001", 1,
+				x12Group("1", []string{"ST*834*0001", "INS*Y*18", "SE*3*0002"})...),
+			1,
+			[]X12EnvelopeError{{Segment: "SE", Position: 5, ControlNumber: "0001", Message: "control number 0002 does not match 0001 from the header"}},
+		},
+		{
+			"GE set count",
+			x12Interchange("000000001", "000000001", 1,
+				append(x12Group("1", x12Set("0001", member...))[:5], "GE*2*1")...),
+			1,
+			[]X12EnvelopeError{{Segment: "GE", Position: 7, ControlNumber: "1", Message: "trailer counts 2 transaction sets, found 1"}},
+		},
+		{
+			"GE control number",
+			x12Interchange("000000001", "000000001", 1,
+				append(x12Group("1", x12Set("0001", member...))[:5], "GE*1*9")...),
+			1,
+			[]X12EnvelopeError{{Segment: "GE", Position: 7, ControlNumber: "1", Message: "control number 9 does not match 1 from the header"}},
+		},
+		{
+			"IEA group count",
+			x12Interchange("000000001", "000000001", 3, x12Group("1", x12Set("0001", member...))...),
+			1,
+			[]X12EnvelopeError{{Segment: "IEA", Position: 8, ControlNumber: "000000001", Message: "trailer counts 3 functional groups, found 1"}},
+		},
+		{
+			"IEA control number",
+			x12Interchange("000000001", "000000002", 1, x12Group("1", x12Set("0001", member...))...),
+			1,
+			[]X12EnvelopeError{{Segment: "IEA", Position: 8, ControlNumber: "000000001", Message: "control number 000000002 does not match 000000001 from the header"}},
+		},
+		{
+			"missing SE",
+			x12Interchange("000000001", "000000001", 1,
+				x12Group("1", []string{"ST*834*0001", "INS*Y*18"}, x12Set("0002", member...))...),
+			2,
+			[]X12EnvelopeError{{Segment: "ST", Position: 5, ControlNumber: "0001", Message: "transaction set has no SE trailer"}},
+		},
+		{
+			"missing trailers at the end",
+			"ISA*00*          *00*          *ZZ*SENDER         *ZZ*RECEIVER       *240101*1200*^*00501*000000001*0*P*:~\n" +
+				"GS*BE*SENDER*RECEIVER*20240101*1200*1*X*005010X220A1~\nST*834*0001~\nINS*Y*18~\n",
+			1,
+			[]X12EnvelopeError{
+				{Segment: "SE", Position: 4, ControlNumber: "0001", Message: "transaction set has no SE trailer"},
+				{Segment: "GE", Position: 4, ControlNumber: "1", Message: "functional group has no GE trailer"},
+				{Segment: "IEA", Position: 4, ControlNumber: "000000001", Message: "interchange has no IEA trailer"},
+			},
+		},
+		{
+			"non-834 set is skipped",
+			x12Interchange("000000001", "000000001", 1,
+				x12Group("1", []string{"ST*270*0001", "INS*Y*18", "REF*0F*SKIPPED", "SE*4*0001"}, x12Set("0002", member...))...),
+			1,
+			[]X12EnvelopeError{{Segment: "ST", Position: 3, ControlNumber: "0001", Message: "transaction set 270 is not an 834, skipped"}},
+		},
+		{
+			"single segment outside a set",
+			x12Interchange("000000001", "000000001", 1,
+				append(x12Group("1", x12Set("0001", member...))[:5], "REF*0F*LOST", "GE*1*1")...),
+			1,
+			[]X12EnvelopeError{{Segment: "REF", Position: 7, Message: "segment outside a transaction set"}},
+		},
+		{
+			"run of segments outside a set is reported once",
+			x12Interchange("000000001", "000000001", 1,
+				append(x12Group("1", x12Set("0001", member...))[:5], "INS*Y*18", "REF*0F*LOST", "NM1*IL*1*LEE", "GE*1*1")...),
+			1,
+			[]X12EnvelopeError{{Segment: "INS", Position: 7, Message: "3 segments outside a transaction set"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, envelopeErrors := readX12(t, tc.input)
+			if len(records) != tc.expectedMembers {
+				t.Errorf("Expected %d members, got %d", tc.expectedMembers, len(records))
+			}
+			if !slices.Equal(envelopeErrors, tc.expectedErrors) {
+				t.Errorf("Expected envelope errors %+v, got %+v", tc.expectedErrors, envelopeErrors)
+			}
+		})
+	}
+}
+
+func TestX12Reader_Members(t *testing.T) {
+	input := x12Interchange("000000001", "000000001", 1, x12Group("1", x12Set("0001",
+		"BGN*00*REF1*20240101*1200****2",
+		"N1*P5*ACME CORP*FI*123456789",
+		"N1*IN*BLUE CROSS*FI*987654321",
+		"INS*Y*18*021*28*A***FT",
+		"REF*0F*M001",
+		"REF*1L*G100",
+		"DTP*356*D8*20240101",
+		"NM1*IL*1*LEE*ANN*M***34*123456789",
+		"PER*IP**TE*5551234567*EM*ann@example.com",
+		"N3*1 MAIN ST*APT 2",
+		"N4*BOISE*ID*83702*US",
+		"DMG*D8*19850701*F",
+		"NM1*31*1",
+		"N3*PO BOX 9",
+		"HD*021**HLT*PLAN-A*EMP",
+		"DTP*348*RD8*20240201-20241231",
+		"REF*1L*POL-1",
+		"HD*021**DEN*PLAN-B*EMP",
+		"DTP*348*D8*20240301",
+		"REF*1L*POL-2",
+		"INS*N*19*021*28*A",
+		"REF*0F*M002",
+		"NM1*IL*1*LEE*SAM",
+		"DMG*D8*2010",
+		"HD*021**HLT",
+		"DTP*348*D8*20240101",
+		"DTP*349*D8*20241231",
+	))...)
+
+	records, envelopeErrors := readX12(t, input)
+	if len(envelopeErrors) != 0 {
+		t.Errorf("Expected no envelope errors, got %+v", envelopeErrors)
+	}
+
+	expected := []map[string]string{
+		{
+			"member_id":          "M001",
+			"group_number":       "G100",
+			"last_name":          "LEE",
+			"first_name":         "ANN",
+			"social_security_no": "123456789",
+			"phone":              "5551234567",
+			"email":              "ann@example.com",
+			"address_line_1":     "1 MAIN ST",
+			"address_line_2":     "APT 2",
+			"city":               "BOISE",
+			"state":              "ID",
+			"zip_code":           "83702",
+			"country":            "US",
+			"birth_date":         "1985-07-01",
+			"insurance_plan_id":  "PLAN-A",
+			"policy_number":      "POL-1",
+			"start_date":         "2024-02-01",
+			"end_date":           "2024-12-31",
+			"employer":           "ACME CORP",
+			"insurance_carrier":  "BLUE CROSS",
+		},
+		{
+			"member_id":         "M002",
+			"last_name":         "LEE",
+			"first_name":        "SAM",
+			"birth_date":        "2010",
+			"insurance_plan_id": "HLT",
+			"start_date":        "2024-01-01",
+			"end_date":          "2024-12-31",
+			"employer":          "ACME CORP",
+			"insurance_carrier": "BLUE CROSS",
+		},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d members, got %d", len(expected), len(records))
+	}
+	for i, record := range records {
+		member := x12Member(record)
+		for column, value := range expected[i] {
+			if member[column] != value {
+				t.Errorf("Member %d: expected %s %q, got %q", i+1, column, value, member[column])
+			}
+		}
+		for column, value := range member {
+			if _, ok := expected[i][column]; !ok {
+				t.Errorf("Member %d: unexpected %s %q", i+1, column, value)
+			}
+		}
+	}
+}
+
+func TestX12Reader_MemberPosition(t *testing.T) {
+	input := x12Interchange("000000001", "000000001", 1, x12Group("1", x12Set("0001",
+		"INS*Y*18", "REF*0F*M001",
+		"INS*Y*18", "REF*0F*M002",
+	))...)
+
+	reader := NewX12Reader(strings.NewReader(input), x12Columns, nil)
+	expected := []int{0, 4, 6}
+	for i, position := range expected {
+		if _, err := reader.Read(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if i == 0 {
+			continue
+		}
+		if line, _ := reader.FieldPos(0); line != position {
+			t.Errorf("Expected member %d at segment %d, got %d", i, position, line)
+		}
+	}
+}
+
+func TestX12Reader_NotX12(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"whitespace", "  \n\n"},
+		{"csv", "first_name,last_name\nAnn,Lee\n"},
+		{"short ISA", "ISA*00*~"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewX12Reader(strings.NewReader(tc.input), x12Columns, nil)
+			if _, err := reader.Read(); err != nil {
+				t.Fatalf("Expected the header row, got %v", err)
+			}
+			if _, err := reader.Read(); err == nil || !strings.Contains(err.Error(), "not an X12 interchange") {
+				t.Errorf("Expected a not an X12 interchange error, got %v", err)
+			}
+		})
+	}
+}
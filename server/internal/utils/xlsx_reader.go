@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxMaxHeaderRow is the furthest row a header row is looked for
+const xlsxMaxHeaderRow = 20
+
+// XLSXReader reads the rows of one worksheet of an Excel workbook. Cells are read as their raw
+// values rather than as displayed, so numbers keep full precision, and numeric cells in date
+// columns are converted from Excel serial dates to ISO 8601. Blank rows are skipped, and rows
+// are padded to the width of the header.
+//
+// The workbook is opened on the first Read; its worksheets are streamed from temporary files
+// once they outgrow excelize's in-memory limit, which are removed when the reader hits io.EOF
+// or an error.
+type XLSXReader struct {
+	input       io.Reader
+	sheet       string
+	headerRow   int
+	dateColumns func(headers []string) []bool
+
+	file     *excelize.File
+	rows     *excelize.Rows
+	date1904 bool
+	isDate   []bool
+	width    int
+	buffered [][]string
+	rowNums  []int
+	row      int
+	done     bool
+}
+
+// NewXLSXReader creates an XLSXReader for the worksheet named sheet. A sheet that matches no name
+// but is a number selects the worksheet at that one-based position, and an empty sheet selects
+// the first. headerRow is the zero-based row of the header, or -1 to detect it from the first
+// rows of the worksheet, skipping title rows above it. dateColumns reports which columns of the
+// header hold dates.
+func NewXLSXReader(
+	input io.Reader,
+	sheet string,
+	headerRow int,
+	dateColumns func(headers []string) []bool,
+) *XLSXReader {
+	return &XLSXReader{
+		input:       input,
+		sheet:       sheet,
+		headerRow:   headerRow,
+		dateColumns: dateColumns,
+	}
+}
+
+// Read returns the header row first, then one row per non-blank worksheet row
+func (r *XLSXReader) Read() ([]string, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.file == nil {
+		headers, err := r.open()
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		return headers, nil
+	}
+
+	for {
+		record, err := r.next()
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		if isBlankRow(record) {
+			continue
+		}
+		return r.convert(record), nil
+	}
+}
+
+// open reads the workbook, selects the worksheet and returns its header row
+func (r *XLSXReader) open() ([]string, error) {
+	file, err := excelize.OpenReader(r.input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	r.file = file
+
+	if props, err := file.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		r.date1904 = *props.Date1904
+	}
+
+	sheet, err := r.sheetName()
+	if err != nil {
+		return nil, err
+	}
+	if r.rows, err = file.Rows(sheet); err != nil {
+		return nil, fmt.Errorf("failed to read worksheet %s: %w", sheet, err)
+	}
+
+	// Buffer the rows the header may be among, then replay the rows after it
+	for len(r.buffered) <= max(r.headerRow, xlsxMaxHeaderRow) {
+		record, err := r.readRow()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.buffered = append(r.buffered, record)
+		r.rowNums = append(r.rowNums, r.row)
+	}
+
+	header := r.headerRow
+	if header < 0 {
+		header = detectHeaderRow(r.buffered)
+	}
+	if header >= len(r.buffered) {
+		return nil, fmt.Errorf("worksheet %s has no row %d", sheet, header+1)
+	}
+
+	headers := r.buffered[header]
+	for len(headers) > 0 && strings.TrimSpace(headers[len(headers)-1]) == "" {
+		headers = headers[:len(headers)-1]
+	}
+	r.width = len(headers)
+	r.row = r.rowNums[header]
+	r.buffered = r.buffered[header+1:]
+	r.rowNums = r.rowNums[header+1:]
+
+	r.isDate = make([]bool, r.width)
+	if r.dateColumns != nil {
+		copy(r.isDate, r.dateColumns(headers))
+	}
+	return slices.Clone(headers), nil
+}
+
+// sheetName resolves the requested worksheet
+func (r *XLSXReader) sheetName() (string, error) {
+	sheets := r.file.GetSheetList()
+	if len(sheets) == 0 {
+		return "", errors.New("workbook has no worksheets")
+	}
+	if r.sheet == "" {
+		return sheets[0], nil
+	}
+	if slices.Contains(sheets, r.sheet) {
+		return r.sheet, nil
+	}
+	if position, err := strconv.Atoi(r.sheet); err == nil && position >= 1 && position <= len(sheets) {
+		return sheets[position-1], nil
+	}
+	return "", fmt.Errorf("worksheet not found: %s (sheets: %s)", r.sheet, strings.Join(sheets, ", "))
+}
+
+// next returns the next row after the header, replaying buffered rows first
+func (r *XLSXReader) next() ([]string, error) {
+	if len(r.buffered) > 0 {
+		record := r.buffered[0]
+		r.row = r.rowNums[0]
+		r.buffered, r.rowNums = r.buffered[1:], r.rowNums[1:]
+		return record, nil
+	}
+	return r.readRow()
+}
+
+func (r *XLSXReader) readRow() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r.row++
+	return r.rows.Columns(excelize.Options{RawCellValue: true})
+}
+
+// convert fits a row to the header width and converts serial dates in date columns. Only empty
+// cells are dropped, so a row with values past the header is still reported as too wide.
+func (r *XLSXReader) convert(record []string) []string {
+	for len(record) > r.width && strings.TrimSpace(record[len(record)-1]) == "" {
+		record = record[:len(record)-1]
+	}
+	if len(record) < r.width {
+		record = append(record, make([]string, r.width-len(record))...)
+	}
+	for i, isDate := range r.isDate {
+		if isDate {
+			if converted, ok := excelSerialDate(record[i], r.date1904); ok {
+				record[i] = converted
+			}
+		}
+	}
+	return record
+}
+
+func (r *XLSXReader) close() {
+	r.done = true
+	if r.rows != nil {
+		_ = r.rows.Close()
+	}
+	if r.file != nil {
+		_ = r.file.Close()
+	}
+}
+
+// FieldPos returns the worksheet row number of the last row. Every field of a row is on it.
+func (r *XLSXReader) FieldPos(field int) (line, column int) {
+	return r.row, field + 1
+}
+
+// InputOffset returns 0, as a workbook is read whole and cannot be resumed part way through
+func (r *XLSXReader) InputOffset() int64 {
+	return 0
+}
+
+// detectHeaderRow returns the first row with at least the most common number of filled cells, so
+// title and note rows above a table are skipped while a header wider than sparse data rows is not
+func detectHeaderRow(rows [][]string) int {
+	counts := make([]int, len(rows))
+	frequency := make(map[int]int)
+	for i, row := range rows {
+		for _, value := range row {
+			if strings.TrimSpace(value) != "" {
+				counts[i]++
+			}
+		}
+		if counts[i] > 0 {
+			frequency[counts[i]]++
+		}
+	}
+
+	mode, seen := 0, 0
+	for count, rows := range frequency {
+		if rows > seen || (rows == seen && count > mode) {
+			mode, seen = count, rows
+		}
+	}
+	for i, count := range counts {
+		if count > 0 && count >= mode {
+			return i
+		}
+	}
+	return 0
+}
+
+func isBlankRow(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// excelSerialDate converts an Excel serial date to "2006-01-02", or to RFC 3339 when it has a
+// time of day
+func excelSerialDate(value string, date1904 bool) (string, bool) {
+	serial, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return "", false
+	}
+	parsed, ok := ExcelSerialToTime(serial, date1904)
+	if !ok {
+		return "", false
+	}
+	if parsed.Equal(parsed.Truncate(24 * time.Hour)) {
+		return parsed.Format(time.DateOnly), true
+	}
+	return parsed.Format(time.RFC3339), true
+}
@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxWorkbook builds a workbook with a worksheet per entry of sheets, in order, each holding its
+// rows from A1
+func xlsxWorkbook(t *testing.T, date1904 bool, sheets []string, rows map[string][][]any) *bytes.Buffer {
+	t.Helper()
+
+	file := excelize.NewFile()
+	defer file.Close()
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+				t.Fatalf("Failed to name worksheet: %v", err)
+			}
+		} else if _, err := file.NewSheet(sheet); err != nil {
+			t.Fatalf("Failed to add worksheet: %v", err)
+		}
+		for j, row := range rows[sheet] {
+			cell, _ := excelize.CoordinatesToCellName(1, j+1)
+			if err := file.SetSheetRow(sheet, cell, &row); err != nil {
+				t.Fatalf("Failed to write row: %v", err)
+			}
+		}
+	}
+	if date1904 {
+		if err := file.SetWorkbookProps(&excelize.WorkbookPropsOptions{Date1904: &date1904}); err != nil {
+			t.Fatalf("Failed to set date1904: %v", err)
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("Failed to write workbook: %v", err)
+	}
+	return buf
+}
+
+// birthDateColumn marks the birth_date column as holding dates
+func birthDateColumn(headers []string) []bool {
+	isDate := make([]bool, len(headers))
+	for i, header := range headers {
+		isDate[i] = header == "birth_date"
+	}
+	return isDate
+}
+
+func readXLSX(reader *XLSXReader) ([][]string, []int, error) {
+	var records [][]string
+	var rowNums []int
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, rowNums, nil
+		}
+		if err != nil {
+			return records, rowNums, err
+		}
+		records = append(records, record)
+		line, _ := reader.FieldPos(0)
+		rowNums = append(rowNums, line)
+	}
+}
+
+func TestDetectHeaderRow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rows     [][]string
+		expected int
+	}{
+		{"header first", [][]string{{"a", "b", "c"}, {"1", "2", "3"}, {"4", "5", "6"}}, 0},
+		{"title rows", [][]string{{"Member Export"}, {"Generated 2024-01-01"}, {"a", "b", "c"}, {"1", "2", "3"}}, 2},
+		{"blank rows above", [][]string{{}, {"", " "}, {"a", "b"}, {"1", "2"}}, 2},
+		{"header wider than sparse rows", [][]string{{"a", "b", "c"}, {"1", ""}, {"2", ""}, {"3", "x"}}, 0},
+		{"title with a note beside it", [][]string{{"Export", "", "draft"}, {"a", "b", "c"}, {"1", "2", "3"}, {"4", "5", "6"}}, 1},
+		{"empty", nil, 0},
+		{"all blank", [][]string{{""}, {" "}}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := detectHeaderRow(tc.rows)
+			if result != tc.expected {
+				t.Errorf("Expected header row %d, got %d", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestXLSXReader_SheetSelection(t *testing.T) {
+	sheets := []string{"Summary", "1", "Members"}
+	workbook := xlsxWorkbook(t, false, sheets, map[string][][]any{
+		"Summary": {{"sheet"}, {"Summary"}},
+		"1":       {{"sheet"}, {"named 1"}},
+		"Members": {{"sheet"}, {"Members"}},
+	})
+
+	testCases := []struct {
+		sheet       string
+		expected    string
+		expectedErr string
+	}{
+		{"", "Summary", ""},
+		{"Members", "Members", ""},
+		{"1", "named 1", ""}, // A name is matched before a position
+		{"3", "Members", ""},
+		{"2", "named 1", ""},
+		{"0", "", "worksheet not found: 0"},
+		{"4", "", "worksheet not found: 4"},
+		{"members", "", "worksheet not found: members"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.sheet, func(t *testing.T) {
+			reader := NewXLSXReader(bytes.NewReader(workbook.Bytes()), tc.sheet, -1, nil)
+			records, _, err := readXLSX(reader)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("Expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(records) != 2 || records[1][0] != tc.expected {
+				t.Errorf("Expected worksheet %q, got %q", tc.expected, records)
+			}
+		})
+	}
+}
+
+func TestXLSXReader_Rows(t *testing.T) {
+	workbook := xlsxWorkbook(t, false, []string{"Members"}, map[string][][]any{
+		"Members": {
+			{"Member Export"},
+			{},
+			{"first_name", "birth_date", "salary", ""},
+			{"Ann", 31229, 52000.5},
+			{},
+			{"Bob"},
+			{"Cy", "1990-01-02", 0.1, nil, "extra"},
+		},
+	})
+
+	testCases := []struct {
+		name            string
+		headerRow       int
+		expectedRecords [][]string
+		expectedRowNums []int
+	}{
+		{
+			"detected header",
+			-1,
+			[][]string{
+				{"first_name", "birth_date", "salary"},
+				{"Ann", "1985-07-01", "52000.5"},
+				{"Bob", "", ""},
+				{"Cy", "1990-01-02", "0.1", "", "extra"},
+			},
+			[]int{3, 4, 6, 7},
+		},
+		{
+			"explicit header",
+			0,
+			[][]string{
+				{"Member Export"},
+				{"first_name"},
+				{"Ann"},
+				{"Bob"},
+				{"Cy"},
+			},
+			[]int{1, 3, 4, 6, 7},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewXLSXReader(bytes.NewReader(workbook.Bytes()), "", tc.headerRow, birthDateColumn)
+			records, rowNums, err := readXLSX(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tc.headerRow == 0 {
+				// Only the first column is compared, as the rest do not fit the one-column header
+				for i := range records {
+					records[i] = records[i][:1]
+				}
+			}
+			if !slices.EqualFunc(records, tc.expectedRecords, slices.Equal) {
+				t.Errorf("Expected records %q, got %q", tc.expectedRecords, records)
+			}
+			if !slices.Equal(rowNums, tc.expectedRowNums) {
+				t.Errorf("Expected rows %v, got %v", tc.expectedRowNums, rowNums)
+			}
+		})
+	}
+
+	reader := NewXLSXReader(bytes.NewReader(workbook.Bytes()), "", 40, nil)
+	if _, err := reader.Read(); err == nil || !strings.Contains(err.Error(), "has no row 41") {
+		t.Errorf("Expected a missing header row error, got %v", err)
+	}
+}
+
+func TestXLSXReader_SerialDates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		date1904 bool
+		serial   any
+		expected string
+	}{
+		{"1900 date", false, 45292, "2024-01-01"},
+		{"1900 date and time", false, 45292.5, "2024-01-01T12:00:00Z"},
+		{"1900 before the leap year bug", false, 59, "1900-02-28"},
+		{"1904 date", true, 43830, "2024-01-01"},
+		{"1904 date and time", true, 43830.25, "2024-01-01T06:00:00Z"},
+		{"1904 epoch", true, 0, "1904-01-01"},
+		{"text is left alone", false, "01/02/2024", "01/02/2024"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			workbook := xlsxWorkbook(t, tc.date1904, []string{"Members"}, map[string][][]any{
+				"Members": {
+					{"birth_date", "member_id"},
+					{tc.serial, tc.serial},
+				},
+			})
+
+			reader := NewXLSXReader(workbook, "", -1, birthDateColumn)
+			records, _, err := readXLSX(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(records) != 2 {
+				t.Fatalf("Expected a header and one row, got %q", records)
+			}
+			if records[1][0] != tc.expected {
+				t.Errorf("Expected birth_date %q, got %q", tc.expected, records[1][0])
+			}
+			if raw := fmt.Sprint(tc.serial); records[1][1] != raw {
+				t.Errorf("Expected member_id to keep its raw value %q, got %q", raw, records[1][1])
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ const (
 	MESSAGE_TYPE_LOADTEST_PROGRESS  = "loadtest_progress"
 	MESSAGE_TYPE_LOADTEST_COMPLETE  = "loadtest_complete"
 	MESSAGE_TYPE_LOADTEST_ERROR     = "loadtest_error"
+	MESSAGE_TYPE_LOADTEST_CANCELLED = "loadtest_cancelled"
 	PING_INTERVAL                   = 30 * time.Second
 	PONG_TIMEOUT                    = 60 * time.Second
 	WRITE_TIMEOUT                   = 10 * time.Second
@@ -88,6 +89,7 @@ func New(
 
 	go manager.subscribeToBroadcastEvents()
 	go manager.subscribeToCacheInvalidationEvents()
+	go manager.subscribeToLoadTestEvents()
 
 	return manager, nil
 }
@@ -499,60 +501,72 @@ func (m *Manager) BroadcastCacheInvalidation(
 	)
 }
 
-// SendLoadTestProgress sends load test progress updates to authenticated clients
+// SendLoadTestProgress sends load test progress updates to authenticated clients on every instance
 func (m *Manager) SendLoadTestProgress(testID string, data map[string]any) {
-	log := m.log.Function("SendLoadTestProgress")
-
-	message := Message{
-		ID:        uuid.New().String(),
-		Type:      MESSAGE_TYPE_LOADTEST_PROGRESS,
-		Channel:   "loadtest",
-		Action:    "progress",
-		Data:      data,
-		Timestamp: time.Now(),
-	}
-
-	message.Data["testId"] = testID
-
-	m.sendToAuthenticatedClients(message)
-	log.Info("Load test progress sent", "testId", testID, "messageID", message.ID)
+	data["testId"] = testID
+	m.publishLoadTestEvent(MESSAGE_TYPE_LOADTEST_PROGRESS, testID, data)
 }
 
-// SendLoadTestComplete sends load test completion notification to authenticated clients
+// SendLoadTestComplete sends load test completion notification to authenticated clients on every instance
 func (m *Manager) SendLoadTestComplete(testID string, testResult map[string]any) {
-	log := m.log.Function("SendLoadTestComplete")
-
-	message := Message{
-		ID:        uuid.New().String(),
-		Type:      MESSAGE_TYPE_LOADTEST_COMPLETE,
-		Channel:   "loadtest",
-		Action:    "complete",
-		Data:      testResult,
-		Timestamp: time.Now(),
-	}
+	testResult["testId"] = testID
+	m.publishLoadTestEvent(MESSAGE_TYPE_LOADTEST_COMPLETE, testID, testResult)
+}
 
-	message.Data["testId"] = testID
+// SendLoadTestError sends load test error notification to authenticated clients on every instance
+func (m *Manager) SendLoadTestError(testID string, errorMsg string) {
+	m.publishLoadTestEvent(MESSAGE_TYPE_LOADTEST_ERROR, testID, map[string]any{
+		"testId": testID,
+		"error":  errorMsg,
+	})
+}
 
-	m.sendToAuthenticatedClients(message)
-	log.Info("Load test completion sent", "testId", testID, "messageID", message.ID)
+// SendLoadTestCancelled sends load test cancellation notification to authenticated clients on every instance
+func (m *Manager) SendLoadTestCancelled(testID string, data map[string]any) {
+	data["testId"] = testID
+	m.publishLoadTestEvent(MESSAGE_TYPE_LOADTEST_CANCELLED, testID, data)
 }
 
-// SendLoadTestError sends load test error notification to authenticated clients
-func (m *Manager) SendLoadTestError(testID string, errorMsg string) {
-	log := m.log.Function("SendLoadTestError")
+// publishLoadTestEvent relays a load test message through the event bus, since the browser
+// watching a test may be connected to a different instance than the one running it
+func (m *Manager) publishLoadTestEvent(messageType string, testID string, data map[string]any) {
+	if err := m.eventBus.PublishLoadTestEvent(messageType, data); err != nil {
+		m.log.Function("publishLoadTestEvent").
+			Er("failed to publish load test event", err, "testId", testID, "type", messageType)
+	}
+}
 
-	message := Message{
-		ID:        uuid.New().String(),
-		Type:      MESSAGE_TYPE_LOADTEST_ERROR,
-		Channel:   "loadtest",
-		Action:    "error",
-		Data: map[string]any{
-			"testId": testID,
-			"error":  errorMsg,
-		},
-		Timestamp: time.Now(),
+// subscribeToLoadTestEvents delivers load test messages published by any instance to this
+// instance's authenticated clients
+func (m *Manager) subscribeToLoadTestEvents() {
+	log := m.log.Function("subscribeToLoadTestEvents")
+	log.Info("Starting load test events subscription")
+
+	actions := map[string]string{
+		MESSAGE_TYPE_LOADTEST_PROGRESS:  "progress",
+		MESSAGE_TYPE_LOADTEST_COMPLETE:  "complete",
+		MESSAGE_TYPE_LOADTEST_ERROR:     "error",
+		MESSAGE_TYPE_LOADTEST_CANCELLED: "cancelled",
 	}
 
-	m.sendToAuthenticatedClients(message)
-	log.Info("Load test error sent", "testId", testID, "messageID", message.ID, "error", errorMsg)
+	err := m.eventBus.SubscribeOrdered(events.LOAD_TEST_CHANNEL, func(event events.Event) error {
+		action, ok := actions[event.Type]
+		if !ok {
+			log.Warn("Unknown load test event type", "eventID", event.ID, "eventType", event.Type)
+			return nil
+		}
+
+		m.sendToAuthenticatedClients(Message{
+			ID:        event.ID,
+			Type:      event.Type,
+			Channel:   "loadtest",
+			Action:    action,
+			Data:      event.Data,
+			Timestamp: event.Timestamp,
+		})
+		return nil
+	})
+	if err != nil {
+		log.Er("Failed to subscribe to load test events", err)
+	}
 }